@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"math"
+	"sync"
 	"time"
 
 	"golang.org/x/text/encoding/charmap"
@@ -16,77 +17,251 @@ var (
 	ErrEmptySessionData = errors.New("Empty session data")
 )
 
+// NewConnection creates a connection to the shared memory of a running sim
 func NewConnection() (*Connection, error) {
-	sdk, err := utils.NewIrsdk()
+	src, err := NewLiveSource()
 	if err != nil {
 		return nil, err
 	}
 
-	conn := &Connection{
+	return NewConnectionFromSource(src), nil
+}
+
+// NewConnectionFromSource creates a connection that reads from any Source
+// (.ibt file, network stream, ...)
+func NewConnectionFromSource(src Source) *Connection {
+	return &Connection{
 		timeout: time.Millisecond * time.Duration(math.Ceil(1000.0/60.0)+1.0),
-		sdk:     sdk,
+		src:     src,
 	}
-
-	return conn, nil
 }
 
+// Connection reads telemetry and session data from a Source. It's safe for
+// concurrent use; reads from the source are serialized.
 type Connection struct {
+	mu sync.Mutex
+
 	timeout        time.Duration
-	sdk            *utils.Irsdk
+	src            Source
+	opened         bool
 	maxFPS         int
 	lastUpdateTime time.Time
+
+	schema       *Schema
+	schemaLayout schemaLayout
+
+	decoder         *Decoder
+	filteredDecoder *Decoder
+	filteredFields  []string
+
+	subsMu  sync.Mutex
+	subs    []*subscriber
+	pumping bool
+
+	session sessionCache
+
+	// disconnected is set when the source reported utils.ErrDisconnected
+	disconnected bool
+}
+
+// schemaLayout is the part of the header the schema depends on; when it
+// changes (the sim loaded another car) the schema is rebuilt
+type schemaLayout struct {
+	numVars         int32
+	varHeaderOffset int32
+	bufLen          int32
 }
 
 func (c *Connection) Connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// If connection was once established: clean it up
-	nilTime := time.Time{}
-	if c.sdk.GetLastValidTime() != nilTime {
-		c.Disconnect()
+	if c.opened {
+		c.disconnect()
 	}
 
-	return c.sdk.Startup()
+	err := c.src.Open()
+	if err != nil {
+		return err
+	}
+
+	c.opened = true
+	c.schema = nil
+	c.disconnected = false
+	c.resetSessionCache()
+	return nil
 }
 
 func (c *Connection) IsConnected() bool {
-	return c.sdk.IsConnected()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.src.IsConnected()
+}
+
+func (c *Connection) GetSource() Source {
+	return c.src
 }
 
 func (c *Connection) GetHeader() (*utils.Header, error) {
-	return c.sdk.GetHeader()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.src.Header()
 }
 
-func (c *Connection) GetRawTelemetryData() ([]byte, error) {
-	return c.WaitForDataReady(c.timeout)
+func (c *Connection) GetVarHeaders() ([]*utils.VarHeader, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.src.VarHeaders()
 }
 
-func (c *Connection) GetTelemetryData() (*TelemetryData, error) {
-	data, err := c.GetRawTelemetryData()
+// GetSchema returns the schema describing every variable the source publishes.
+// It's cached until the layout of the var buffers changes.
+func (c *Connection) GetSchema() (*Schema, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.getSchema()
+}
+
+func (c *Connection) getSchema() (*Schema, error) {
+	header, err := c.src.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	layout := schemaLayout{}
+	if header != nil {
+		layout = schemaLayout{
+			numVars:         header.NumVars,
+			varHeaderOffset: header.VarHeaderOffset,
+			bufLen:          header.BufLen,
+		}
+	}
+
+	if c.schema != nil && c.schemaLayout == layout {
+		return c.schema, nil
+	}
+
+	varHeaders, err := c.src.VarHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := NewSchema(varHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	c.schema = schema
+	c.schemaLayout = layout
+	return c.schema, nil
+}
+
+// getDecoder returns the decoder for the current layout, compiling it when the
+// layout changed. Decoders for a set of fields are cached until fields change.
+// The caller must hold c.mu.
+func (c *Connection) getDecoder(fields []string) (*Decoder, error) {
+	schema, err := c.getSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		if c.decoder == nil || c.decoder.Schema() != schema {
+			c.decoder = NewDecoder(schema)
+		}
+		return c.decoder, nil
+	}
+
+	if c.filteredDecoder == nil || c.filteredDecoder.Schema() != schema || !equalStrings(c.filteredFields, fields) {
+		c.filteredDecoder = NewDecoderFiltered(schema, fields)
+		c.filteredFields = append([]string{}, fields...)
+	}
+
+	return c.filteredDecoder, nil
+}
+
+// GetFrame waits for the next tick and returns it as a Frame, which gives
+// access to every variable, including the ones TelemetryData doesn't know.
+// Frames own their data, so they can be kept or sent to other goroutines.
+func (c *Connection) GetFrame() (*Frame, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := c.waitForDataReady(c.timeout)
 	if err != nil {
 		return nil, err
 	}
 
-	if data != nil {
-		return c.BytesToTelemetryStruct(data)
+	if data == nil {
+		return nil, nil
+	}
+
+	schema, err := c.getSchema()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, nil
+	frame := schema.NewFrame(data)
+	if tc, ok := c.src.(tickCounter); ok {
+		frame.TickCount = tc.LastTickCount()
+	}
+
+	return frame, nil
 }
 
+func (c *Connection) GetRawTelemetryData() ([]byte, error) {
+	return c.WaitForDataReady(c.timeout)
+}
+
+// GetTelemetryData waits for the next tick and decodes it. Every call returns
+// a new TelemetryData, so earlier results stay valid.
+func (c *Connection) GetTelemetryData() (*TelemetryData, error) {
+	return c.GetTelemetryDataFiltered(nil)
+}
+
+// GetTelemetryDataFiltered is GetTelemetryData, but only decodes the variables
+// in fields
 func (c *Connection) GetTelemetryDataFiltered(fields []string) (*TelemetryData, error) {
-	data, err := c.WaitForDataReady(c.timeout)
+	c.mu.Lock()
+	data, err := c.waitForDataReady(c.timeout)
+	if err != nil || data == nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	decoder, err := c.getDecoder(fields)
+	c.mu.Unlock()
 	if err != nil {
 		return nil, err
 	}
 
-	if data != nil {
-		return c.BytesToTelemetryStructFiltered(data, fields), nil
+	td := NewTelemetryData()
+	err = decoder.Decode(data, td)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, nil
+	return td, nil
 }
 
 func (c *Connection) GetRawSessionData() ([]byte, error) {
-	b := c.sdk.GetSessionInfoStr()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.getRawSessionData()
+}
+
+func (c *Connection) getRawSessionData() ([]byte, error) {
+	b, err := c.src.SessionInfo()
+	if err != nil {
+		return nil, err
+	}
+
 	if b == nil {
 		return nil, nil
 	}
@@ -100,18 +275,21 @@ func (c *Connection) GetRawSessionData() ([]byte, error) {
 	return b, nil
 }
 
+// GetSessionData returns the parsed session info. It's only parsed again when
+// SessionInfoUpdate changed, so the result is shared between calls and must
+// not be modified.
 func (c *Connection) GetSessionData() (*SessionData, error) {
-	b, err := c.GetRawSessionData()
-	if err != nil {
-		return nil, err
-	}
+	c.mu.Lock()
+	update, ok := c.sessionInfoUpdate()
+	c.mu.Unlock()
 
-	if b == nil {
-		return nil, ErrEmptySessionData
+	if ok {
+		if data := c.cachedSessionData(update); data != nil {
+			return data, nil
+		}
 	}
 
-	b = bytesToUtf8(b)
-	return NewSessionDataFromBytes(b)
+	return c.loadSessionData()
 }
 
 func (c *Connection) SendCommand() error {
@@ -119,9 +297,41 @@ func (c *Connection) SendCommand() error {
 }
 
 func (c *Connection) WaitForDataReady(timeOut time.Duration) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.waitForDataReady(timeOut)
+}
+
+func (c *Connection) waitForDataReady(timeOut time.Duration) ([]byte, error) {
+	b, err := c.waitForDataReadyThrottled(timeOut)
+	c.noteError(err)
+	return b, err
+}
+
+// noteError remembers utils.ErrDisconnected for the Supervisor. The caller
+// must hold c.mu.
+func (c *Connection) noteError(err error) {
+	if err == utils.ErrDisconnected {
+		c.disconnected = true
+	}
+}
+
+// takeDisconnected reports (and clears) if the source reported
+// utils.ErrDisconnected since the last call
+func (c *Connection) takeDisconnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	disconnected := c.disconnected
+	c.disconnected = false
+	return disconnected
+}
+
+func (c *Connection) waitForDataReadyThrottled(timeOut time.Duration) ([]byte, error) {
 	// Check if maxfps specified
 	if c.maxFPS == 0 {
-		b, err := c.sdk.WaitForDataReady(c.timeout)
+		b, err := c.src.WaitForTick(c.timeout)
 		if err == nil {
 			c.lastUpdateTime = time.Now()
 		}
@@ -131,16 +341,16 @@ func (c *Connection) WaitForDataReady(timeOut time.Duration) ([]byte, error) {
 	// Check if first tick
 	nilTime := time.Time{}
 	if c.lastUpdateTime == nilTime {
-		b, err := c.sdk.WaitForDataReady(c.timeout)
+		b, err := c.src.WaitForTick(c.timeout)
 		if err == nil {
 			c.lastUpdateTime = time.Now()
 		}
 		return b, err
 	}
 
-	header, err := c.GetHeader()
+	header, err := c.src.Header()
 	if header == nil {
-		b, err := c.sdk.WaitForDataReady(c.timeout)
+		b, err := c.src.WaitForTick(c.timeout)
 		if err == nil {
 			c.lastUpdateTime = time.Now()
 		}
@@ -149,7 +359,7 @@ func (c *Connection) WaitForDataReady(timeOut time.Duration) ([]byte, error) {
 
 	// MaxFPS >= 60, go for max performance
 	if c.maxFPS >= int(header.TickRate) {
-		b, err := c.sdk.WaitForDataReady(c.timeout)
+		b, err := c.src.WaitForTick(c.timeout)
 		if err != nil {
 			c.lastUpdateTime = time.Now()
 		}
@@ -164,20 +374,50 @@ func (c *Connection) WaitForDataReady(timeOut time.Duration) ([]byte, error) {
 	time.Sleep(timeToWait)
 
 	// Call non-throttled WaitForDataReady
-	b, err := c.sdk.WaitForDataReady(c.timeout)
+	b, err := c.src.WaitForTick(c.timeout)
 	if err == nil {
 		c.lastUpdateTime = time.Now()
 	}
 	return b, err
 }
 
+// SetMaxFPS throttles WaitForDataReady and everything built on it for the
+// whole connection.
+//
+// Deprecated: use Subscribe with SubscribeOptions.Rate, which lets every
+// subscriber pick its own rate.
 func (c *Connection) SetMaxFPS(maxFPS int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.maxFPS = maxFPS
 }
 
 func (c *Connection) Disconnect() error {
-	c.sdk.Shutdown()
-	return nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.disconnect()
+}
+
+func (c *Connection) disconnect() error {
+	c.opened = false
+	c.schema = nil
+	return c.src.Close()
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
 }
 
 // bytesToUtf8 is used to convert stringdata from iRacing to UTF-8 so it can
@@ -0,0 +1,269 @@
+package irsdk
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonb/irsdk-go/fakesim"
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// newFakeSim starts a fakesim memory map with a first tick. The test writes
+// the next ticks with p.Tick().
+func newFakeSim(tb testing.TB) (*fakesim.Producer, string) {
+	path := filepath.Join(tb.TempDir(), "irsdk.mmap")
+	p, err := fakesim.NewProducer(path, nil, fakesim.Options{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { p.Close() })
+
+	err = p.Tick()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return p, path
+}
+
+// newFakeConnection connects to a fakesim memory map through NewMmapSource
+func newFakeConnection(tb testing.TB) (*fakesim.Producer, *Connection) {
+	p, path := newFakeSim(tb)
+
+	conn := NewConnectionFromSource(NewMmapSource(path, nil))
+	err := conn.Connect()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { conn.Disconnect() })
+
+	// Like the C++ SDK the first read after connecting only syncs to the tick
+	// count of the sim
+	conn.WaitForDataReady(0)
+
+	return p, conn
+}
+
+// fakeSignal returns the value of a variable of fakesim.DefaultVars at t
+func fakeSignal(name string, t time.Duration, index int) float64 {
+	for _, v := range fakesim.DefaultVars() {
+		if v.Name == name {
+			return v.Signal(t, index)
+		}
+	}
+
+	return 0
+}
+
+func TestConnectionTelemetry(t *testing.T) {
+	p, conn := newFakeConnection(t)
+
+	for i := 0; i < 120; i++ {
+		err := p.Tick()
+		if err != nil {
+			t.Fatal(err)
+		}
+		elapsed := p.Elapsed()
+
+		td, err := conn.GetTelemetryData()
+		if err != nil {
+			t.Fatalf("tick %d: %v", i, err)
+		}
+
+		if td.SessionTime != elapsed.Seconds() {
+			t.Fatalf("tick %d: SessionTime is %v, want %v", i, td.SessionTime, elapsed.Seconds())
+		}
+		if want := float32(fakeSignal("Speed", elapsed, 0)); td.Speed != want {
+			t.Errorf("tick %d: Speed is %v, want %v", i, td.Speed, want)
+		}
+		if want := float32(fakeSignal("RPM", elapsed, 0)); td.RPM != want {
+			t.Errorf("tick %d: RPM is %v, want %v", i, td.RPM, want)
+		}
+		if want := int(fakeSignal("Gear", elapsed, 0)); td.Gear != want {
+			t.Errorf("tick %d: Gear is %v, want %v", i, td.Gear, want)
+		}
+		if td.Lap != 1 || !td.IsOnTrack || td.OnPitRoad {
+			t.Errorf("tick %d: got Lap %v, IsOnTrack %v, OnPitRoad %v", i, td.Lap, td.IsOnTrack, td.OnPitRoad)
+		}
+		if !td.SessionFlags.Has(utils.GreenFlag) || td.SessionState != utils.StateRacing {
+			t.Errorf("tick %d: got SessionFlags %v, SessionState %v", i, td.SessionFlags, td.SessionState)
+		}
+		for car := 0; car < 4; car++ {
+			want := float32(fakeSignal("CarIdxLapDistPct", elapsed, car))
+			if td.CarIdxLapDistPct[car] != want {
+				t.Errorf("tick %d: CarIdxLapDistPct[%d] is %v, want %v", i, car, td.CarIdxLapDistPct[car], want)
+			}
+		}
+	}
+}
+
+func TestConnectionFrames(t *testing.T) {
+	p, conn := newFakeConnection(t)
+
+	var last *Frame
+	for i := 0; i < 10; i++ {
+		p.Tick()
+		frame, err := conn.GetFrame()
+		if err != nil {
+			t.Fatalf("tick %d: %v", i, err)
+		}
+
+		if last != nil && frame.TickCount != last.TickCount+1 {
+			t.Errorf("tick %d: TickCount is %d after %d", i, frame.TickCount, last.TickCount)
+		}
+		sessionTime, err := frame.Double("SessionTime")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sessionTime != p.Elapsed().Seconds() {
+			t.Errorf("tick %d: SessionTime is %v, want %v", i, sessionTime, p.Elapsed().Seconds())
+		}
+		if pos, _ := frame.IntArray("CarIdxPosition"); pos[0] != 1 || pos[2] != 3 || pos[3] != 0 {
+			t.Errorf("tick %d: CarIdxPosition is %v", i, pos[:4])
+		}
+		last = frame
+	}
+
+	// Nothing new without a tick
+	frame, err := conn.GetFrame()
+	if frame != nil {
+		t.Errorf("got a frame (%v) without a new tick", err)
+	}
+}
+
+func TestConnectionSessionData(t *testing.T) {
+	p, conn := newFakeConnection(t)
+
+	data, err := conn.GetSessionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.WeekendInfo.TrackDisplayName != "Lime Rock Park" || data.WeekendInfo.TrackLength.Value != 2.41 {
+		t.Errorf("WeekendInfo: got %q, %v", data.WeekendInfo.TrackDisplayName, data.WeekendInfo.TrackLength)
+	}
+	if len(data.DriverInfo.Drivers) != fakesim.DEFAULT_NUMCARS {
+		t.Fatalf("got %d drivers, want %d", len(data.DriverInfo.Drivers), fakesim.DEFAULT_NUMCARS)
+	}
+	if driver := data.PlayerDriver(); driver == nil || driver.UserName != "Fake Driver" {
+		t.Errorf("PlayerDriver: got %+v", driver)
+	}
+
+	again, _ := conn.GetSessionData()
+	if again != data {
+		t.Error("session info parsed again without a new revision")
+	}
+
+	err = p.SetSessionInfo([]byte(fakesim.DefaultSessionInfo + "CameraInfo:\n Groups:\n - GroupNum: 1\n   GroupName: Nose\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := conn.GetSessionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated == data || len(updated.CameraInfo.Groups) != 1 || updated.CameraInfo.Groups[0].GroupName != "Nose" {
+		t.Errorf("new revision not parsed: got %+v", updated.CameraInfo)
+	}
+}
+
+func TestConnectionConcurrentUse(t *testing.T) {
+	p, conn := newFakeConnection(t)
+
+	stop := make(chan struct{})
+	running := make(chan struct{})
+	go func() {
+		p.Run(stop)
+		close(running)
+	}()
+	defer func() {
+		close(stop)
+		<-running
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	loop := func(f func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				err := f()
+				if err != nil && err != utils.ErrNothingChanged && err != utils.ErrDataChanged {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	loop(func() error {
+		td, err := conn.GetTelemetryData()
+		if err == nil && td != nil && td.Lap != 1 {
+			t.Errorf("GetTelemetryData: got Lap %v", td.Lap)
+		}
+		return err
+	})
+	loop(func() error {
+		frame, err := conn.GetFrame()
+		if err == nil && frame != nil {
+			_, err = frame.Float("Speed")
+		}
+		return err
+	})
+	loop(func() error {
+		data, err := conn.GetSessionData()
+		if err == nil && data.WeekendInfo.TrackDisplayName != "Lime Rock Park" {
+			t.Errorf("GetSessionData: got %q", data.WeekendInfo.TrackDisplayName)
+		}
+		return err
+	})
+	loop(func() error {
+		time.Sleep(50 * time.Millisecond)
+		return p.SetSessionInfo([]byte(fakesim.DefaultSessionInfo))
+	})
+
+	for i := 0; i < 3; i++ {
+		frames, err := conn.Subscribe(ctx, SubscribeOptions{Rate: 30 * (i + 1), Policy: BackpressurePolicy(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := 0
+			for frame := range frames {
+				if _, err := frame.Double("SessionTime"); err != nil {
+					errs <- err
+				}
+				n = n + 1
+			}
+			if n == 0 {
+				errs <- errors.New("subscriber got no frames")
+			}
+		}()
+	}
+
+	changes := conn.SessionChanges(ctx)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range changes {
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime/pprof"
+	"strings"
 	"time"
 
 	"github.com/codegangsta/cli"
 	irsdk "github.com/leonb/irsdk-go"
+	"github.com/leonb/irsdk-go/export"
+	"github.com/leonb/irsdk-go/fakesim"
+	"github.com/leonb/irsdk-go/utils"
 )
 
 // dictionaryFlag only accepts a list of predefined flag values
@@ -21,13 +30,62 @@ func main() {
 	app.Name = "irsdk"
 	app.Usage = "some simple commands to check if the iRacing go sdk is working"
 	app.Version = "0.0.1"
-	dumpFlags := []cli.Flag{
+	sourceFlags := []cli.Flag{
+		cli.StringFlag{
+			Name:  "ibt",
+			Usage: "read from an .ibt file instead of the running sim",
+		},
+		cli.StringFlag{
+			Name:  "remote",
+			Usage: "read from an 'irsdk serve' instance (host:port)",
+		},
+		cli.StringFlag{
+			Name:  "mmap",
+			Usage: "map a file directly instead of using the rpc helper ('sim' maps the shared memory of the sim)",
+		},
+		cli.DurationFlag{
+			Name:  "poll",
+			Usage: "interval to poll the mapped file for new data (default: 4 times per tick)",
+		},
+		cli.StringFlag{
+			Name:  "replay",
+			Usage: "play back a recording made with 'irsdk record'",
+		},
+		cli.Float64Flag{
+			Name:  "speed",
+			Value: 1,
+			Usage: "playback speed of --replay (0 = as fast as possible)",
+		},
+	}
+	dumpFlags := append([]cli.Flag{
 		cli.StringFlag{
 			Name:  "format",
 			Value: "raw",
 			Usage: "format to dump the data in (raw, struct)",
 		},
-	}
+	}, sourceFlags...)
+	exportFlags := append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "vars",
+			Usage: "comma separated variables to export, CarIdxRPM[3] for one entry (default all)",
+		},
+		cli.Float64Flag{
+			Name:  "rate",
+			Usage: "resample to this many rows per second (default every frame)",
+		},
+		cli.Float64Flag{
+			Name:  "from",
+			Usage: "first SessionTime (seconds) to export",
+		},
+		cli.Float64Flag{
+			Name:  "to",
+			Usage: "last SessionTime (seconds) to export",
+		},
+		cli.StringFlag{
+			Name:  "laps",
+			Usage: "only export these laps (3 or 3-5)",
+		},
+	}, sourceFlags...)
 	app.Commands = []cli.Command{
 		{
 			Name:    "dump",
@@ -39,13 +97,7 @@ func main() {
 					Usage: "dump data header",
 					Flags: dumpFlags,
 					Action: func(c *cli.Context) {
-						conn, err := irsdk.NewConnection()
-						if err != nil {
-							fmt.Fprintln(os.Stdout, err)
-							return
-						}
-
-						err = conn.Connect()
+						conn, err := openConnection(c)
 						if err != nil {
 							fmt.Fprintln(os.Stdout, err)
 							return
@@ -72,13 +124,7 @@ func main() {
 					Usage: "dump session data",
 					Flags: dumpFlags,
 					Action: func(c *cli.Context) {
-						conn, err := irsdk.NewConnection()
-						if err != nil {
-							fmt.Fprintln(os.Stdout, err)
-							return
-						}
-
-						err = conn.Connect()
+						conn, err := openConnection(c)
 						if err != nil {
 							fmt.Fprintln(os.Stdout, err)
 							return
@@ -112,29 +158,65 @@ func main() {
 					Usage: "dump telemetry data",
 					Flags: dumpFlags,
 					Action: func(c *cli.Context) {
-						filename := "telemetry-test.ibt"
+						filename := c.String("ibt")
+						if filename == "" {
+							filename = "telemetry-test.ibt"
+						}
 						f, err := os.Open(filename)
 						if err != nil {
 							fmt.Fprintln(os.Stdout, err)
 							return
 						}
+						defer f.Close()
 
 						tr := irsdk.NewTelemetryReader(f)
-
-						// @TODO: profile GetAllDataPoints()
-						datapoints, err := tr.GetAllDataPoints()
+						it, err := tr.Frames()
 						if err != nil {
 							fmt.Fprintln(os.Stderr, err)
+							return
 						}
 
-						for _, dp := range datapoints {
-							fmt.Println(dp.OnPitRoad)
+						var last *irsdk.Frame
+						for it.Next() {
+							last = it.Frame()
+							onPitRoad, _ := last.Bool("OnPitRoad")
+							fmt.Println(onPitRoad)
+						}
+						if err := it.Err(); err != nil {
+							fmt.Fprintln(os.Stderr, err)
+							return
 						}
 
-						last := len(datapoints) - 1
-						fmt.Printf("%+v\n", datapoints[last])
+						if last != nil {
+							td, err := tr.ReadDataPointN(it.Index())
+							if err != nil {
+								fmt.Fprintln(os.Stderr, err)
+								return
+							}
+							fmt.Printf("%+v\n", td)
+						}
+					},
+				},
+				{
+					Name:  "vars",
+					Usage: "dump the name, type, count, unit and description of every variable",
+					Flags: dumpFlags,
+					Action: func(c *cli.Context) {
+						conn, err := openConnection(c)
+						if err != nil {
+							fmt.Fprintln(os.Stdout, err)
+							return
+						}
 
-						return
+						schema, err := conn.GetSchema()
+						if err != nil {
+							fmt.Fprintln(app.Writer, err)
+							return
+						}
+
+						for _, v := range schema.Vars() {
+							fmt.Printf("%-32s %-8v %3d %-12s %s\n", v.Name, v.Type, v.Count, v.Unit, v.Desc)
+						}
 					},
 				},
 				{
@@ -150,6 +232,355 @@ func main() {
 			},
 		},
 
+		{
+			Name:  "ibt",
+			Usage: ".ibt file commands",
+			Subcommands: []cli.Command{
+				{
+					Name:      "info",
+					Usage:     "summarize an .ibt file",
+					ArgsUsage: "FILE",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "vars",
+							Usage: "also list the variables",
+						},
+					},
+					Action: func(c *cli.Context) {
+						path := c.Args().First()
+						if path == "" {
+							fmt.Fprintln(os.Stderr, "No file given")
+							return
+						}
+
+						f, err := os.Open(path)
+						if err != nil {
+							fmt.Fprintln(os.Stderr, err)
+							return
+						}
+						defer f.Close()
+
+						info, err := irsdk.NewTelemetryReader(f).Info()
+						if err != nil {
+							fmt.Fprintln(os.Stderr, err)
+							return
+						}
+
+						fmt.Printf("Track:     %s (%s)\n", info.Track, info.TrackConfig)
+						fmt.Printf("Car:       %s\n", info.Car)
+						fmt.Printf("Driver:    %s\n", info.Driver)
+						fmt.Printf("Date:      %s\n", info.StartDate.Format(time.RFC3339))
+						fmt.Printf("Duration:  %s (%.2fs - %.2fs)\n", info.Duration, info.StartTime, info.EndTime)
+						fmt.Printf("Laps:      %d\n", info.Laps)
+						fmt.Printf("Records:   %d at %d Hz\n", info.Records, info.TickRate)
+						fmt.Printf("Variables: %d\n", len(info.Vars))
+
+						if c.Bool("vars") {
+							for _, v := range info.Vars {
+								fmt.Printf("  %-32s %-8v %3d %-12s %s\n", v.Name, v.Type, v.Count, v.Unit, v.Desc)
+							}
+						}
+					},
+				},
+				{
+					Name:      "trim",
+					Usage:     "write part of an .ibt file to a new .ibt file",
+					ArgsUsage: "IN OUT",
+					Flags: []cli.Flag{
+						cli.Float64Flag{
+							Name:  "from",
+							Usage: "first SessionTime (seconds) to keep",
+						},
+						cli.Float64Flag{
+							Name:  "to",
+							Usage: "last SessionTime (seconds) to keep",
+						},
+						cli.StringFlag{
+							Name:  "laps",
+							Usage: "only keep these laps (3 or 3-5)",
+						},
+						cli.StringFlag{
+							Name:  "vars",
+							Usage: "comma separated variables to keep (default all)",
+						},
+					},
+					Action: func(c *cli.Context) {
+						if len(c.Args()) != 2 {
+							fmt.Fprintln(os.Stderr, "Usage: irsdk ibt trim IN OUT")
+							return
+						}
+
+						n, err := trimIbt(c.Args()[0], c.Args()[1], c)
+						if err != nil {
+							fmt.Fprintln(os.Stderr, err)
+							return
+						}
+
+						fmt.Printf("Wrote %d records\n", n)
+					},
+				},
+				{
+					Name:      "log",
+					Usage:     "log the running sim to an .ibt file (stop with ctrl-c)",
+					ArgsUsage: "FILE",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{
+							Name:  "vars",
+							Usage: "comma separated variables to log (default all)",
+						},
+					}, sourceFlags...),
+					Action: func(c *cli.Context) {
+						path := c.Args().First()
+						if path == "" {
+							fmt.Fprintln(os.Stderr, "No file given")
+							return
+						}
+
+						n, err := logIbt(path, c)
+						if err != nil {
+							fmt.Fprintln(os.Stderr, err)
+						}
+
+						fmt.Printf("Logged %d records\n", n)
+					},
+				},
+			},
+		},
+
+		{
+			Name:  "export",
+			Usage: "export telemetry of an .ibt file (--ibt) or a running sim (stop with ctrl-c)",
+			Subcommands: []cli.Command{
+				{
+					Name:      "csv",
+					Usage:     "export to CSV ('-' writes to stdout)",
+					ArgsUsage: "FILE",
+					Flags: append([]cli.Flag{
+						cli.BoolFlag{
+							Name:  "units",
+							Usage: "add a second header line with the units",
+						},
+					}, exportFlags...),
+					Action: func(c *cli.Context) {
+						exportTelemetry(c, "csv")
+					},
+				},
+				{
+					Name:      "parquet",
+					Usage:     "export to Apache Parquet ('-' writes to stdout)",
+					ArgsUsage: "FILE",
+					Flags:     exportFlags,
+					Action: func(c *cli.Context) {
+						exportTelemetry(c, "parquet")
+					},
+				},
+				{
+					Name:      "motec",
+					Usage:     "convert an .ibt file to a MoTeC i2 log (.ld and .ldx)",
+					ArgsUsage: "IN.ibt OUT.ld",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "vars",
+							Usage: "comma separated variables to export, CarIdxRPM[3] for one entry (default all but arrays)",
+						},
+						cli.IntFlag{
+							Name:  "rate",
+							Usage: "sample rate in Hz, must divide the tick rate (default the tick rate)",
+						},
+						cli.StringFlag{
+							Name:  "comment",
+							Usage: "short comment of the log",
+						},
+					},
+					Action: func(c *cli.Context) {
+						if len(c.Args()) != 2 {
+							fmt.Fprintln(os.Stderr, "Usage: irsdk export motec IN.ibt OUT.ld")
+							return
+						}
+
+						err := exportMotec(c.Args()[0], c.Args()[1], c)
+						if err != nil {
+							fmt.Fprintln(os.Stderr, err)
+						}
+					},
+				},
+			},
+		},
+
+		{
+			Name:  "setup",
+			Usage: "car setup commands",
+			Subcommands: []cli.Command{
+				{
+					Name:      "diff",
+					Usage:     "compare two car setups",
+					ArgsUsage: "A B (an .ibt file, a session info dump, 'live' or 'remote:host:port')",
+					Action: func(c *cli.Context) {
+						if len(c.Args()) != 2 {
+							fmt.Fprintln(os.Stderr, "Two setups needed")
+							return
+						}
+
+						setups := []*irsdk.CarSetup{}
+						for _, arg := range c.Args() {
+							session, err := loadSessionData(arg)
+							if err != nil {
+								fmt.Fprintf(os.Stderr, "%s: %v\n", arg, err)
+								return
+							}
+							setups = append(setups, &session.CarSetup)
+						}
+
+						for _, change := range irsdk.DiffCarSetup(setups[0], setups[1]) {
+							old, new := "-", "-"
+							if change.Old != nil {
+								old = change.Old.Raw
+							}
+							if change.New != nil {
+								new = change.New.Raw
+							}
+
+							delta := ""
+							if change.Delta != nil {
+								delta = strings.TrimSpace(fmt.Sprintf("%+g %s", change.Delta.Value, change.Delta.Unit))
+							}
+							fmt.Printf("%-48s %-20s %-20s %s\n", strings.Join(change.Path, " > "), old, new, delta)
+						}
+					},
+				},
+			},
+		},
+
+		{
+			Name:  "serve",
+			Usage: "serve telemetry to network sources",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "addr",
+					Value: ":7778",
+					Usage: "address to listen on",
+				},
+			}, sourceFlags...),
+			Action: func(c *cli.Context) {
+				src, err := openSource(c)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return
+				}
+
+				l, err := net.Listen("tcp", c.String("addr"))
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return
+				}
+
+				err = irsdk.ServeSource(l, src)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			},
+		},
+
+		{
+			Name:      "record",
+			Usage:     "record the raw memory map frames to a file (stop with ctrl-c)",
+			ArgsUsage: "FILE",
+			Flags:     sourceFlags,
+			Action: func(c *cli.Context) {
+				path := c.Args().First()
+				if path == "" {
+					fmt.Fprintln(os.Stderr, "No file given")
+					return
+				}
+
+				f, err := os.Create(path)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return
+				}
+				defer f.Close()
+
+				src, err := openSource(c)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return
+				}
+
+				recorder := irsdk.NewRecorder(src, f)
+				conn := irsdk.NewConnectionFromSource(recorder)
+				err = conn.Connect()
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return
+				}
+				defer conn.Disconnect()
+
+				signals := make(chan os.Signal, 1)
+				signal.Notify(signals, os.Interrupt)
+
+				frames := 0
+				for {
+					select {
+					case <-signals:
+						fmt.Printf("Recorded %d frames\n", frames)
+						return
+					default:
+					}
+
+					data, err := conn.GetRawTelemetryData()
+					if err == io.EOF {
+						fmt.Printf("Recorded %d frames\n", frames)
+						return
+					}
+					if data != nil {
+						frames = frames + 1
+					}
+				}
+			},
+		},
+
+		{
+			Name:      "fakesim",
+			Usage:     "write a synthetic iRacing memory map to a file (read it with --mmap)",
+			ArgsUsage: "FILE",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "tickrate",
+					Value: fakesim.DEFAULT_TICKRATE,
+					Usage: "var buffers written per second",
+				},
+			},
+			Action: func(c *cli.Context) {
+				path := c.Args().First()
+				if path == "" {
+					fmt.Fprintln(os.Stderr, "No file given")
+					return
+				}
+
+				opts := fakesim.Options{TickRate: c.Int("tickrate")}
+				p, err := fakesim.NewProducer(path, nil, opts)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return
+				}
+				defer p.Close()
+
+				// Stop on ctrl-c
+				stop := make(chan struct{})
+				signals := make(chan os.Signal, 1)
+				signal.Notify(signals, os.Interrupt)
+				go func() {
+					<-signals
+					close(stop)
+				}()
+
+				err = p.Run(stop)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			},
+		},
+
 		{
 			// https://blog.golang.org/profiling-go-programs
 			Name:    "profile",
@@ -202,3 +633,433 @@ func main() {
 
 	app.Run(os.Args)
 }
+
+// openSource picks the telemetry source based on the command line flags
+func openSource(c *cli.Context) (irsdk.Source, error) {
+	if filename := c.String("ibt"); filename != "" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		return irsdk.NewDiskSource(f), nil
+	}
+
+	if filename := c.String("replay"); filename != "" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		return irsdk.NewRecordingSource(f, c.Float64("speed")), nil
+	}
+
+	if addr := c.String("remote"); addr != "" {
+		return irsdk.NewNetSource(addr), nil
+	}
+
+	if path := c.String("mmap"); path != "" {
+		if path == "sim" {
+			path = ""
+		}
+
+		var poll utils.PollStrategy
+		if interval := c.Duration("poll"); interval > 0 {
+			poll = utils.FixedPoll(interval)
+		}
+		return irsdk.NewMmapSource(path, poll), nil
+	}
+
+	return irsdk.NewLiveSource()
+}
+
+// loadSessionData reads the session info from an .ibt file, a session info
+// dump ('irsdk dump session'), the running sim ('live') or an 'irsdk serve'
+// instance ('remote:host:port')
+func loadSessionData(arg string) (*irsdk.SessionData, error) {
+	var src irsdk.Source
+	switch {
+	case arg == "live":
+		live, err := irsdk.NewLiveSource()
+		if err != nil {
+			return nil, err
+		}
+		src = live
+	case strings.HasPrefix(arg, "remote:"):
+		src = irsdk.NewNetSource(strings.TrimPrefix(arg, "remote:"))
+	case strings.EqualFold(filepath.Ext(arg), ".ibt"):
+		f, err := os.Open(arg)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		return irsdk.NewTelemetryReader(f).ReadSessionData()
+	default:
+		f, err := os.Open(arg)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		return irsdk.NewSessionDataFromReader(f)
+	}
+
+	conn := irsdk.NewConnectionFromSource(src)
+	err := conn.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Disconnect()
+
+	return conn.GetSessionData()
+}
+
+// openConnection creates and connects a connection to the source selected on
+// the command line
+func openConnection(c *cli.Context) (*irsdk.Connection, error) {
+	src, err := openSource(c)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := irsdk.NewConnectionFromSource(src)
+	err = conn.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// splitVars splits a comma separated --vars flag
+func splitVars(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	vars := []string{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			vars = append(vars, name)
+		}
+	}
+
+	return vars
+}
+
+// parseLapRange parses "3" or "3-5"
+func parseLapRange(s string) (int, int, error) {
+	var first, last int
+	n, _ := fmt.Sscanf(s, "%d-%d", &first, &last)
+	switch n {
+	case 1:
+		return first, first, nil
+	case 2:
+		return first, last, nil
+	}
+
+	return 0, 0, fmt.Errorf("Invalid lap range %q", s)
+}
+
+// trimIbt writes the records of in selected with the --from, --to, --laps and
+// --vars flags to out
+func trimIbt(in string, out string, c *cli.Context) (int, error) {
+	f, err := os.Open(in)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	tr := irsdk.NewTelemetryReader(f)
+	header, err := tr.GetHeader()
+	if err != nil {
+		return 0, err
+	}
+	subHeader, err := tr.GetSubHeader()
+	if err != nil {
+		return 0, err
+	}
+	schema, err := tr.GetSchema()
+	if err != nil {
+		return 0, err
+	}
+	sessionInfo, err := tr.ReadRawSessionData()
+	if err != nil {
+		return 0, err
+	}
+
+	it, err := tr.Frames()
+	if err != nil {
+		return 0, err
+	}
+
+	lastLap := -1
+	if laps := c.String("laps"); laps != "" {
+		var firstLap int
+		firstLap, lastLap, err = parseLapRange(laps)
+		if err != nil {
+			return 0, err
+		}
+		err = it.SeekLap(firstLap)
+	} else if c.IsSet("from") {
+		err = it.SeekTime(c.Float64("from"))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	o, err := os.Create(out)
+	if err != nil {
+		return 0, err
+	}
+	defer o.Close()
+
+	w, err := irsdk.NewIbtWriter(o, schema, sessionInfo, irsdk.IbtWriterOptions{
+		Vars:      splitVars(c.String("vars")),
+		TickRate:  int(header.TickRate),
+		StartDate: subHeader.StartDate(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for it.Next() {
+		frame := it.Frame()
+
+		t, _ := frame.Double("SessionTime")
+		if c.IsSet("from") && t < c.Float64("from") {
+			continue
+		}
+		if c.IsSet("to") && t > c.Float64("to") {
+			break
+		}
+
+		if lastLap >= 0 {
+			lap, _ := frame.Int("Lap")
+			if lap > lastLap {
+				break
+			}
+		}
+
+		err = w.WriteFrame(frame)
+		if err != nil {
+			return w.Records(), err
+		}
+	}
+	if it.Err() != nil {
+		return w.Records(), it.Err()
+	}
+
+	return w.Records(), w.Close()
+}
+
+// logIbt writes every frame of the source selected on the command line to an
+// .ibt file until ctrl-c or the end of the source
+func logIbt(path string, c *cli.Context) (int, error) {
+	conn, err := openConnection(c)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Disconnect()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	var w *irsdk.IbtWriter
+	var update int32
+	err = func() error {
+		for {
+			select {
+			case <-signals:
+				return nil
+			default:
+			}
+
+			frame, err := conn.GetFrame()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if frame == nil {
+				continue
+			}
+
+			header, err := conn.GetHeader()
+			if err != nil {
+				return err
+			}
+
+			if w == nil {
+				sessionInfo, err := conn.GetRawSessionData()
+				if err != nil {
+					return err
+				}
+
+				// Leave room for the session info to grow (results, drivers
+				// joining)
+				w, err = irsdk.NewIbtWriter(f, frame.Schema(), sessionInfo, irsdk.IbtWriterOptions{
+					Vars:               splitVars(c.String("vars")),
+					TickRate:           int(header.TickRate),
+					SessionInfoReserve: 4 * len(sessionInfo),
+				})
+				if err != nil {
+					return err
+				}
+				update = header.SessionInfoUpdate
+			} else if header.SessionInfoUpdate != update {
+				sessionInfo, err := conn.GetRawSessionData()
+				if err != nil {
+					return err
+				}
+
+				err = w.SetSessionInfo(sessionInfo)
+				if err == irsdk.ErrSessionInfoTooLarge {
+					fmt.Fprintln(os.Stderr, "Session info grew too big, keeping the old one")
+				} else if err != nil {
+					return err
+				}
+				update = header.SessionInfoUpdate
+			}
+
+			err = w.WriteFrame(frame)
+			if err != nil {
+				return err
+			}
+		}
+	}()
+
+	if w == nil {
+		return 0, err
+	}
+
+	closeErr := w.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return w.Records(), err
+}
+
+// exportTelemetry exports the .ibt file of --ibt or the source selected on the
+// command line to the file given as argument
+func exportTelemetry(c *cli.Context, format string) {
+	path := c.Args().First()
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "No file given")
+		return
+	}
+
+	opts := export.Options{
+		Vars: splitVars(c.String("vars")),
+		Rate: c.Float64("rate"),
+		From: c.Float64("from"),
+		To:   c.Float64("to"),
+	}
+	if laps := c.String("laps"); laps != "" {
+		first, last, err := parseLapRange(laps)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		opts.Laps = &export.LapRange{First: first, Last: last}
+	}
+
+	var src export.FrameSource
+	if filename := c.String("ibt"); filename != "" {
+		f, err := os.Open(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		defer f.Close()
+
+		src, err = export.ReaderFrames(irsdk.NewTelemetryReader(f), opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+	} else {
+		conn, err := openConnection(c)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		defer conn.Disconnect()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt)
+		go func() {
+			<-signals
+			cancel()
+		}()
+
+		src = export.ConnectionFrames(ctx, conn)
+	}
+
+	out := os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var w export.Writer
+	switch format {
+	case "csv":
+		cw := export.NewCSVWriter(out)
+		cw.Units = c.Bool("units")
+		w = cw
+	case "parquet":
+		w = export.NewParquetWriter(out)
+	}
+
+	rows, err := export.Export(src, w, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d rows\n", rows)
+}
+
+// exportMotec converts in to the MoTeC log out and writes the lap beacons to
+// the .ldx file next to it
+func exportMotec(in string, out string, c *cli.Context) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ld, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer ld.Close()
+
+	ldx, err := os.Create(strings.TrimSuffix(out, filepath.Ext(out)) + ".ldx")
+	if err != nil {
+		return err
+	}
+	defer ldx.Close()
+
+	return export.WriteMotec(irsdk.NewTelemetryReader(f), ld, ldx, export.MotecOptions{
+		Vars:    splitVars(c.String("vars")),
+		Rate:    c.Int("rate"),
+		Comment: c.String("comment"),
+	})
+}
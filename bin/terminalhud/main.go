@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -77,27 +78,43 @@ func (r graphRenderer) String() string {
 }
 
 func main() {
-	var conn *irsdk.Connection
 	var session *irsdk.SessionData
-	var err error
 
-	// Keep trying until connection is made
-	conn, _ = irsdk.NewConnection()
-	conn.SetMaxFPS(30)
+	conn, err := irsdk.NewConnection()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	for {
-		if conn.IsConnected() == false {
-			time.Sleep(time.Second * 1)
-			conn.Connect()
+	// The supervisor (re)connects whenever the sim starts
+	ctx := context.Background()
+	supervisor := irsdk.NewSupervisor(conn, irsdk.SupervisorOptions{})
+	events := supervisor.Events(ctx)
+	go supervisor.Run(ctx)
+
+	// 30 fps is plenty for a terminal; only the newest frame matters
+	frames, err := conn.Subscribe(ctx, irsdk.SubscribeOptions{
+		Rate:   30,
+		Fields: []string{"Speed", "RPM", "Gear", "Clutch", "Brake", "Throttle"},
+		Policy: irsdk.LatestOnly,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
-			session, err = conn.GetSessionData()
-			if err != nil {
-				log.Fatal(err)
+	for {
+		var frame *irsdk.Frame
+		select {
+		case event := <-events:
+			switch event.To {
+			case irsdk.Connected:
+				session, _ = conn.GetSessionData()
+			case irsdk.Disconnected, irsdk.SimNotRunning:
+				session = nil
 			}
+		case frame = <-frames:
+		case <-time.After(time.Second):
 		}
 
-		telemetryData, _ := conn.GetTelemetryData()
-
 		tSpeed := float32(0.0)
 		tRPM := float32(0.0)
 		tGear := 0
@@ -105,13 +122,13 @@ func main() {
 		tBrake := float32(0.0)
 		tThrottle := float32(0.0)
 
-		if telemetryData != nil {
-			tSpeed = telemetryData.Speed
-			tRPM = telemetryData.RPM
-			tGear = telemetryData.Gear
-			tClutch = telemetryData.Clutch
-			tBrake = telemetryData.Brake
-			tThrottle = telemetryData.Throttle
+		if frame != nil {
+			tSpeed, _ = frame.Float("Speed")
+			tRPM, _ = frame.Float("RPM")
+			tGear, _ = frame.Int("Gear")
+			tClutch, _ = frame.Float("Clutch")
+			tBrake, _ = frame.Float("Brake")
+			tThrottle, _ = frame.Float("Throttle")
 		}
 
 		speed := textRenderer{
@@ -0,0 +1,215 @@
+package irsdk
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// CarSetup is the setup of the player's car. Sections differ per car
+// (TiresAero, Chassis, ...), so they're kept as a tree in the order of the
+// session info.
+type CarSetup struct {
+	UpdateCount int
+	Sections    []*SetupSection
+}
+
+// SetupSection is a (sub)section of the setup, for example Chassis or
+// Chassis > LeftFront
+type SetupSection struct {
+	Name     string
+	Sections []*SetupSection
+	Values   []*SetupValue
+}
+
+// SetupValue is a single setting ("138 kPa", "28C, 28C, 28C", "-1/16\"")
+type SetupValue struct {
+	Name string
+	Raw  string
+	// Quantity is nil when Raw isn't a single number with an optional unit
+	Quantity *Quantity
+}
+
+// SetupChange is a setting that differs between two setups. Old or New is nil
+// when the setting only exists in one of them.
+type SetupChange struct {
+	Path []string
+	Old  *SetupValue
+	New  *SetupValue
+	// Delta is New - Old when both are quantities with the same unit
+	Delta *Quantity
+}
+
+var setupQuantityRegexp = regexp.MustCompile(`^([+-]?[0-9]*\.?[0-9]+)\s*([^0-9\s,/][^,]*)?$`)
+
+func (s *CarSetup) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var tree yaml.MapSlice
+	err := unmarshal(&tree)
+	if err != nil {
+		return err
+	}
+
+	setup := CarSetup{}
+	for _, item := range tree {
+		name := fmt.Sprint(item.Key)
+		if name == "UpdateCount" {
+			setup.UpdateCount, _ = strconv.Atoi(fmt.Sprint(item.Value))
+			continue
+		}
+
+		if children, ok := item.Value.(yaml.MapSlice); ok {
+			setup.Sections = append(setup.Sections, newSetupSection(name, children))
+		}
+	}
+
+	*s = setup
+	return nil
+}
+
+func newSetupSection(name string, tree yaml.MapSlice) *SetupSection {
+	section := &SetupSection{Name: name}
+	for _, item := range tree {
+		key := fmt.Sprint(item.Key)
+		if children, ok := item.Value.(yaml.MapSlice); ok {
+			section.Sections = append(section.Sections, newSetupSection(key, children))
+			continue
+		}
+
+		raw := ""
+		if item.Value != nil {
+			raw = fmt.Sprint(item.Value)
+		}
+		section.Values = append(section.Values, newSetupValue(key, raw))
+	}
+
+	return section
+}
+
+func newSetupValue(name, raw string) *SetupValue {
+	v := &SetupValue{Name: name, Raw: raw}
+
+	m := setupQuantityRegexp.FindStringSubmatch(strings.TrimSpace(raw))
+	if m == nil {
+		return v
+	}
+
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return v
+	}
+
+	v.Quantity = &Quantity{Value: value, Unit: strings.TrimSpace(m[2])}
+	return v
+}
+
+func (v *SetupValue) String() string {
+	return v.Raw
+}
+
+// Section returns the (sub)section at path or nil
+func (s *CarSetup) Section(path ...string) *SetupSection {
+	if len(path) == 0 {
+		return nil
+	}
+
+	section := findSetupSection(s.Sections, path[0])
+	for _, name := range path[1:] {
+		if section == nil {
+			return nil
+		}
+		section = findSetupSection(section.Sections, name)
+	}
+
+	return section
+}
+
+// Value returns the setting at path (Chassis, LeftFront, CornerWeight) or nil
+func (s *CarSetup) Value(path ...string) *SetupValue {
+	if len(path) < 2 {
+		return nil
+	}
+
+	section := s.Section(path[:len(path)-1]...)
+	if section == nil {
+		return nil
+	}
+
+	name := path[len(path)-1]
+	for _, v := range section.Values {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func findSetupSection(sections []*SetupSection, name string) *SetupSection {
+	for _, section := range sections {
+		if section.Name == name {
+			return section
+		}
+	}
+
+	return nil
+}
+
+// Walk calls f for every setting with its path
+func (s *CarSetup) Walk(f func(path []string, v *SetupValue)) {
+	for _, section := range s.Sections {
+		section.walk(nil, f)
+	}
+}
+
+func (s *SetupSection) walk(parent []string, f func(path []string, v *SetupValue)) {
+	path := append(append([]string{}, parent...), s.Name)
+	for _, v := range s.Values {
+		f(append(append([]string{}, path...), v.Name), v)
+	}
+	for _, section := range s.Sections {
+		section.walk(path, f)
+	}
+}
+
+// DiffCarSetup compares two setups. Changes are in the order of new, followed
+// by the settings only old has.
+func DiffCarSetup(old, new *CarSetup) []SetupChange {
+	if old == nil {
+		old = &CarSetup{}
+	}
+	if new == nil {
+		new = &CarSetup{}
+	}
+
+	changes := []SetupChange{}
+	seen := map[string]bool{}
+
+	new.Walk(func(path []string, nv *SetupValue) {
+		seen[strings.Join(path, "\x00")] = true
+
+		ov := old.Value(path...)
+		if ov != nil && ov.Raw == nv.Raw {
+			return
+		}
+
+		change := SetupChange{Path: path, Old: ov, New: nv}
+		if ov != nil && ov.Quantity != nil && nv.Quantity != nil && ov.Quantity.Unit == nv.Quantity.Unit {
+			change.Delta = &Quantity{
+				Value: nv.Quantity.Value - ov.Quantity.Value,
+				Unit:  nv.Quantity.Unit,
+			}
+		}
+		changes = append(changes, change)
+	})
+
+	old.Walk(func(path []string, ov *SetupValue) {
+		if !seen[strings.Join(path, "\x00")] {
+			changes = append(changes, SetupChange{Path: path, Old: ov})
+		}
+	})
+
+	return changes
+}
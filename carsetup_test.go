@@ -0,0 +1,108 @@
+package irsdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCarSetup(t *testing.T) {
+	setup := testSessionData(t).CarSetup
+
+	if len(setup.Sections) != 2 || setup.Sections[0].Name != "TiresAero" || setup.Sections[1].Name != "Chassis" {
+		t.Fatalf("Sections: got %+v", setup.Sections)
+	}
+
+	if s := setup.Section("Chassis", "LeftFront"); s == nil || len(s.Values) != 2 {
+		t.Errorf("Section(Chassis, LeftFront): got %+v", s)
+	}
+	if s := setup.Section("Chassis", "Rear"); s != nil {
+		t.Errorf("Section(Chassis, Rear): got %+v", s)
+	}
+
+	tests := []struct {
+		path  []string
+		raw   string
+		value float64
+		unit  string
+	}{
+		{[]string{"TiresAero", "LeftFront", "StartingPressure"}, "138 kPa", 138, "kPa"},
+		{[]string{"Chassis", "Front", "ArbBlades"}, "2", 2, ""},
+		{[]string{"Chassis", "Front", "BrakePressureBias"}, "54.0%", 54, "%"},
+		{[]string{"Chassis", "LeftFront", "RideHeight"}, "55.0 mm", 55, "mm"},
+	}
+
+	for _, test := range tests {
+		v := setup.Value(test.path...)
+		if v == nil {
+			t.Errorf("%v: not found", test.path)
+			continue
+		}
+		if v.Raw != test.raw || v.Quantity == nil || v.Quantity.Value != test.value || v.Quantity.Unit != test.unit {
+			t.Errorf("%v: got %q, %+v", test.path, v.Raw, v.Quantity)
+		}
+	}
+
+	// Not a single number
+	v := setup.Value("TiresAero", "LeftFront", "LastTempsOMI")
+	if v == nil || v.Raw != "28C, 28C, 28C" || v.Quantity != nil {
+		t.Errorf("LastTempsOMI: got %+v", v)
+	}
+}
+
+func TestDiffCarSetup(t *testing.T) {
+	old := testSessionData(t).CarSetup
+
+	changed := strings.NewReplacer(
+		"CornerWeight: 4511 N", "CornerWeight: 4620 N",
+		"BrakePressureBias: 54.0%", "BrakePressureBias: 53.5%",
+		"   ArbBlades: 2\n", "",
+		"   LastTempsOMI: 28C, 28C, 28C\n", "   LastTempsOMI: 31C, 30C, 29C\n   TreadRemaining: 100%, 100%, 100%\n",
+	).Replace(testSessionYAML)
+	data, err := NewSessionDataFromBytes([]byte(changed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new := data.CarSetup
+
+	changes := DiffCarSetup(&old, &new)
+
+	want := []struct {
+		path  string
+		old   string
+		new   string
+		delta string
+	}{
+		{"TiresAero/LeftFront/LastTempsOMI", "28C, 28C, 28C", "31C, 30C, 29C", ""},
+		{"TiresAero/LeftFront/TreadRemaining", "", "100%, 100%, 100%", ""},
+		{"Chassis/Front/BrakePressureBias", "54.0%", "53.5%", "-0.5 %"},
+		{"Chassis/LeftFront/CornerWeight", "4511 N", "4620 N", "109 N"},
+		{"Chassis/Front/ArbBlades", "2", "", ""},
+	}
+
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+
+	for i, w := range want {
+		c := changes[i]
+		path := strings.Join(c.Path, "/")
+		old, new, delta := "", "", ""
+		if c.Old != nil {
+			old = c.Old.Raw
+		}
+		if c.New != nil {
+			new = c.New.Raw
+		}
+		if c.Delta != nil {
+			delta = c.Delta.String()
+		}
+
+		if path != w.path || old != w.old || new != w.new || delta != w.delta {
+			t.Errorf("change %d: got %s %q -> %q (%q), want %s %q -> %q (%q)", i, path, old, new, delta, w.path, w.old, w.new, w.delta)
+		}
+	}
+
+	if changes := DiffCarSetup(&old, &old); len(changes) != 0 {
+		t.Errorf("same setup: got %+v", changes)
+	}
+}
@@ -0,0 +1,198 @@
+package irsdk
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"unsafe"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+var (
+	ErrBufferTooSmall = errors.New("Var buffer is smaller than the schema")
+)
+
+// decodeKind is the combination of source var type and destination field type
+// of a decodeOp
+type decodeKind int
+
+const (
+	decodeBool decodeKind = iota
+	decodeInt
+	decodeInt32
+	decodeFloat
+	decodeDouble
+	decodeBitfield
+)
+
+// decodeOp copies one variable from a var buffer into a TelemetryData field
+type decodeOp struct {
+	kind decodeKind
+	// src is the offset in the var buffer
+	src int
+	// dst is the offset of the field in TelemetryData
+	dst uintptr
+	// count is the number of entries copied (1 for scalars)
+	count int
+	// elemSize is the size of a single entry of the destination field
+	elemSize uintptr
+}
+
+// Decoder decodes var buffers into TelemetryData. The plan (which bytes go to
+// which field) is compiled once per var header layout, so decoding a frame
+// doesn't look at the var headers, doesn't use reflection and doesn't
+// allocate.
+type Decoder struct {
+	schema *Schema
+	ops    []decodeOp
+}
+
+// NewDecoder compiles a decoder for every variable in schema that has a field
+// in TelemetryData
+func NewDecoder(schema *Schema) *Decoder {
+	return NewDecoderFiltered(schema, nil)
+}
+
+// NewDecoderFiltered compiles a decoder that only decodes the variables in
+// fields. When fields is empty every variable is decoded.
+func NewDecoderFiltered(schema *Schema, fields []string) *Decoder {
+	d := &Decoder{
+		schema: schema,
+	}
+
+	wanted := make(map[string]bool, len(fields))
+	for _, name := range fields {
+		wanted[name] = true
+	}
+
+	t := reflect.TypeOf(TelemetryData{})
+	for _, v := range schema.Vars() {
+		if len(wanted) > 0 && !wanted[v.Name] {
+			continue
+		}
+
+		field, ok := t.FieldByName(ucFirst(v.Name))
+		if !ok || field.PkgPath != "" {
+			// No (exported) field: only available through Frame
+			continue
+		}
+
+		op, ok := compileOp(v, field)
+		if !ok {
+			continue
+		}
+
+		d.ops = append(d.ops, op)
+	}
+
+	return d
+}
+
+// compileOp creates the decodeOp for v. It returns false when the type of the
+// field doesn't match the type of the variable.
+func compileOp(v *VarInfo, field reflect.StructField) (decodeOp, bool) {
+	op := decodeOp{
+		src:      v.Offset,
+		dst:      field.Offset,
+		count:    1,
+		elemSize: field.Type.Size(),
+	}
+
+	ft := field.Type
+	if ft.Kind() == reflect.Array {
+		op.count = ft.Len()
+		if v.Count < op.count {
+			op.count = v.Count
+		}
+		ft = ft.Elem()
+		op.elemSize = ft.Size()
+	}
+
+	switch {
+	case v.Type == utils.BoolType && ft.Kind() == reflect.Bool:
+		op.kind = decodeBool
+	case v.Type == utils.IntType && ft.Kind() == reflect.Int:
+		op.kind = decodeInt
+	case v.Type == utils.IntType && ft.Kind() == reflect.Int32:
+		// Enums (SessionState, TrkLoc, ...)
+		op.kind = decodeInt32
+	case v.Type == utils.FloatType && ft.Kind() == reflect.Float32:
+		op.kind = decodeFloat
+	case v.Type == utils.DoubleType && ft.Kind() == reflect.Float64:
+		op.kind = decodeDouble
+	case v.Type == utils.BitfieldType && ft.Size() == 4 && (ft.Kind() == reflect.Uint32 || ft.Kind() == reflect.Int32):
+		op.kind = decodeBitfield
+	default:
+		return op, false
+	}
+
+	return op, true
+}
+
+// Schema returns the schema the decoder was compiled for
+func (d *Decoder) Schema() *Schema {
+	return d.schema
+}
+
+// Decode decodes a var buffer into dst. Fields of variables that aren't in the
+// layout are reset to their zero value.
+func (d *Decoder) Decode(data []byte, dst *TelemetryData) error {
+	if len(data) < d.schema.MinBufLen() {
+		return ErrBufferTooSmall
+	}
+
+	d.reset(dst)
+	base := unsafe.Pointer(dst)
+
+	for i := range d.ops {
+		op := &d.ops[i]
+
+		switch op.kind {
+		case decodeBool:
+			for j := 0; j < op.count; j++ {
+				p := unsafe.Pointer(uintptr(base) + op.dst + uintptr(j)*op.elemSize)
+				*(*bool)(p) = data[op.src+j] != 0
+			}
+		case decodeInt:
+			for j := 0; j < op.count; j++ {
+				p := unsafe.Pointer(uintptr(base) + op.dst + uintptr(j)*op.elemSize)
+				b := data[op.src+j*4:]
+				*(*int)(p) = int(int32(binary.LittleEndian.Uint32(b)))
+			}
+		case decodeInt32:
+			for j := 0; j < op.count; j++ {
+				p := unsafe.Pointer(uintptr(base) + op.dst + uintptr(j)*op.elemSize)
+				b := data[op.src+j*4:]
+				*(*int32)(p) = int32(binary.LittleEndian.Uint32(b))
+			}
+		case decodeFloat:
+			for j := 0; j < op.count; j++ {
+				p := unsafe.Pointer(uintptr(base) + op.dst + uintptr(j)*op.elemSize)
+				b := data[op.src+j*4:]
+				*(*float32)(p) = math.Float32frombits(binary.LittleEndian.Uint32(b))
+			}
+		case decodeDouble:
+			for j := 0; j < op.count; j++ {
+				p := unsafe.Pointer(uintptr(base) + op.dst + uintptr(j)*op.elemSize)
+				b := data[op.src+j*8:]
+				*(*float64)(p) = math.Float64frombits(binary.LittleEndian.Uint64(b))
+			}
+		case decodeBitfield:
+			for j := 0; j < op.count; j++ {
+				p := unsafe.Pointer(uintptr(base) + op.dst + uintptr(j)*op.elemSize)
+				b := data[op.src+j*4:]
+				*(*uint32)(p) = binary.LittleEndian.Uint32(b)
+			}
+		}
+	}
+
+	return nil
+}
+
+// reset zeroes dst, so fields of variables that aren't in the layout don't
+// keep the values of a previous frame
+func (d *Decoder) reset(dst *TelemetryData) {
+	*dst = TelemetryData{}
+}
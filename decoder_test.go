@@ -0,0 +1,115 @@
+package irsdk
+
+import (
+	"testing"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// fakeTick returns the var buffer and schema of a fakesim tick
+func fakeTick(tb testing.TB) ([]byte, *Schema) {
+	p, conn := newFakeConnection(tb)
+	for i := 0; i < 100; i++ {
+		p.Tick()
+	}
+
+	data, err := conn.GetRawTelemetryData()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	schema, err := conn.GetSchema()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return data, schema
+}
+
+func TestDecoder(t *testing.T) {
+	data, schema := fakeTick(t)
+	frame := schema.NewFrame(data)
+
+	td := NewTelemetryData()
+	err := NewDecoder(schema).Decode(data, td)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessionTime, _ := frame.Double("SessionTime")
+	speed, _ := frame.Float("Speed")
+	gear, _ := frame.Int("Gear")
+	flags, _ := frame.Bitfield("SessionFlags")
+	pcts, _ := frame.FloatArray("CarIdxLapDistPct")
+	if td.SessionTime != sessionTime || td.Speed != speed || td.Gear != gear {
+		t.Errorf("got SessionTime %v, Speed %v, Gear %v", td.SessionTime, td.Speed, td.Gear)
+	}
+	if uint32(td.SessionFlags) != flags || td.SessionState != utils.StateRacing || !td.IsOnTrack {
+		t.Errorf("got SessionFlags %v, SessionState %v, IsOnTrack %v", td.SessionFlags, td.SessionState, td.IsOnTrack)
+	}
+	for i := range pcts {
+		if td.CarIdxLapDistPct[i] != pcts[i] {
+			t.Errorf("CarIdxLapDistPct[%d]: got %v, want %v", i, td.CarIdxLapDistPct[i], pcts[i])
+		}
+	}
+
+	// Values of a previous frame don't stick around
+	filtered := NewDecoderFiltered(schema, []string{"Speed"})
+	err = filtered.Decode(data, td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if td.Speed != speed || td.Gear != 0 || td.SessionTime != 0 {
+		t.Errorf("filtered: got Speed %v, Gear %v, SessionTime %v", td.Speed, td.Gear, td.SessionTime)
+	}
+
+	err = filtered.Decode(data[:schema.MinBufLen()-1], td)
+	if err != ErrBufferTooSmall {
+		t.Errorf("short buffer: got %v, want %v", err, ErrBufferTooSmall)
+	}
+}
+
+func TestDecoderDoesntAllocate(t *testing.T) {
+	data, schema := fakeTick(t)
+	decoder := NewDecoder(schema)
+	td := NewTelemetryData()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		decoder.Decode(data, td)
+	})
+	if allocs != 0 {
+		t.Errorf("Decode allocates %v times per frame", allocs)
+	}
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	data, schema := fakeTick(b)
+	decoder := NewDecoder(schema)
+	td := NewTelemetryData()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoder.Decode(data, td)
+	}
+}
+
+func BenchmarkNewDecoder(b *testing.B) {
+	_, schema := fakeTick(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewDecoder(schema)
+	}
+}
+
+func BenchmarkFrameFloat(b *testing.B) {
+	data, schema := fakeTick(b)
+	frame := schema.NewFrame(data)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		frame.Float("Speed")
+	}
+}
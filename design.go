@@ -0,0 +1,219 @@
+package irsdk
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidColor  = errors.New("Invalid color")
+	ErrInvalidDesign = errors.New("Invalid design string")
+)
+
+// Color is a 0xRRGGBB color (CarClassColor, LicColor and the colors of the
+// design strings). It implements image/color.Color.
+type Color struct {
+	RGB uint32
+	// Raw is the value of the session info when it isn't a color (the pace
+	// car has "LicColor: 0xundefined"), RGB is 0 then
+	Raw string
+}
+
+// ParseColor parses "ffda59", "#ffda59" or "0xffda59"
+func ParseColor(s string) (Color, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "#")
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || v > 0xffffff {
+		return Color{}, ErrInvalidColor
+	}
+
+	return Color{RGB: uint32(v)}, nil
+}
+
+func (c *Color) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// 0xffda59 is an int to yaml.v2
+	var i int64
+	if err := unmarshal(&i); err == nil && i >= 0 && i <= 0xffffff {
+		*c = Color{RGB: uint32(i)}
+		return nil
+	}
+
+	var s string
+	err := unmarshal(&s)
+	if err != nil {
+		return err
+	}
+
+	*c, err = ParseColor(s)
+	if err != nil {
+		*c = Color{Raw: s}
+	}
+	return nil
+}
+
+func (c Color) R() uint8 {
+	return uint8(c.RGB >> 16)
+}
+
+func (c Color) G() uint8 {
+	return uint8(c.RGB >> 8)
+}
+
+func (c Color) B() uint8 {
+	return uint8(c.RGB)
+}
+
+// RGBA implements image/color.Color
+func (c Color) RGBA() (r, g, b, a uint32) {
+	r = uint32(c.R())
+	g = uint32(c.G())
+	b = uint32(c.B())
+	return r | r<<8, g | g<<8, b | b<<8, 0xffff
+}
+
+// String returns the color as #rrggbb, or Raw when it isn't a color
+func (c Color) String() string {
+	if c.Raw != "" {
+		return c.Raw
+	}
+
+	return fmt.Sprintf("#%06x", c.RGB)
+}
+
+// Design is a paint scheme: CarDesignStr, HelmetDesignStr and SuitDesignStr
+// ("0,FFFFFF,ED2129,2A3795")
+type Design struct {
+	Pattern int
+	Colors  []Color
+	// Raw is the value of the session info when it isn't a design
+	Raw string
+}
+
+func ParseDesign(s string) (Design, error) {
+	d := Design{}
+	if strings.TrimSpace(s) == "" {
+		return d, nil
+	}
+
+	parts := strings.Split(s, ",")
+	pattern, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return d, ErrInvalidDesign
+	}
+	d.Pattern = pattern
+
+	d.Colors, err = parseDesignColors(parts[1:])
+	return d, err
+}
+
+func (d *Design) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	err := unmarshal(&s)
+	if err != nil {
+		return err
+	}
+
+	*d, err = ParseDesign(s)
+	if err != nil {
+		*d = Design{Raw: s}
+	}
+	return nil
+}
+
+// String returns the design in the format of the session info
+func (d Design) String() string {
+	if d.Raw != "" {
+		return d.Raw
+	}
+
+	return strconv.Itoa(d.Pattern) + formatDesignColors(d.Colors)
+}
+
+// NumberDesign is the design of the car number: CarNumberDesignStr
+// ("0,0,FFFFFF,777777,000000")
+type NumberDesign struct {
+	// Pattern is the font of the number
+	Pattern int
+	Slant   int
+	Colors  []Color
+	// Raw is the value of the session info when it isn't a design
+	Raw string
+}
+
+func ParseNumberDesign(s string) (NumberDesign, error) {
+	d := NumberDesign{}
+	if strings.TrimSpace(s) == "" {
+		return d, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) < 2 {
+		return d, ErrInvalidDesign
+	}
+
+	pattern, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return d, ErrInvalidDesign
+	}
+	slant, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return d, ErrInvalidDesign
+	}
+	d.Pattern = pattern
+	d.Slant = slant
+
+	d.Colors, err = parseDesignColors(parts[2:])
+	return d, err
+}
+
+func (d *NumberDesign) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	err := unmarshal(&s)
+	if err != nil {
+		return err
+	}
+
+	*d, err = ParseNumberDesign(s)
+	if err != nil {
+		*d = NumberDesign{Raw: s}
+	}
+	return nil
+}
+
+// String returns the design in the format of the session info
+func (d NumberDesign) String() string {
+	if d.Raw != "" {
+		return d.Raw
+	}
+
+	return strconv.Itoa(d.Pattern) + "," + strconv.Itoa(d.Slant) + formatDesignColors(d.Colors)
+}
+
+func parseDesignColors(parts []string) ([]Color, error) {
+	colors := make([]Color, 0, len(parts))
+	for _, part := range parts {
+		c, err := ParseColor(part)
+		if err != nil {
+			return nil, ErrInvalidDesign
+		}
+		colors = append(colors, c)
+	}
+
+	return colors, nil
+}
+
+func formatDesignColors(colors []Color) string {
+	s := ""
+	for _, c := range colors {
+		s = s + fmt.Sprintf(",%06X", c.RGB)
+	}
+
+	return s
+}
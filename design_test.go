@@ -0,0 +1,88 @@
+package irsdk
+
+import "testing"
+
+func TestParseColor(t *testing.T) {
+	for _, s := range []string{"ffda59", "#ffda59", "0xffda59", "0XFFDA59"} {
+		c, err := ParseColor(s)
+		if err != nil {
+			t.Fatalf("ParseColor(%q): %v", s, err)
+		}
+		if c.RGB != 0xffda59 || c.String() != "#ffda59" {
+			t.Errorf("ParseColor(%q) = %v", s, c)
+		}
+	}
+
+	_, err := ParseColor("0xundefined")
+	if err != ErrInvalidColor {
+		t.Errorf("ParseColor(0xundefined): got %v, want %v", err, ErrInvalidColor)
+	}
+}
+
+func TestDesignString(t *testing.T) {
+	d, err := ParseDesign("0,FFFFFF,ED2129,2A3795")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Pattern != 0 || len(d.Colors) != 3 || d.Colors[1].RGB != 0xed2129 {
+		t.Errorf("ParseDesign: got %+v", d)
+	}
+	if d.String() != "0,FFFFFF,ED2129,2A3795" {
+		t.Errorf("Design.String: got %q", d.String())
+	}
+
+	n, err := ParseNumberDesign("0,0,FFFFFF,777777,000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.String() != "0,0,FFFFFF,777777,000000" {
+		t.Errorf("NumberDesign.String: got %q", n.String())
+	}
+}
+
+func TestUnparseableColorAndDesign(t *testing.T) {
+	yaml := `---
+DriverInfo:
+ Drivers:
+ - CarIdx: 0
+   UserName: Pace Car
+   CarClassColor: 0xffffff
+   LicColor: 0xundefined
+   CarDesignStr: x,y
+   CarNumberDesignStr: 0
+ - CarIdx: 1
+   UserName: Driver
+   LicColor: 0xfc8a27
+   CarDesignStr: 1,FFFFFF,ED2129,2A3795
+...
+`
+	data, err := NewSessionDataFromBytes([]byte(yaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pace := data.DriverInfo.Drivers[0]
+	if pace.LicColor.RGB != 0 || pace.LicColor.Raw != "0xundefined" {
+		t.Errorf("LicColor: got %+v", pace.LicColor)
+	}
+	if pace.LicColor.String() != "0xundefined" {
+		t.Errorf("LicColor.String: got %q", pace.LicColor.String())
+	}
+	if pace.CarClassColor.RGB != 0xffffff {
+		t.Errorf("CarClassColor: got %+v", pace.CarClassColor)
+	}
+	if pace.CarDesignStr.Raw != "x,y" || pace.CarDesignStr.Colors != nil {
+		t.Errorf("CarDesignStr: got %+v", pace.CarDesignStr)
+	}
+	if pace.CarNumberDesignStr.Raw != "0" {
+		t.Errorf("CarNumberDesignStr: got %+v", pace.CarNumberDesignStr)
+	}
+
+	driver := data.DriverInfo.Drivers[1]
+	if driver.LicColor.RGB != 0xfc8a27 || driver.LicColor.Raw != "" {
+		t.Errorf("LicColor: got %+v", driver.LicColor)
+	}
+	if driver.CarDesignStr.Pattern != 1 || len(driver.CarDesignStr.Colors) != 3 {
+		t.Errorf("CarDesignStr: got %+v", driver.CarDesignStr)
+	}
+}
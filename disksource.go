@@ -0,0 +1,76 @@
+package irsdk
+
+import (
+	"io"
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// diskSource replays the datapoints of an .ibt file one by one
+type diskSource struct {
+	tr   *TelemetryReader
+	next int
+	eof  bool
+}
+
+// NewDiskSource creates a Source that reads the datapoints of an .ibt file.
+// Every call to NextVarBuf or WaitForTick returns the next datapoint; after the
+// last one io.EOF is returned. Opening the source again starts at the first
+// datapoint. The caller closes data.
+func NewDiskSource(data io.ReadSeeker) Source {
+	return &diskSource{tr: NewTelemetryReader(data)}
+}
+
+func (s *diskSource) Open() error {
+	s.next = 0
+	s.eof = false
+
+	_, err := s.tr.GetHeader()
+	return err
+}
+
+func (s *diskSource) Close() error {
+	// data is kept open so the source can be opened again after a reconnect
+	return nil
+}
+
+func (s *diskSource) IsConnected() bool {
+	return !s.eof
+}
+
+func (s *diskSource) Header() (*utils.Header, error) {
+	return s.tr.GetHeader()
+}
+
+func (s *diskSource) VarHeaders() ([]*utils.VarHeader, error) {
+	return s.tr.GetVarHeaders()
+}
+
+func (s *diskSource) SessionInfo() ([]byte, error) {
+	return s.tr.ReadRawSessionData()
+}
+
+func (s *diskSource) NextVarBuf() ([]byte, error) {
+	if s.eof {
+		return nil, io.EOF
+	}
+
+	b, err := s.tr.ReadRawDataPointN(s.next)
+	if err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		s.eof = true
+		return nil, io.EOF
+	}
+
+	s.next = s.next + 1
+	return b, nil
+}
+
+func (s *diskSource) WaitForTick(timeOut time.Duration) ([]byte, error) {
+	// Data from disk is always ready
+	return s.NextVarBuf()
+}
@@ -0,0 +1,54 @@
+package irsdk
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskSourceReconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ibt")
+	err := ioutil.WriteFile(path, testIbt{Records: 10}.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	conn := NewConnectionFromSource(NewDiskSource(f))
+	for i := 0; i < 2; i++ {
+		// A reconnect (like the Supervisor does) starts at the first record
+		// again
+		err = conn.Connect()
+		if err != nil {
+			t.Fatalf("connect %d: %v", i, err)
+		}
+
+		for record := 0; record < 3; record++ {
+			frame, err := conn.GetFrame()
+			if err != nil {
+				t.Fatalf("connect %d, record %d: %v", i, record, err)
+			}
+			if speed, _ := frame.Float("Speed"); speed != float32(record) {
+				t.Errorf("connect %d: got Speed %v, want %d", i, speed, record)
+			}
+		}
+
+		err = conn.Disconnect()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The file is still open after Disconnect
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		t.Errorf("file closed by the source: %v", err)
+	}
+}
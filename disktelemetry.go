@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"unsafe"
 
 	"github.com/leonb/irsdk-go/utils"
 )
@@ -16,6 +15,8 @@ type TelemetryReader struct {
 	subHeader   *utils.DiskSubHeader
 	sessionData *SessionData
 	varHeaders  []*utils.VarHeader
+	schema      *Schema
+	decoder     *Decoder
 	dataPoints  []*TelemetryData
 }
 
@@ -75,30 +76,20 @@ func (tr *TelemetryReader) GetHeader() (*utils.Header, error) {
 func (tr *TelemetryReader) ReadHeader() (*utils.Header, error) {
 	header := &utils.Header{}
 
-	// Create byte slice big enough for header data
-	size := binary.Size(header)
-	b := make([]byte, size)
-
 	// Jump to right position in file
 	startByte := 0
 	tr.data.Seek(int64(startByte), 0) // 0 = relative to the origin of the file
 
-	// Read data from file into byteslice
-	_, err := tr.data.Read(b)
+	err := binary.Read(tr.data, binary.LittleEndian, header)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create a pointer to the bytes
-	bPtr := uintptr(unsafe.Pointer(&b[0]))
-
-	// Point header struct to the location of the bytes
-	header = (*utils.Header)(unsafe.Pointer(bPtr))
 	return header, nil
 }
 
-// GetHeader memoizes the ReadSubHeader function
-func (tr *TelemetryReader) GetsubHeader() (*utils.DiskSubHeader, error) {
+// GetSubHeader memoizes the ReadSubHeader function
+func (tr *TelemetryReader) GetSubHeader() (*utils.DiskSubHeader, error) {
 	var err error
 
 	if tr.subHeader == nil {
@@ -109,6 +100,13 @@ func (tr *TelemetryReader) GetsubHeader() (*utils.DiskSubHeader, error) {
 	return tr.subHeader, err
 }
 
+// GetsubHeader is GetSubHeader.
+//
+// Deprecated: use GetSubHeader
+func (tr *TelemetryReader) GetsubHeader() (*utils.DiskSubHeader, error) {
+	return tr.GetSubHeader()
+}
+
 // ReadSubHeader reads the second header specialiy for telemtry data saved to
 // .ibt files
 func (tr *TelemetryReader) ReadSubHeader() (*utils.DiskSubHeader, error) {
@@ -117,28 +115,16 @@ func (tr *TelemetryReader) ReadSubHeader() (*utils.DiskSubHeader, error) {
 		return nil, err
 	}
 
-	subHeader := &utils.DiskSubHeader{}
-	headerSize := binary.Size(header)
-	subHeaderSize := binary.Size(subHeader)
-	startByte := headerSize
-
-	// Create byte slice big enough for subHeader data
-	b := make([]byte, subHeaderSize)
-
-	// Jump to right position in file
+	// The sub header directly follows the header
+	startByte := binary.Size(header)
 	tr.data.Seek(int64(startByte), 0) // 0 = relative to the origin of the file
 
-	// Read data from file into byteslice
-	_, err = tr.data.Read(b)
+	subHeader := &utils.DiskSubHeader{}
+	err = binary.Read(tr.data, binary.LittleEndian, subHeader)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create a pointer to the bytes
-	bPtr := uintptr(unsafe.Pointer(&b[0]))
-
-	// Point subHeader struct to the location of the bytes
-	subHeader = (*utils.DiskSubHeader)(unsafe.Pointer(bPtr))
 	return subHeader, nil
 }
 
@@ -155,6 +141,21 @@ func (tr *TelemetryReader) GetSessionData() (*SessionData, error) {
 }
 
 func (tr *TelemetryReader) ReadSessionData() (*SessionData, error) {
+	b, err := tr.ReadRawSessionData()
+	if err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return nil, ErrEmptySessionData
+	}
+
+	b = bytesToUtf8(b)
+	return NewSessionDataFromBytes(b)
+}
+
+// ReadRawSessionData reads the session info (YAML) string without parsing it
+func (tr *TelemetryReader) ReadRawSessionData() ([]byte, error) {
 	header, err := tr.GetHeader()
 	if err != nil {
 		return nil, err
@@ -175,24 +176,15 @@ func (tr *TelemetryReader) ReadSessionData() (*SessionData, error) {
 	if err != nil {
 		return nil, err
 	}
-	if b == nil {
-		return nil, nil
-	}
 
 	// Copied from GetRawSessionData()
 	sep := []byte("\n...")
 	pieces := bytes.Split(b, sep)
 	if len(pieces) == 0 {
-		return nil, ErrEmptySessionData
-	}
-
-	if pieces[0] == nil {
-		return nil, ErrEmptySessionData
+		return nil, nil
 	}
 
-	b = bytesToUtf8(pieces[0])
-	fmt.Printf("%v\n", b)
-	return NewSessionDataFromBytes(b)
+	return pieces[0], nil
 }
 
 // GetVarHeaders memoizes the ReadHeader function
@@ -215,9 +207,7 @@ func (tr *TelemetryReader) ReadVarHeaders() ([]*utils.VarHeader, error) {
 		return nil, err
 	}
 
-	varHeader := &utils.VarHeader{}
 	startByte := int64(header.VarHeaderOffset)
-	varHeaderSize := binary.Size(varHeader)
 	numVars := int(header.NumVars)
 	varHeaders := make([]*utils.VarHeader, numVars)
 
@@ -225,26 +215,58 @@ func (tr *TelemetryReader) ReadVarHeaders() ([]*utils.VarHeader, error) {
 	tr.data.Seek(startByte, 0) // 0 = relative to the origin of the file
 
 	for i := 0; i < numVars; i++ {
-		// Create byte slice big enough for varHeader data
-		b := make([]byte, varHeaderSize)
-
-		// Read data from file into byteslice
-		_, err = tr.data.Read(b)
+		vh := &utils.VarHeader{}
+		err = binary.Read(tr.data, binary.LittleEndian, vh)
 		if err != nil {
 			return nil, err
 		}
-
-		// Create a pointer to the bytes
-		bPtr := uintptr(unsafe.Pointer(&b[0]))
-
-		// Point varHeader struct to the location of the bytes
-		vh := (*utils.VarHeader)(unsafe.Pointer(bPtr))
 		varHeaders[i] = vh
 	}
 
 	return varHeaders, nil
 }
 
+// GetSchema memoizes the ReadSchema function
+func (tr *TelemetryReader) GetSchema() (*Schema, error) {
+	var err error
+
+	if tr.schema == nil {
+		tr.schema, err = tr.ReadSchema()
+		return tr.schema, err
+	}
+
+	return tr.schema, err
+}
+
+// ReadSchema builds a Schema from the var headers in the file
+func (tr *TelemetryReader) ReadSchema() (*Schema, error) {
+	varHeaders, err := tr.GetVarHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSchema(varHeaders)
+}
+
+// ReadFrameN reads a specific datapoint as a Frame. It returns nil when i is
+// past the last datapoint.
+func (tr *TelemetryReader) ReadFrameN(i int) (*Frame, error) {
+	schema, err := tr.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := tr.ReadRawDataPointN(i)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, nil
+	}
+
+	return schema.NewFrame(b), nil
+}
+
 // GetVarBufs memoizes the ReadHeader function
 func (tr *TelemetryReader) GetAllDataPoints() ([]*TelemetryData, error) {
 	var err error
@@ -257,31 +279,26 @@ func (tr *TelemetryReader) GetAllDataPoints() ([]*TelemetryData, error) {
 	return tr.dataPoints, err
 }
 
-// ReadAllDataPoints reads all datapoints (TelemetrydDat) from the file / memmap
+// ReadAllDataPoints reads all datapoints (TelemetrydDat) from the file / memmap.
+// This keeps the whole session in memory, use Frames to walk over long files.
 func (tr *TelemetryReader) ReadAllDataPoints() ([]*TelemetryData, error) {
-	// diskSubHeader.sessionRecordCount should have the total number of
-	// telemetrydatapoints
-	//
-	// David Tucker wrote: Hmm, maybe this has a clue, it looks like there is an
-	// additional sessionRecordCount parameter tacked onto the YAML session
-	// string when writing out the .ibt file. My code seems to blow away the sub
-	// header sessionRecordCount and always uses the YAML version...
-	sessionRecordCount := 0
-	datapoints := make([]*TelemetryData, sessionRecordCount)
-
-	i := 0
-	for {
+	count, err := tr.RecordCount()
+	if err != nil {
+		return nil, err
+	}
+
+	datapoints := make([]*TelemetryData, 0, count)
+	for i := 0; i < count; i++ {
 		td, err := tr.ReadDataPointN(i)
 		if err != nil {
 			return nil, err
 		}
 		if td == nil {
-			// End of data: get out of while-loop
+			// Truncated file
 			break
 		}
 
 		datapoints = append(datapoints, td)
-		i = i + 1
 	}
 
 	return datapoints, nil
@@ -289,6 +306,35 @@ func (tr *TelemetryReader) ReadAllDataPoints() ([]*TelemetryData, error) {
 
 // ReadDataPointN reads a specific datapoint (TelemetryData)
 func (tr *TelemetryReader) ReadDataPointN(i int) (*TelemetryData, error) {
+	b, err := tr.ReadRawDataPointN(i)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, nil
+	}
+
+	if tr.decoder == nil {
+		schema, err := tr.GetSchema()
+		if err != nil {
+			return nil, err
+		}
+		tr.decoder = NewDecoder(schema)
+	}
+
+	// Create new datapoint
+	td := NewTelemetryData()
+	err = tr.decoder.Decode(b, td)
+	if err != nil {
+		return nil, err
+	}
+
+	return td, nil
+}
+
+// ReadRawDataPointN reads the var buffer of a specific datapoint without
+// parsing it. It returns nil when i is past the last datapoint.
+func (tr *TelemetryReader) ReadRawDataPointN(i int) ([]byte, error) {
 	header, err := tr.GetHeader()
 	if err != nil {
 		return nil, err
@@ -312,23 +358,11 @@ func (tr *TelemetryReader) ReadDataPointN(i int) (*TelemetryData, error) {
 	if err != nil && err != io.EOF {
 		return nil, err
 	}
-	if n <= 0 {
+	if n < varBufSize {
 		return nil, nil
 	}
 
-	// Create new datapoint
-	td := NewTelemetryData()
-
-	// Loop through all headers and parse data/bytes
-	varHeaders, _ := tr.GetVarHeaders()
-	for _, varHeader := range varHeaders {
-		err = td.addVarHeaderData(varHeader, b)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return td, nil
+	return b, nil
 }
 
 // NewTelemetryReader intializes a new TelemetryReader object based on an
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// CSVWriter writes a header line with the column names and a line per row.
+// Bools are written as true/false, floats in the shortest form that reads
+// back the same.
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []*Column
+	record  []string
+
+	// Units adds a second header line with the unit of every column. Read it
+	// with pandas.read_csv(path, header=[0, 1]).
+	Units bool
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{
+		w: csv.NewWriter(w),
+	}
+}
+
+func (cw *CSVWriter) Begin(columns []*Column) error {
+	cw.columns = columns
+	cw.record = make([]string, len(columns))
+
+	for i, c := range columns {
+		cw.record[i] = c.Name
+	}
+	err := cw.w.Write(cw.record)
+	if err != nil {
+		return err
+	}
+
+	if cw.Units {
+		for i, c := range columns {
+			cw.record[i] = c.Unit
+		}
+		return cw.w.Write(cw.record)
+	}
+
+	return nil
+}
+
+func (cw *CSVWriter) WriteRow(values []float64) error {
+	for i, c := range cw.columns {
+		cw.record[i] = formatValue(values[i], c.Type)
+	}
+
+	return cw.w.Write(cw.record)
+}
+
+func (cw *CSVWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func formatValue(v float64, varType utils.VarType) string {
+	switch varType {
+	case utils.BoolType:
+		return strconv.FormatBool(v != 0)
+	case utils.FloatType:
+		return strconv.FormatFloat(v, 'g', -1, 32)
+	case utils.DoubleType:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return strconv.FormatInt(int64(v), 10)
+}
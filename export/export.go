@@ -0,0 +1,423 @@
+// Package export writes telemetry to formats other tools read: CSV and Apache
+// Parquet for pandas, DuckDB and spreadsheets.
+//
+// Variables are exported as columns. Array variables (CarIdx*) become one
+// column per entry (CarIdxRPM_0, CarIdxRPM_1, ...), or select one entry with
+// "CarIdxRPM[3]". SessionTime is always exported, as the first column unless
+// it's selected somewhere else.
+package export
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	irsdk "github.com/leonb/irsdk-go"
+	"github.com/leonb/irsdk-go/utils"
+)
+
+const (
+	// MAX_GAP is the longest pause in the SessionTime (seconds) resampling
+	// interpolates over. No rows are made up inside longer gaps (resets, tows).
+	MAX_GAP = 1.0
+)
+
+var (
+	ErrInvalidColumn   = errors.New("Invalid column")
+	ErrDuplicateColumn = errors.New("Column selected more than once")
+	ErrNoSessionTime   = errors.New("Telemetry has no SessionTime")
+)
+
+// Column is a single exported value: a scalar variable or one entry of an
+// array variable
+type Column struct {
+	// Name is the column name: the variable name, with _i appended for
+	// entries of arrays
+	Name string
+	Unit string
+	Desc string
+	Type utils.VarType
+
+	// Var is the variable the column reads, Index the entry of an array
+	Var   string
+	Index int
+}
+
+// Writer writes rows. Values are passed as float64, which holds every
+// telemetry type exactly; Column.Type tells how to write them.
+type Writer interface {
+	// Begin is called once, before the first row
+	Begin(columns []*Column) error
+	WriteRow(values []float64) error
+	// Close finishes the file. It doesn't close the underlying writer.
+	Close() error
+}
+
+// LapRange is a range of laps (the Lap variable), First and Last included
+type LapRange struct {
+	First int
+	Last  int
+}
+
+// Options select what is exported. The zero value exports every variable of
+// every frame.
+type Options struct {
+	// Vars are the variables to export, in this order. Empty means all. A
+	// column can only be selected once.
+	Vars []string
+	// Rate resamples to this many rows per second. Floats are interpolated,
+	// everything else holds the previous value. 0 writes every frame.
+	Rate float64
+	// From and To limit the export to a SessionTime range (seconds). To 0
+	// means until the end.
+	From float64
+	To   float64
+	// Laps limits the export to a range of laps
+	Laps *LapRange
+}
+
+// Columns returns the columns for vars (all variables when empty) in schema.
+// Selecting a column twice, like "Speed,Speed" or "CarIdxLap,CarIdxLap[1]",
+// returns ErrDuplicateColumn: column names have to be unique in Parquet.
+func Columns(schema *irsdk.Schema, vars []string) ([]*Column, error) {
+	if len(vars) == 0 {
+		for _, v := range schema.Vars() {
+			vars = append(vars, v.Name)
+		}
+	}
+
+	columns := []*Column{}
+	names := make(map[string]bool)
+	add := func(c *Column) error {
+		if names[c.Name] {
+			return fmt.Errorf("%w: %s", ErrDuplicateColumn, c.Name)
+		}
+		names[c.Name] = true
+		columns = append(columns, c)
+		return nil
+	}
+
+	hasTime := false
+	for _, name := range vars {
+		varName, index, err := parseColumn(name)
+		if err != nil {
+			return nil, err
+		}
+
+		v := schema.Lookup(varName)
+		if v == nil {
+			return nil, fmt.Errorf("%w: %s", irsdk.ErrUnknownVar, varName)
+		}
+		if index >= v.Count {
+			return nil, fmt.Errorf("%w: %s", irsdk.ErrIndexOutOfRange, name)
+		}
+
+		if v.Name == "SessionTime" {
+			hasTime = true
+		}
+
+		if index >= 0 {
+			err = add(newColumn(v, index))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		for i := 0; i < v.Count; i++ {
+			err = add(newColumn(v, i))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !hasTime {
+		v := schema.Lookup("SessionTime")
+		if v == nil {
+			return nil, ErrNoSessionTime
+		}
+		columns = append([]*Column{newColumn(v, 0)}, columns...)
+	}
+
+	return columns, nil
+}
+
+// parseColumn splits "Name[i]" in the name and index. Index is -1 without an
+// index.
+func parseColumn(s string) (string, int, error) {
+	open := strings.IndexByte(s, '[')
+	if open < 0 {
+		return s, -1, nil
+	}
+
+	if !strings.HasSuffix(s, "]") {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidColumn, s)
+	}
+
+	index, err := strconv.Atoi(s[open+1 : len(s)-1])
+	if err != nil || index < 0 {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidColumn, s)
+	}
+
+	return s[:open], index, nil
+}
+
+func newColumn(v *irsdk.VarInfo, index int) *Column {
+	name := v.Name
+	if v.IsArray() {
+		name = fmt.Sprintf("%s_%d", v.Name, index)
+	}
+
+	return &Column{
+		Name:  name,
+		Unit:  v.Unit,
+		Desc:  v.Desc,
+		Type:  v.Type,
+		Var:   v.Name,
+		Index: index,
+	}
+}
+
+// Export writes the frames of src selected by opts to w and returns the
+// number of rows written. The columns are based on the schema of the first
+// frame.
+func Export(src FrameSource, w Writer, opts Options) (int, error) {
+	e := &exporter{
+		w:    w,
+		opts: opts,
+		vars: make(map[*irsdk.Schema][]*irsdk.VarInfo),
+	}
+
+	for {
+		frame, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			e.w.Close()
+			return e.rows, err
+		}
+
+		done, err := e.add(frame)
+		if err != nil {
+			e.w.Close()
+			return e.rows, err
+		}
+		if done {
+			break
+		}
+	}
+
+	if e.columns == nil {
+		// Without frames there are no columns, but the file should still be
+		// valid
+		err := e.w.Begin([]*Column{})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return e.rows, e.w.Close()
+}
+
+type exporter struct {
+	w       Writer
+	opts    Options
+	columns []*Column
+	// timeColumn is the index of the SessionTime column
+	timeColumn int
+	// vars caches, per frame schema, the variable of every column
+	vars map[*irsdk.Schema][]*irsdk.VarInfo
+	rows int
+
+	// Resampling state: the previous frame and the index of the next row on
+	// the 1/Rate grid
+	values   []float64
+	prev     []float64
+	prevTime float64
+	hasPrev  bool
+	next     int64
+	row      []float64
+}
+
+// add exports frame. It returns true when the frame is past the range.
+func (e *exporter) add(frame *irsdk.Frame) (bool, error) {
+	sessionTime, err := frame.Double("SessionTime")
+	if err != nil {
+		return false, ErrNoSessionTime
+	}
+
+	if sessionTime < e.opts.From {
+		return false, nil
+	}
+	if e.opts.To > 0 && sessionTime > e.opts.To {
+		return true, nil
+	}
+	if e.opts.Laps != nil {
+		lap, err := frame.Int("Lap")
+		if err != nil {
+			return false, err
+		}
+		if lap < e.opts.Laps.First {
+			return false, nil
+		}
+		if lap > e.opts.Laps.Last {
+			return true, nil
+		}
+	}
+
+	if e.columns == nil {
+		err = e.begin(frame.Schema())
+		if err != nil {
+			return false, err
+		}
+	}
+
+	e.read(frame, e.values)
+
+	if e.opts.Rate <= 0 {
+		return false, e.write(e.values)
+	}
+
+	return false, e.resample(sessionTime)
+}
+
+func (e *exporter) begin(schema *irsdk.Schema) error {
+	columns, err := Columns(schema, e.opts.Vars)
+	if err != nil {
+		return err
+	}
+
+	for i, c := range columns {
+		if c.Var == "SessionTime" {
+			e.timeColumn = i
+		}
+	}
+
+	e.columns = columns
+	e.values = make([]float64, len(columns))
+	e.prev = make([]float64, len(columns))
+	e.row = make([]float64, len(columns))
+	return e.w.Begin(columns)
+}
+
+// resample writes the rows on the grid between the previous frame and this
+// one
+func (e *exporter) resample(sessionTime float64) error {
+	gap := !e.hasPrev || sessionTime-e.prevTime > MAX_GAP || sessionTime < e.prevTime
+	if gap {
+		// Start the grid again at this frame
+		e.next = int64(math.Ceil(sessionTime * e.opts.Rate))
+	}
+
+	for {
+		t := float64(e.next) / e.opts.Rate
+		if t > sessionTime {
+			break
+		}
+
+		if t == sessionTime || gap {
+			copy(e.row, e.values)
+		} else {
+			e.interpolate((t - e.prevTime) / (sessionTime - e.prevTime))
+		}
+		e.row[e.timeColumn] = t
+
+		err := e.write(e.row)
+		if err != nil {
+			return err
+		}
+		e.next = e.next + 1
+	}
+
+	copy(e.prev, e.values)
+	e.prevTime = sessionTime
+	e.hasPrev = true
+	return nil
+}
+
+// interpolate fills row with the values at fraction f between the previous
+// frame and the current one
+func (e *exporter) interpolate(f float64) {
+	for i, c := range e.columns {
+		switch c.Type {
+		case utils.FloatType, utils.DoubleType:
+			e.row[i] = e.prev[i] + (e.values[i]-e.prev[i])*f
+		default:
+			e.row[i] = e.prev[i]
+		}
+	}
+}
+
+func (e *exporter) write(values []float64) error {
+	err := e.w.WriteRow(values)
+	if err != nil {
+		return err
+	}
+
+	e.rows = e.rows + 1
+	return nil
+}
+
+// read decodes the columns of frame into values. Columns the frame doesn't
+// have (the schema changed) are NaN for floats and 0 otherwise.
+func (e *exporter) read(frame *irsdk.Frame, values []float64) {
+	vars, ok := e.vars[frame.Schema()]
+	if !ok {
+		vars = make([]*irsdk.VarInfo, len(e.columns))
+		for i, c := range e.columns {
+			v := frame.Schema().Lookup(c.Var)
+			if v != nil && v.Type == c.Type && c.Index < v.Count {
+				vars[i] = v
+			}
+		}
+		e.vars[frame.Schema()] = vars
+	}
+
+	raw := frame.Raw()
+	for i, c := range e.columns {
+		values[i] = readValue(raw, vars[i], c)
+	}
+}
+
+func readValue(raw []byte, v *irsdk.VarInfo, c *Column) float64 {
+	missing := 0.0
+	if c.Type == utils.FloatType || c.Type == utils.DoubleType {
+		missing = math.NaN()
+	}
+	if v == nil || v.Type < 0 || v.Type >= utils.ETCount {
+		return missing
+	}
+
+	size := int(utils.VarTypeBytes[v.Type])
+	offset := v.Offset + c.Index*size
+	if offset < 0 || offset+size > len(raw) {
+		return missing
+	}
+	b := raw[offset : offset+size]
+
+	switch v.Type {
+	case utils.CharType:
+		return float64(b[0])
+	case utils.BoolType:
+		if b[0] != 0 {
+			return 1
+		}
+		return 0
+	case utils.IntType:
+		return float64(int32(binary.LittleEndian.Uint32(b)))
+	case utils.BitfieldType:
+		return float64(binary.LittleEndian.Uint32(b))
+	case utils.FloatType:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	case utils.DoubleType:
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+
+	return missing
+}
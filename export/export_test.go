@@ -0,0 +1,385 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	irsdk "github.com/leonb/irsdk-go"
+	"github.com/leonb/irsdk-go/fakesim"
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// rowWriter keeps the exported rows in memory
+type rowWriter struct {
+	columns []*Column
+	rows    [][]float64
+	closed  bool
+}
+
+func (w *rowWriter) Begin(columns []*Column) error {
+	w.columns = columns
+	return nil
+}
+
+func (w *rowWriter) WriteRow(values []float64) error {
+	w.rows = append(w.rows, append([]float64(nil), values...))
+	return nil
+}
+
+func (w *rowWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func (w *rowWriter) names() string {
+	names := []string{}
+	for _, c := range w.columns {
+		names = append(names, c.Name)
+	}
+
+	return strings.Join(names, ",")
+}
+
+// frameSource returns frames with a SessionTime and Speed variable
+type frameSource struct {
+	schema *irsdk.Schema
+	times  []float64
+	speeds []float32
+}
+
+func newFrameSource(t *testing.T, times []float64, speeds []float32) *frameSource {
+	schema, err := irsdk.NewSchema([]*utils.VarHeader{
+		testVarHeader("SessionTime", "s", utils.DoubleType, 0, 1),
+		testVarHeader("Speed", "m/s", utils.FloatType, 8, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &frameSource{schema: schema, times: times, speeds: speeds}
+}
+
+func (s *frameSource) Next() (*irsdk.Frame, error) {
+	if len(s.times) == 0 {
+		return nil, io.EOF
+	}
+
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint64(data[0:], math.Float64bits(s.times[0]))
+	binary.LittleEndian.PutUint32(data[8:], math.Float32bits(s.speeds[0]))
+	s.times, s.speeds = s.times[1:], s.speeds[1:]
+
+	return s.schema.NewFrame(data), nil
+}
+
+func exportTestIbt(t *testing.T, records int, opts Options) *rowWriter {
+	tr := writeTestIbt(t, records, fakesim.DefaultSessionInfo)
+	src, err := ReaderFrames(tr, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &rowWriter{}
+	n, err := Export(src, w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(w.rows) {
+		t.Errorf("Export returned %d rows, wrote %d", n, len(w.rows))
+	}
+	if !w.closed {
+		t.Error("writer isn't closed")
+	}
+
+	return w
+}
+
+func TestColumns(t *testing.T) {
+	schema, err := writeTestIbt(t, 1, "").GetSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		vars []string
+		want string
+	}{
+		{nil, "SessionTime,SessionNum,Lap,Speed,Throttle,OnPitRoad,CarIdxLap_0,CarIdxLap_1,CarIdxLap_2"},
+		{[]string{"Speed", "CarIdxLap[1]"}, "SessionTime,Speed,CarIdxLap_1"},
+		{[]string{"Speed", "SessionTime"}, "Speed,SessionTime"},
+	}
+
+	for _, test := range tests {
+		columns, err := Columns(schema, test.vars)
+		if err != nil {
+			t.Fatalf("%v: %v", test.vars, err)
+		}
+
+		w := &rowWriter{columns: columns}
+		if got := w.names(); got != test.want {
+			t.Errorf("%v: got %s, want %s", test.vars, got, test.want)
+		}
+	}
+
+	columns, _ := Columns(schema, []string{"CarIdxLap[2]"})
+	if c := columns[1]; c.Var != "CarIdxLap" || c.Index != 2 || c.Type != utils.IntType {
+		t.Errorf("CarIdxLap[2]: got %+v", c)
+	}
+
+	errs := []struct {
+		vars []string
+		err  error
+	}{
+		{[]string{"Nope"}, irsdk.ErrUnknownVar},
+		{[]string{"CarIdxLap[3]"}, irsdk.ErrIndexOutOfRange},
+		{[]string{"CarIdxLap[1"}, ErrInvalidColumn},
+		{[]string{"CarIdxLap[-1]"}, ErrInvalidColumn},
+		{[]string{"Speed", "Speed"}, ErrDuplicateColumn},
+		{[]string{"CarIdxLap", "CarIdxLap[1]"}, ErrDuplicateColumn},
+		{[]string{"CarIdxLap[1]", "CarIdxLap[1]"}, ErrDuplicateColumn},
+		{[]string{"SessionTime", "Speed", "SessionTime"}, ErrDuplicateColumn},
+	}
+	for _, test := range errs {
+		_, err := Columns(schema, test.vars)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%v: got %v, want %v", test.vars, err, test.err)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	w := exportTestIbt(t, 300, Options{Vars: []string{"Speed", "OnPitRoad", "CarIdxLap[2]"}})
+
+	if w.names() != "SessionTime,Speed,OnPitRoad,CarIdxLap_2" {
+		t.Errorf("columns: got %s", w.names())
+	}
+	if len(w.rows) != 300 {
+		t.Fatalf("got %d rows, want 300", len(w.rows))
+	}
+
+	for i, row := range w.rows {
+		want := []float64{float64(i) / 60, float64(i), float64(i % 2), float64(i/100 + 2)}
+		for j := range want {
+			if row[j] != want[j] {
+				t.Fatalf("row %d: got %v, want %v", i, row, want)
+			}
+		}
+	}
+}
+
+func TestExportRange(t *testing.T) {
+	// SessionTime 1 to 2 seconds
+	w := exportTestIbt(t, 300, Options{Vars: []string{"Speed"}, From: 1, To: 2})
+	if len(w.rows) != 61 || w.rows[0][1] != 60 || w.rows[60][1] != 120 {
+		t.Errorf("From/To: got %d rows from %v to %v", len(w.rows), w.rows[0], w.rows[len(w.rows)-1])
+	}
+
+	// Laps start every 100 records at lap 1
+	w = exportTestIbt(t, 500, Options{Vars: []string{"Speed"}, Laps: &LapRange{First: 2, Last: 3}})
+	if len(w.rows) != 200 || w.rows[0][1] != 100 || w.rows[199][1] != 299 {
+		t.Errorf("Laps: got %d rows from %v to %v", len(w.rows), w.rows[0], w.rows[len(w.rows)-1])
+	}
+
+	w = exportTestIbt(t, 100, Options{From: 10})
+	if len(w.rows) != 0 {
+		t.Errorf("From after the end: got %d rows", len(w.rows))
+	}
+}
+
+func TestExportResample(t *testing.T) {
+	w := exportTestIbt(t, 300, Options{Vars: []string{"Speed", "OnPitRoad"}, Rate: 30})
+	if len(w.rows) != 150 {
+		t.Fatalf("30 Hz: got %d rows, want 150", len(w.rows))
+	}
+	for i, row := range w.rows {
+		if math.Abs(row[0]-float64(i)/30) > 1e-9 || row[1] != float64(i*2) {
+			t.Fatalf("30 Hz row %d: got %v", i, row)
+		}
+	}
+
+	// Between two frames floats are interpolated, bools hold the previous
+	// value
+	w = exportTestIbt(t, 300, Options{Vars: []string{"Speed", "OnPitRoad"}, Rate: 120})
+	if len(w.rows) != 599 {
+		t.Fatalf("120 Hz: got %d rows, want 599", len(w.rows))
+	}
+	row := w.rows[3]
+	if math.Abs(row[0]-3.0/120) > 1e-9 || math.Abs(row[1]-1.5) > 1e-6 || row[2] != 1 {
+		t.Errorf("120 Hz row 3: got %v", row)
+	}
+}
+
+func TestExportResampleGap(t *testing.T) {
+	// A tow: SessionTime jumps from 0.1 to 10 seconds
+	src := newFrameSource(t, []float64{0, 0.05, 0.1, 10, 10.05, 10.1}, []float32{0, 1, 2, 50, 51, 52})
+
+	w := &rowWriter{}
+	_, err := Export(src, w, Options{Rate: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, row := range w.rows {
+		if row[0] > 0.1 && row[0] < 10 {
+			t.Errorf("row inside the gap: %v", row)
+		}
+	}
+	if len(w.rows) != 6 {
+		t.Errorf("got %d rows, want 6: %v", len(w.rows), w.rows)
+	}
+}
+
+func TestExportWithoutFrames(t *testing.T) {
+	buf := &bytes.Buffer{}
+	n, err := Export(newFrameSource(t, nil, nil), NewCSVWriter(buf), Options{})
+	if err != nil || n != 0 {
+		t.Errorf("got %d rows, %v", n, err)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	tr := writeTestIbt(t, 3, fakesim.DefaultSessionInfo)
+	opts := Options{Vars: []string{"Speed", "Throttle", "OnPitRoad", "CarIdxLap[2]"}}
+	src, err := ReaderFrames(tr, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	w := NewCSVWriter(buf)
+	w.Units = true
+	_, err = Export(src, w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"SessionTime,Speed,Throttle,OnPitRoad,CarIdxLap_2",
+		"s,m/s,%,,",
+		"0,0,0.5,false,2",
+		"0.016666666666666666,1,0.5,true,2",
+		"0.03333333333333333,2,0.5,false,2",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestConnectionFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "irsdk.mmap")
+	p, err := fakesim.NewProducer(path, nil, fakesim.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// The sim is running before the export starts
+	err = p.Tick()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go p.Run(stop)
+
+	conn := irsdk.NewConnectionFromSource(irsdk.NewMmapSource(path, nil))
+	err = conn.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Disconnect()
+
+	// A live export runs until it's stopped, also when no new tick arrived
+	// for a while
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	w := &rowWriter{}
+	n, err := Export(ConnectionFrames(ctx, conn), w, Options{Vars: []string{"Speed"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n < 10 {
+		t.Errorf("got %d rows in 500ms at 60 Hz", n)
+	}
+
+	for i := 1; i < len(w.rows); i++ {
+		if w.rows[i][0] <= w.rows[i-1][0] {
+			t.Fatalf("SessionTime didn't increase: %v after %v", w.rows[i], w.rows[i-1])
+		}
+	}
+}
+
+func TestConnectionFramesWithoutSim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.mmap")
+	conn := irsdk.NewConnectionFromSource(irsdk.NewMmapSource(path, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	n, err := Export(ConnectionFrames(ctx, conn), &rowWriter{}, Options{})
+	if err != nil || n != 0 {
+		t.Errorf("got %d rows, %v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("export took %v after it was stopped", elapsed)
+	}
+}
+
+// idleSource is connected but never has data, like the sim before it's
+// connected to a session
+type idleSource struct {
+	mu    sync.Mutex
+	waits int
+}
+
+func (s *idleSource) Open() error                             { return nil }
+func (s *idleSource) Close() error                            { return nil }
+func (s *idleSource) IsConnected() bool                       { return false }
+func (s *idleSource) Header() (*utils.Header, error)          { return nil, nil }
+func (s *idleSource) VarHeaders() ([]*utils.VarHeader, error) { return nil, nil }
+func (s *idleSource) SessionInfo() ([]byte, error)            { return nil, nil }
+func (s *idleSource) NextVarBuf() ([]byte, error)             { return s.WaitForTick(0) }
+
+func (s *idleSource) WaitForTick(timeOut time.Duration) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.waits = s.waits + 1
+	return nil, nil
+}
+
+func TestConnectionFramesBackoff(t *testing.T) {
+	src := &idleSource{}
+	conn := irsdk.NewConnectionFromSource(src)
+	err := conn.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	n, err := Export(ConnectionFrames(ctx, conn), &rowWriter{}, Options{})
+	if err != nil || n != 0 {
+		t.Errorf("got %d rows, %v", n, err)
+	}
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if src.waits > 2 {
+		t.Errorf("source read %d times in 200ms with a back off of %v", src.waits, irsdk.DEFAULT_MIN_BACKOFF)
+	}
+}
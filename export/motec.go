@@ -0,0 +1,555 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	irsdk "github.com/leonb/irsdk-go"
+	"github.com/leonb/irsdk-go/utils"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	// MOTEC_BEACON is the name of the channel that is 1 on the first sample of
+	// every lap
+	MOTEC_BEACON = "Beacon"
+)
+
+// MoTeC channel data types
+const (
+	motecTypeInt   = 0x03
+	motecTypeFloat = 0x07
+)
+
+// motecChunkRows is the number of rows buffered per channel before they're
+// written
+const motecChunkRows = 4096
+
+var (
+	ErrInvalidRate = errors.New("Rate doesn't divide the tick rate")
+)
+
+// motecUnits maps iRacing units to the ones MoTeC knows, with the factor the
+// values are multiplied with. iRacing writes percentages as 0-1.
+var motecUnits = map[string]struct {
+	unit   string
+	factor float64
+}{
+	"%":        {"%", 100},
+	"revs/min": {"rpm", 1},
+	"m/s^2":    {"m/s/s", 1},
+	"l":        {"L", 1},
+	"l/hr":     {"L/h", 1},
+	"N*m":      {"Nm", 1},
+}
+
+// MotecOptions configures WriteMotec. The zero value writes every scalar
+// variable at the tick rate.
+type MotecOptions struct {
+	// Vars are the variables to write, like Options.Vars. Empty means every
+	// variable that isn't an array.
+	Vars []string
+	// Rate is the sample rate (Hz). It must divide the tick rate of the file,
+	// 0 keeps the tick rate.
+	Rate int
+	// Comment is the short comment of the log
+	Comment string
+}
+
+// The .ld layout as read by MoTeC i2. Blank fields are unknown and written as
+// zeroes, the other constants are what MoTeC's own loggers write.
+type motecHeader struct {
+	Marker        uint32
+	_             [4]byte
+	ChannelPtr    uint32
+	DataPtr       uint32
+	_             [20]byte
+	EventPtr      uint32
+	_             [24]byte
+	Unknown1      [3]uint16
+	DeviceSerial  uint32
+	DeviceType    [8]byte
+	DeviceVersion uint16
+	Unknown2      uint16
+	NumChannels   uint32
+	_             [4]byte
+	Date          [16]byte
+	_             [16]byte
+	Time          [16]byte
+	_             [16]byte
+	Driver        [64]byte
+	VehicleID     [64]byte
+	_             [64]byte
+	Venue         [64]byte
+	_             [64]byte
+	_             [1024]byte
+	ProLogging    uint32
+	_             [66]byte
+	ShortComment  [64]byte
+	_             [126]byte
+}
+
+type motecEvent struct {
+	Name     [64]byte
+	Session  [64]byte
+	Comment  [1024]byte
+	VenuePtr uint16
+}
+
+type motecVenue struct {
+	Name       [64]byte
+	_          [1034]byte
+	VehiclePtr uint16
+}
+
+type motecVehicle struct {
+	ID      [64]byte
+	_       [128]byte
+	Weight  uint32
+	Type    [32]byte
+	Comment [32]byte
+}
+
+type motecChannelHeader struct {
+	PrevPtr   uint32
+	NextPtr   uint32
+	DataPtr   uint32
+	DataLen   uint32
+	Counter   uint16
+	DataTypeA uint16
+	DataType  uint16
+	Freq      uint16
+	Shift     int16
+	Mul       int16
+	Scale     int16
+	Dec       int16
+	Name      [32]byte
+	ShortName [8]byte
+	Unit      [12]byte
+	_         [40]byte
+}
+
+// motecChannel is a channel being written
+type motecChannel struct {
+	header motecChannelHeader
+	column *Column
+	factor float64
+	size   int
+	buf    []byte
+}
+
+// WriteMotec converts an .ibt file to a MoTeC i2 log: ld gets the .ld file
+// and ldx, when not nil, the .ldx file with the lap beacons. The venue,
+// vehicle, driver, event and session come from the session info, they stay
+// empty when it can't be parsed.
+//
+// Every channel gets the sample rate of the file (or Rate), units are
+// translated to the ones MoTeC knows and the Beacon channel marks the start
+// of every lap (the Lap variable).
+func WriteMotec(tr *irsdk.TelemetryReader, ld io.WriterAt, ldx io.Writer, opts MotecOptions) error {
+	header, err := tr.GetHeader()
+	if err != nil {
+		return err
+	}
+	subHeader, err := tr.GetSubHeader()
+	if err != nil {
+		return err
+	}
+	schema, err := tr.GetSchema()
+	if err != nil {
+		return err
+	}
+	session, err := tr.GetSessionData()
+	if err != nil {
+		// The telemetry is still fine, only the venue, vehicle, driver and
+		// event stay empty
+		session = &irsdk.SessionData{}
+	}
+
+	step := 1
+	rate := int(header.TickRate)
+	if opts.Rate > 0 {
+		if opts.Rate > rate || rate%opts.Rate != 0 {
+			return ErrInvalidRate
+		}
+		step = rate / opts.Rate
+		rate = opts.Rate
+	}
+
+	names := opts.Vars
+	if len(names) == 0 {
+		for _, v := range schema.Vars() {
+			if !v.IsArray() {
+				names = append(names, v.Name)
+			}
+		}
+	}
+
+	columns, err := Columns(schema, names)
+	if err != nil {
+		return err
+	}
+	columns = append(columns, &Column{
+		Name: MOTEC_BEACON,
+		Desc: "Start of a lap",
+		Type: utils.BoolType,
+	})
+
+	records, err := tr.RecordCount()
+	if err != nil {
+		return err
+	}
+	maxRows := (records + step - 1) / step
+
+	// Layout: header, event, venue, vehicle, channel headers, channel data
+	eventPtr := binary.Size(motecHeader{})
+	venuePtr := eventPtr + binary.Size(motecEvent{})
+	vehiclePtr := venuePtr + binary.Size(motecVenue{})
+	channelPtr := vehiclePtr + binary.Size(motecVehicle{})
+	dataPtr := channelPtr + len(columns)*binary.Size(motecChannelHeader{})
+
+	channels := make([]*motecChannel, len(columns))
+	offset := dataPtr
+	for i, c := range columns {
+		ch := newMotecChannel(c, i, rate)
+		ch.header.DataPtr = uint32(offset)
+		if i > 0 {
+			ch.header.PrevPtr = uint32(channelPtr + (i-1)*binary.Size(ch.header))
+		}
+		if i < len(columns)-1 {
+			ch.header.NextPtr = uint32(channelPtr + (i+1)*binary.Size(ch.header))
+		}
+
+		channels[i] = ch
+		offset = offset + maxRows*ch.size
+	}
+
+	it, err := tr.Frames()
+	if err != nil {
+		return err
+	}
+
+	// beacons are the times (seconds in the log) laps start
+	beacons := []float64{}
+	lapVar := schema.Lookup("Lap")
+	lapColumn := &Column{Var: "Lap", Type: utils.IntType}
+	sessionNum := -1
+	prevLap := 0
+	pending := false
+
+	vars := make([]*irsdk.VarInfo, len(columns)-1)
+	for i, c := range columns[:len(columns)-1] {
+		vars[i] = schema.Lookup(c.Var)
+	}
+
+	rows := 0
+	chunkStart := 0
+	values := make([]float64, len(columns))
+	for it.Next() {
+		raw := it.Frame().Raw()
+
+		if lapVar != nil && lapVar.Type == utils.IntType {
+			lap := int(readValue(raw, lapVar, lapColumn))
+			if it.Index() > 0 && lap != prevLap {
+				pending = true
+			}
+			prevLap = lap
+		}
+
+		if it.Index()%step != 0 {
+			continue
+		}
+
+		if sessionNum < 0 {
+			num, err := it.Frame().Int("SessionNum")
+			if err == nil {
+				sessionNum = num
+			}
+		}
+
+		for i, c := range columns[:len(columns)-1] {
+			values[i] = readValue(raw, vars[i], c)
+		}
+		values[len(values)-1] = 0
+		if pending {
+			values[len(values)-1] = 1
+			beacons = append(beacons, float64(rows)/float64(rate))
+			pending = false
+		}
+
+		row := rows - chunkStart
+		for i, ch := range channels {
+			ch.put(row, values[i])
+		}
+
+		rows = rows + 1
+		if rows-chunkStart == motecChunkRows || rows == maxRows {
+			err = flushMotecChunk(ld, channels, chunkStart, rows-chunkStart)
+			if err != nil {
+				return err
+			}
+			chunkStart = rows
+		}
+
+		if rows == maxRows {
+			break
+		}
+	}
+	if it.Err() != nil {
+		return it.Err()
+	}
+	if rows > chunkStart {
+		err = flushMotecChunk(ld, channels, chunkStart, rows-chunkStart)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, ch := range channels {
+		ch.header.DataLen = uint32(rows)
+		err = writeAtLE(ld, int64(channelPtr+i*binary.Size(ch.header)), &ch.header)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeMotecMetadata(ld, session, sessionNum, subHeader, opts.Comment, eventPtr, venuePtr, vehiclePtr)
+	if err != nil {
+		return err
+	}
+
+	mh := motecHeader{
+		Marker:        0x40,
+		ChannelPtr:    uint32(channelPtr),
+		DataPtr:       uint32(dataPtr),
+		EventPtr:      uint32(eventPtr),
+		Unknown1:      [3]uint16{1, 0x4240, 0xf},
+		DeviceSerial:  0x1f44,
+		DeviceVersion: 420,
+		Unknown2:      0xadb0,
+		NumChannels:   uint32(len(channels)),
+		ProLogging:    0xc81a4,
+	}
+	copy(mh.DeviceType[:], "ADL")
+
+	start := subHeader.StartDate()
+	copy(mh.Date[:], start.Format("02/01/2006"))
+	copy(mh.Time[:], start.Format("15:04:05"))
+	if driver := session.PlayerDriver(); driver != nil {
+		motecString(mh.Driver[:], driver.UserName)
+		motecString(mh.VehicleID[:], driver.CarScreenName)
+	}
+	motecString(mh.Venue[:], session.WeekendInfo.TrackDisplayName)
+	motecString(mh.ShortComment[:], opts.Comment)
+
+	err = writeAtLE(ld, 0, &mh)
+	if err != nil {
+		return err
+	}
+
+	if ldx != nil {
+		return writeMotecLdx(ldx, beacons)
+	}
+
+	return nil
+}
+
+func newMotecChannel(c *Column, i int, rate int) *motecChannel {
+	ch := &motecChannel{
+		column: c,
+		factor: 1,
+	}
+
+	ch.header.Counter = uint16(0x2ee1 + i)
+	ch.header.Freq = uint16(rate)
+	ch.header.Mul = 1
+	ch.header.Scale = 1
+
+	switch c.Type {
+	case utils.FloatType, utils.DoubleType:
+		ch.header.DataTypeA = motecTypeFloat
+		ch.header.DataType = 4
+		ch.size = 4
+	case utils.IntType, utils.BitfieldType:
+		ch.header.DataTypeA = motecTypeInt
+		ch.header.DataType = 4
+		ch.size = 4
+	default:
+		ch.header.DataTypeA = motecTypeInt
+		ch.header.DataType = 2
+		ch.size = 2
+	}
+
+	unit := c.Unit
+	if m, ok := motecUnits[unit]; ok {
+		unit = m.unit
+		if ch.header.DataTypeA == motecTypeFloat {
+			ch.factor = m.factor
+		}
+	}
+	if len(unit) >= len(ch.header.Unit) || (len(unit) > 6 && unit[:6] == "irsdk_") {
+		// Enums and bitfields have no unit
+		unit = ""
+	}
+
+	motecString(ch.header.Name[:], c.Name)
+	motecString(ch.header.ShortName[:], c.Name)
+	motecString(ch.header.Unit[:], unit)
+
+	ch.buf = make([]byte, motecChunkRows*ch.size)
+	return ch
+}
+
+// put stores value as row of the current chunk
+func (ch *motecChannel) put(row int, value float64) {
+	b := ch.buf[row*ch.size : (row+1)*ch.size]
+	switch {
+	case ch.header.DataTypeA == motecTypeFloat:
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(value*ch.factor)))
+	case ch.size == 4:
+		if ch.column.Type == utils.BitfieldType {
+			binary.LittleEndian.PutUint32(b, uint32(value))
+		} else {
+			binary.LittleEndian.PutUint32(b, uint32(int32(value)))
+		}
+	default:
+		binary.LittleEndian.PutUint16(b, uint16(int16(value)))
+	}
+}
+
+func flushMotecChunk(ld io.WriterAt, channels []*motecChannel, start int, rows int) error {
+	for _, ch := range channels {
+		offset := int64(ch.header.DataPtr) + int64(start*ch.size)
+		_, err := ld.WriteAt(ch.buf[:rows*ch.size], offset)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeMotecMetadata(ld io.WriterAt, session *irsdk.SessionData, sessionNum int, subHeader *utils.DiskSubHeader, comment string, eventPtr, venuePtr, vehiclePtr int) error {
+	event := motecEvent{VenuePtr: uint16(venuePtr)}
+	motecString(event.Name[:], session.WeekendInfo.EventType)
+	s := session.CurrentSession(sessionNum)
+	if s == nil && len(session.SessionInfo.Sessions) == 1 {
+		// Without the SessionNum variable only a single session is certain
+		s = &session.SessionInfo.Sessions[0]
+	}
+	if s != nil {
+		motecString(event.Session[:], s.SessionType)
+	}
+	motecString(event.Comment[:], comment)
+
+	venue := motecVenue{VehiclePtr: uint16(vehiclePtr)}
+	motecString(venue.Name[:], session.WeekendInfo.TrackDisplayName)
+
+	vehicle := motecVehicle{}
+	if driver := session.PlayerDriver(); driver != nil {
+		motecString(vehicle.ID[:], driver.CarScreenName)
+		motecString(vehicle.Type[:], driver.CarClassShortName)
+		motecString(vehicle.Comment[:], driver.CarNumber)
+	}
+
+	err := writeAtLE(ld, int64(eventPtr), &event)
+	if err != nil {
+		return err
+	}
+	err = writeAtLE(ld, int64(venuePtr), &venue)
+	if err != nil {
+		return err
+	}
+
+	return writeAtLE(ld, int64(vehiclePtr), &vehicle)
+}
+
+// writeMotecLdx writes the lap beacons and the fastest lap. MoTeC counts the
+// part before the first beacon as lap 1.
+func writeMotecLdx(w io.Writer, beacons []float64) error {
+	fastestLap := 0
+	fastestTime := 0.0
+	for i := 1; i < len(beacons); i++ {
+		lapTime := beacons[i] - beacons[i-1]
+		if fastestLap == 0 || lapTime < fastestTime {
+			fastestLap = i + 1
+			fastestTime = lapTime
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "<?xml version=\"1.0\"?>\n"+
+		"<LDXFile Locale=\"English_United States.1252\" DefaultLocale=\"C\" Version=\"1.6\">\n"+
+		" <Layers>\n"+
+		"  <Layer>\n"+
+		"   <MarkerBlock>\n"+
+		"    <MarkerGroup Name=\"Beacons\" Index=\"%d\">\n", len(beacons))
+	if err != nil {
+		return err
+	}
+
+	for i, t := range beacons {
+		_, err = fmt.Fprintf(w, "     <Marker Version=\"100\" ClassName=\"BCN\" Name=\"Manual.%d\" Flags=\"77\" Time=\"%s\"/>\n",
+			i+1, strconv.FormatFloat(t*1e6, 'e', 6, 64))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "    </MarkerGroup>\n"+
+		"   </MarkerBlock>\n"+
+		"   <RangeBlock/>\n"+
+		"  </Layer>\n"+
+		"  <Details>\n"+
+		"   <String Id=\"Total Laps\" Value=\"%d\"/>\n", len(beacons)+1)
+	if err != nil {
+		return err
+	}
+
+	if fastestLap > 0 {
+		_, err = fmt.Fprintf(w, "   <String Id=\"Fastest Time\" Value=\"%s\"/>\n"+
+			"   <String Id=\"Fastest Lap\" Value=\"%d\"/>\n", formatLapTime(fastestTime), fastestLap)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "  </Details>\n"+
+		" </Layers>\n"+
+		"</LDXFile>\n")
+	return err
+}
+
+// formatLapTime formats seconds as m:ss.sss
+func formatLapTime(t float64) string {
+	ms := int64(math.Round(t * 1000))
+	return fmt.Sprintf("%d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+}
+
+// motecString copies s, in Windows-1252 like the rest of the file, into the
+// fixed size field dst. The last byte is always NUL.
+func motecString(dst []byte, s string) {
+	b, err := encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).Bytes([]byte(s))
+	if err != nil {
+		b = []byte(s)
+	}
+
+	copy(dst[:len(dst)-1], b)
+}
+
+// writeAtLE writes the little endian encoding of v at offset
+func writeAtLE(w io.WriterAt, offset int64, v interface{}) error {
+	buf := &bytes.Buffer{}
+	err := binary.Write(buf, binary.LittleEndian, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.WriteAt(buf.Bytes(), offset)
+	return err
+}
@@ -0,0 +1,210 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	irsdk "github.com/leonb/irsdk-go"
+	"github.com/leonb/irsdk-go/fakesim"
+	"github.com/leonb/irsdk-go/utils"
+)
+
+func testVarHeader(name, unit string, varType utils.VarType, offset, count int32) *utils.VarHeader {
+	vh := &utils.VarHeader{Type: varType, Offset: offset, Count: count}
+	copy(vh.Name[:], name)
+	copy(vh.Unit[:], unit)
+	return vh
+}
+
+// writeTestIbt writes an .ibt file of records at 60 Hz: a lap takes 100
+// records and Speed is the index of the record
+func writeTestIbt(t *testing.T, records int, sessionInfo string) *irsdk.TelemetryReader {
+	schema, err := irsdk.NewSchema([]*utils.VarHeader{
+		testVarHeader("SessionTime", "s", utils.DoubleType, 0, 1),
+		testVarHeader("SessionNum", "", utils.IntType, 8, 1),
+		testVarHeader("Lap", "", utils.IntType, 12, 1),
+		testVarHeader("Speed", "m/s", utils.FloatType, 16, 1),
+		testVarHeader("Throttle", "%", utils.FloatType, 20, 1),
+		testVarHeader("OnPitRoad", "", utils.BoolType, 24, 1),
+		testVarHeader("CarIdxLap", "", utils.IntType, 28, 3),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.ibt")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w, err := irsdk.NewIbtWriter(f, schema, []byte(sessionInfo), irsdk.IbtWriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 40)
+	for i := 0; i < records; i++ {
+		binary.LittleEndian.PutUint64(data[0:], math.Float64bits(float64(i)/60))
+		binary.LittleEndian.PutUint32(data[12:], uint32(i/100+1))
+		binary.LittleEndian.PutUint32(data[16:], math.Float32bits(float32(i)))
+		binary.LittleEndian.PutUint32(data[20:], math.Float32bits(0.5))
+		data[24] = byte(i % 2)
+		for car := 0; car < 3; car++ {
+			binary.LittleEndian.PutUint32(data[28+car*4:], uint32(i/100+car))
+		}
+
+		err = w.WriteFrame(schema.NewFrame(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+
+	return irsdk.NewTelemetryReader(r)
+}
+
+// readMotec returns the header and the channels of an .ld file
+func readMotec(t *testing.T, ld []byte) (motecHeader, []motecChannelHeader) {
+	var header motecHeader
+	err := binary.Read(bytes.NewReader(ld), binary.LittleEndian, &header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	channels := make([]motecChannelHeader, header.NumChannels)
+	ptr := header.ChannelPtr
+	for i := range channels {
+		err = binary.Read(bytes.NewReader(ld[ptr:]), binary.LittleEndian, &channels[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ptr = channels[i].NextPtr
+	}
+
+	return header, channels
+}
+
+func cString(b []byte) string {
+	return utils.CToGoString(b)
+}
+
+// ldFile is an in memory io.WriterAt
+type ldFile struct {
+	b []byte
+}
+
+func (f *ldFile) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(f.b) {
+		f.b = append(f.b, make([]byte, end-len(f.b))...)
+	}
+
+	return copy(f.b[off:], p), nil
+}
+
+func TestWriteMotec(t *testing.T) {
+	tr := writeTestIbt(t, 1000, fakesim.DefaultSessionInfo)
+
+	ld := &ldFile{}
+	ldx := &bytes.Buffer{}
+	err := WriteMotec(tr, ld, ldx, MotecOptions{Rate: 30, Comment: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, channels := readMotec(t, ld.b)
+	if cString(header.Venue[:]) != "Lime Rock Park" || cString(header.Driver[:]) != "Fake Driver" {
+		t.Errorf("got venue %q and driver %q", cString(header.Venue[:]), cString(header.Driver[:]))
+	}
+	if cString(header.ShortComment[:]) != "test" {
+		t.Errorf("ShortComment: got %q", cString(header.ShortComment[:]))
+	}
+
+	// Every scalar variable, SessionTime first, and the beacon
+	names := []string{}
+	for _, ch := range channels {
+		names = append(names, cString(ch.Name[:]))
+		if ch.DataLen != 500 || ch.Freq != 30 {
+			t.Errorf("%s: got %d samples at %d Hz, want 500 at 30 Hz", cString(ch.Name[:]), ch.DataLen, ch.Freq)
+		}
+	}
+	if got := strings.Join(names, ","); got != "SessionTime,SessionNum,Lap,Speed,Throttle,OnPitRoad,Beacon" {
+		t.Errorf("channels: got %s", got)
+	}
+
+	speed := channels[3]
+	if cString(speed.Unit[:]) != "m/s" {
+		t.Errorf("Speed unit: got %q", cString(speed.Unit[:]))
+	}
+	for _, row := range []int{0, 1, 499} {
+		v := math.Float32frombits(binary.LittleEndian.Uint32(ld.b[int(speed.DataPtr)+row*4:]))
+		if v != float32(row*2) {
+			t.Errorf("Speed[%d]: got %v, want %v", row, v, row*2)
+		}
+	}
+
+	throttle := channels[4]
+	if v := math.Float32frombits(binary.LittleEndian.Uint32(ld.b[throttle.DataPtr:])); v != 50 {
+		t.Errorf("Throttle: got %v, want 50 (%%)", v)
+	}
+
+	beacon := channels[6]
+	beacons := 0
+	for row := 0; row < int(beacon.DataLen); row++ {
+		if binary.LittleEndian.Uint16(ld.b[int(beacon.DataPtr)+row*2:]) == 1 {
+			beacons = beacons + 1
+			if row%50 != 0 {
+				t.Errorf("beacon at row %d, laps start every 50 rows", row)
+			}
+		}
+	}
+	if beacons != 9 {
+		t.Errorf("got %d beacons, want 9", beacons)
+	}
+
+	if n := strings.Count(ldx.String(), "<Marker "); n != 9 {
+		t.Errorf("got %d markers in the .ldx file, want 9", n)
+	}
+	if !strings.Contains(ldx.String(), `<String Id="Total Laps" Value="10"/>`) {
+		t.Errorf(".ldx file without the lap count:\n%s", ldx.String())
+	}
+}
+
+func TestWriteMotecWithoutSessionInfo(t *testing.T) {
+	tr := writeTestIbt(t, 100, "WeekendInfo: [")
+
+	ld := &ldFile{}
+	err := WriteMotec(tr, ld, nil, MotecOptions{Vars: []string{"Speed", "CarIdxLap[2]"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, channels := readMotec(t, ld.b)
+	if cString(header.Venue[:]) != "" || cString(header.Driver[:]) != "" {
+		t.Errorf("got venue %q and driver %q", cString(header.Venue[:]), cString(header.Driver[:]))
+	}
+	if len(channels) != 4 || channels[0].DataLen != 100 || channels[0].Freq != 60 {
+		t.Errorf("got %d channels of %d samples at %d Hz", len(channels), channels[0].DataLen, channels[0].Freq)
+	}
+
+	err = WriteMotec(tr, ld, nil, MotecOptions{Rate: 7})
+	if err != ErrInvalidRate {
+		t.Errorf("Rate 7: got %v, want %v", err, ErrInvalidRate)
+	}
+}
@@ -0,0 +1,352 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+const (
+	// PARQUET_ROW_GROUP_SIZE is the number of buffered bytes after which a
+	// row group is written
+	PARQUET_ROW_GROUP_SIZE = 64 * 1024 * 1024
+)
+
+// Parquet physical types, converted types and encodings
+const (
+	parquetBoolean = 0
+	parquetInt32   = 1
+	parquetFloat   = 4
+	parquetDouble  = 5
+
+	parquetUint8  = 11
+	parquetUint32 = 13
+
+	parquetPlain = 0
+	parquetRLE   = 3
+)
+
+var parquetMagic = []byte("PAR1")
+
+// ParquetWriter writes an uncompressed Parquet file with a flat schema: a
+// required column per Column. The unit and description of every column are in
+// the key value metadata of its column chunks, and the units of all columns
+// are in the file metadata as a JSON object under "units":
+//
+//	import pyarrow.parquet as pq
+//	json.loads(pq.read_schema(path).metadata[b"units"])
+//
+//	SELECT value FROM parquet_kv_metadata('file.parquet') WHERE key = 'units';
+type ParquetWriter struct {
+	w      io.Writer
+	offset int64
+
+	columns []*Column
+	buffers []*parquetBuffer
+	// rows is the number of buffered rows, buffered their size
+	rows     int64
+	buffered int
+
+	rowGroups []*parquetRowGroup
+	numRows   int64
+
+	// Metadata is added to the key value metadata of the file
+	Metadata map[string]string
+}
+
+// parquetBuffer holds the values of a column of the current row group
+type parquetBuffer struct {
+	buf bytes.Buffer
+	// bits and nbits hold the booleans that don't fill a byte yet
+	bits  byte
+	nbits uint
+}
+
+type parquetRowGroup struct {
+	chunks []parquetChunk
+	rows   int64
+	size   int64
+}
+
+type parquetChunk struct {
+	offset int64
+	size   int64
+}
+
+func NewParquetWriter(w io.Writer) *ParquetWriter {
+	return &ParquetWriter{
+		w: w,
+	}
+}
+
+func (pw *ParquetWriter) Begin(columns []*Column) error {
+	pw.columns = columns
+	pw.buffers = make([]*parquetBuffer, len(columns))
+	for i := range columns {
+		pw.buffers[i] = &parquetBuffer{}
+	}
+
+	return pw.write(parquetMagic)
+}
+
+func (pw *ParquetWriter) WriteRow(values []float64) error {
+	var b [8]byte
+	for i, c := range pw.columns {
+		buf := pw.buffers[i]
+		switch c.Type {
+		case utils.BoolType:
+			if values[i] != 0 {
+				buf.bits = buf.bits | 1<<buf.nbits
+			}
+			buf.nbits = buf.nbits + 1
+			if buf.nbits == 8 {
+				buf.buf.WriteByte(buf.bits)
+				buf.bits, buf.nbits = 0, 0
+			}
+		case utils.CharType, utils.IntType:
+			binary.LittleEndian.PutUint32(b[:], uint32(int32(values[i])))
+			buf.buf.Write(b[:4])
+			pw.buffered = pw.buffered + 4
+		case utils.BitfieldType:
+			binary.LittleEndian.PutUint32(b[:], uint32(values[i]))
+			buf.buf.Write(b[:4])
+			pw.buffered = pw.buffered + 4
+		case utils.FloatType:
+			binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(values[i])))
+			buf.buf.Write(b[:4])
+			pw.buffered = pw.buffered + 4
+		default:
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(values[i]))
+			buf.buf.Write(b[:8])
+			pw.buffered = pw.buffered + 8
+		}
+	}
+
+	pw.rows = pw.rows + 1
+	if pw.buffered >= PARQUET_ROW_GROUP_SIZE {
+		return pw.flush()
+	}
+
+	return nil
+}
+
+// Close writes the last row group and the file metadata
+func (pw *ParquetWriter) Close() error {
+	if pw.rows > 0 {
+		err := pw.flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	footer := pw.fileMetaData()
+	err := pw.write(footer)
+	if err != nil {
+		return err
+	}
+
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	err = pw.write(length[:])
+	if err != nil {
+		return err
+	}
+
+	return pw.write(parquetMagic)
+}
+
+// flush writes the buffered rows as a row group, with a single data page per
+// column
+func (pw *ParquetWriter) flush() error {
+	rg := &parquetRowGroup{
+		chunks: make([]parquetChunk, len(pw.columns)),
+		rows:   pw.rows,
+	}
+
+	for i, buf := range pw.buffers {
+		if buf.nbits > 0 {
+			buf.buf.WriteByte(buf.bits)
+			buf.bits, buf.nbits = 0, 0
+		}
+		data := buf.buf.Bytes()
+
+		t := &thriftWriter{}
+		t.StructElem()
+		t.I32(1, 0) // DATA_PAGE
+		t.I32(2, int32(len(data)))
+		t.I32(3, int32(len(data)))
+		t.Struct(5)
+		t.I32(1, int32(pw.rows))
+		t.I32(2, parquetPlain)
+		t.I32(3, parquetRLE)
+		t.I32(4, parquetRLE)
+		t.End()
+		t.End()
+
+		rg.chunks[i].offset = pw.offset
+		err := pw.write(t.Bytes())
+		if err != nil {
+			return err
+		}
+		err = pw.write(data)
+		if err != nil {
+			return err
+		}
+		rg.chunks[i].size = pw.offset - rg.chunks[i].offset
+		rg.size = rg.size + rg.chunks[i].size
+
+		buf.buf.Reset()
+	}
+
+	pw.rowGroups = append(pw.rowGroups, rg)
+	pw.numRows = pw.numRows + pw.rows
+	pw.rows = 0
+	pw.buffered = 0
+	return nil
+}
+
+func (pw *ParquetWriter) fileMetaData() []byte {
+	t := &thriftWriter{}
+	t.StructElem()
+	t.I32(1, 1)
+
+	t.List(2, thriftStruct, len(pw.columns)+1)
+	t.StructElem()
+	t.String(4, "schema")
+	t.I32(5, int32(len(pw.columns)))
+	t.End()
+	for _, c := range pw.columns {
+		physical, converted := parquetType(c.Type)
+		t.StructElem()
+		t.I32(1, physical)
+		t.I32(3, 0) // REQUIRED
+		t.String(4, c.Name)
+		if converted >= 0 {
+			t.I32(6, converted)
+		}
+		t.End()
+	}
+
+	t.I64(3, pw.numRows)
+
+	t.List(4, thriftStruct, len(pw.rowGroups))
+	for _, rg := range pw.rowGroups {
+		t.StructElem()
+		t.List(1, thriftStruct, len(pw.columns))
+		for i, c := range pw.columns {
+			chunk := rg.chunks[i]
+			physical, _ := parquetType(c.Type)
+
+			t.StructElem()
+			t.I64(2, chunk.offset)
+			t.Struct(3)
+			t.I32(1, physical)
+			t.List(2, thriftI32, 1)
+			t.I32Elem(parquetPlain)
+			t.List(3, thriftBinary, 1)
+			t.StringElem(c.Name)
+			t.I32(4, 0) // UNCOMPRESSED
+			t.I64(5, rg.rows)
+			t.I64(6, chunk.size)
+			t.I64(7, chunk.size)
+			writeKeyValues(t, 8, columnMetadata(c))
+			t.I64(9, chunk.offset)
+			t.End()
+			t.End()
+		}
+		t.I64(2, rg.size)
+		t.I64(3, rg.rows)
+		t.End()
+	}
+
+	writeKeyValues(t, 5, pw.fileMetadata())
+	t.String(6, "irsdk-go")
+	t.End()
+
+	return t.Bytes()
+}
+
+func (pw *ParquetWriter) fileMetadata() map[string]string {
+	metadata := map[string]string{}
+	for k, v := range pw.Metadata {
+		metadata[k] = v
+	}
+
+	units := map[string]string{}
+	for _, c := range pw.columns {
+		if c.Unit != "" {
+			units[c.Name] = c.Unit
+		}
+	}
+
+	b, err := json.Marshal(units)
+	if err == nil {
+		metadata["units"] = string(b)
+	}
+
+	return metadata
+}
+
+func columnMetadata(c *Column) map[string]string {
+	metadata := map[string]string{}
+	if c.Unit != "" {
+		metadata["unit"] = c.Unit
+	}
+	if c.Desc != "" {
+		metadata["description"] = c.Desc
+	}
+
+	return metadata
+}
+
+// writeKeyValues writes a list of KeyValue structs, sorted by key. Nothing is
+// written for an empty map, the field is optional.
+func writeKeyValues(t *thriftWriter, id int16, kv map[string]string) {
+	if len(kv) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	t.List(id, thriftStruct, len(keys))
+	for _, k := range keys {
+		t.StructElem()
+		t.String(1, k)
+		t.String(2, kv[k])
+		t.End()
+	}
+}
+
+// parquetType returns the physical and converted type (-1 for none) of a
+// variable type
+func parquetType(varType utils.VarType) (int32, int32) {
+	switch varType {
+	case utils.BoolType:
+		return parquetBoolean, -1
+	case utils.CharType:
+		return parquetInt32, parquetUint8
+	case utils.IntType:
+		return parquetInt32, -1
+	case utils.BitfieldType:
+		return parquetInt32, parquetUint32
+	case utils.FloatType:
+		return parquetFloat, -1
+	}
+
+	return parquetDouble, -1
+}
+
+func (pw *ParquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset = pw.offset + int64(n)
+	return err
+}
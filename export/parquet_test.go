@@ -0,0 +1,198 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/leonb/irsdk-go/fakesim"
+)
+
+// thriftReader reads the Thrift compact protocol into maps of field id to
+// value, enough to check the Parquet metadata
+type thriftReader struct {
+	r *bytes.Reader
+}
+
+func (t *thriftReader) uvarint() uint64 {
+	v, _ := binary.ReadUvarint(t.r)
+	return v
+}
+
+func (t *thriftReader) zigzag() int64 {
+	v := t.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (t *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case 3:
+		b, _ := t.r.ReadByte()
+		return b
+	case 4, 5, 6:
+		return t.zigzag()
+	case 7:
+		var b [8]byte
+		t.r.Read(b[:])
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[:]))
+	case thriftBinary:
+		b := make([]byte, t.uvarint())
+		t.r.Read(b)
+		return string(b)
+	case thriftList, 10:
+		h, _ := t.r.ReadByte()
+		size := int(h >> 4)
+		if size == 15 {
+			size = int(t.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = t.value(h & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return t.readStruct()
+	}
+
+	return nil
+}
+
+func (t *thriftReader) readStruct() map[int16]interface{} {
+	s := map[int16]interface{}{}
+	id := int16(0)
+	for {
+		h, err := t.r.ReadByte()
+		if err != nil || h == 0 {
+			return s
+		}
+
+		if delta := int16(h >> 4); delta != 0 {
+			id = id + delta
+		} else {
+			id = int16(t.zigzag())
+		}
+		s[id] = t.value(h & 0x0f)
+	}
+}
+
+// readParquet returns the file metadata of a Parquet file
+func readParquet(t *testing.T, b []byte) map[int16]interface{} {
+	if len(b) < 12 || string(b[:4]) != "PAR1" || string(b[len(b)-4:]) != "PAR1" {
+		t.Fatal("not a Parquet file")
+	}
+
+	length := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	footer := b[len(b)-8-length : len(b)-8]
+	return (&thriftReader{r: bytes.NewReader(footer)}).readStruct()
+}
+
+// parquetColumn returns the data of the first page of column i of row group 0
+func parquetColumn(b []byte, metadata map[int16]interface{}, i int) []byte {
+	rowGroup := metadata[4].([]interface{})[0].(map[int16]interface{})
+	chunk := rowGroup[1].([]interface{})[i].(map[int16]interface{})
+
+	r := bytes.NewReader(b[chunk[2].(int64):])
+	page := (&thriftReader{r: r}).readStruct()
+	start := len(b) - r.Len()
+	return b[start : start+int(page[3].(int64))]
+}
+
+func keyValues(list interface{}) map[string]string {
+	kv := map[string]string{}
+	for _, item := range list.([]interface{}) {
+		item := item.(map[int16]interface{})
+		kv[item[1].(string)] = item[2].(string)
+	}
+
+	return kv
+}
+
+func TestParquetWriter(t *testing.T) {
+	tr := writeTestIbt(t, 10, fakesim.DefaultSessionInfo)
+	opts := Options{Vars: []string{"Speed", "OnPitRoad", "CarIdxLap[1]"}}
+	src, err := ReaderFrames(tr, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	w := NewParquetWriter(buf)
+	w.Metadata = map[string]string{"track": "limerock"}
+	_, err = Export(src, w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+	metadata := readParquet(t, b)
+	if rows := metadata[3].(int64); rows != 10 {
+		t.Errorf("num_rows: got %d, want 10", rows)
+	}
+
+	// The root and a required column per Column
+	schema := metadata[2].([]interface{})
+	names := []string{}
+	for _, element := range schema[1:] {
+		names = append(names, element.(map[int16]interface{})[4].(string))
+	}
+	if len(schema) != 5 || names[0] != "SessionTime" || names[1] != "Speed" || names[3] != "CarIdxLap_1" {
+		t.Errorf("schema: got %v", names)
+	}
+
+	kv := keyValues(metadata[5])
+	if kv["track"] != "limerock" {
+		t.Errorf("metadata: got %v", kv)
+	}
+	units := map[string]string{}
+	err = json.Unmarshal([]byte(kv["units"]), &units)
+	if err != nil || units["Speed"] != "m/s" || units["SessionTime"] != "s" {
+		t.Errorf("units: got %q", kv["units"])
+	}
+
+	speed := parquetColumn(b, metadata, 1)
+	if len(speed) != 40 {
+		t.Fatalf("Speed: got %d bytes, want 10 floats", len(speed))
+	}
+	for i := 0; i < 10; i++ {
+		if v := math.Float32frombits(binary.LittleEndian.Uint32(speed[i*4:])); v != float32(i) {
+			t.Errorf("Speed[%d]: got %v", i, v)
+		}
+	}
+
+	// Bit packed, OnPitRoad is set on odd records
+	onPitRoad := parquetColumn(b, metadata, 2)
+	if !bytes.Equal(onPitRoad, []byte{0xaa, 0x02}) {
+		t.Errorf("OnPitRoad: got %x", onPitRoad)
+	}
+
+	carIdxLap := parquetColumn(b, metadata, 3)
+	if v := int32(binary.LittleEndian.Uint32(carIdxLap)); v != 1 {
+		t.Errorf("CarIdxLap_1: got %d", v)
+	}
+}
+
+func TestParquetWriterWithoutRows(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewParquetWriter(buf)
+
+	err := w.Begin([]*Column{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := readParquet(t, buf.Bytes())
+	if rows := metadata[3].(int64); rows != 0 {
+		t.Errorf("num_rows: got %d", rows)
+	}
+	if groups := metadata[4].([]interface{}); len(groups) != 0 {
+		t.Errorf("row groups: got %v", groups)
+	}
+}
@@ -0,0 +1,127 @@
+package export
+
+import (
+	"context"
+	"io"
+	"time"
+
+	irsdk "github.com/leonb/irsdk-go"
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// FrameSource yields the frames to export. Next returns io.EOF after the last
+// frame.
+type FrameSource interface {
+	Next() (*irsdk.Frame, error)
+}
+
+type readerSource struct {
+	it *irsdk.FrameIterator
+}
+
+// ReaderFrames reads the frames of an .ibt file. It seeks to the start of the
+// range of opts, so exporting the end of a long file doesn't read it all.
+func ReaderFrames(tr *irsdk.TelemetryReader, opts Options) (FrameSource, error) {
+	it, err := tr.Frames()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opts.Laps != nil:
+		err = it.SeekLap(opts.Laps.First)
+	case opts.From > 0:
+		err = it.SeekTime(opts.From)
+		if err == irsdk.ErrTimeOutOfRange {
+			// Before the first record reads everything, after the last one
+			// reads nothing: the range check of Export handles both
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &readerSource{it: it}, nil
+}
+
+func (s *readerSource) Next() (*irsdk.Frame, error) {
+	if s.it.Next() {
+		return s.it.Frame(), nil
+	}
+
+	if err := s.it.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+type connectionSource struct {
+	ctx  context.Context
+	conn *irsdk.Connection
+	// synced is cleared when the connection is (re)connected: the first read
+	// after connecting to a live source reports utils.ErrDisconnected while it
+	// syncs to the tick count of the sim
+	synced bool
+}
+
+// ConnectionFrames reads the frames of a connection until ctx is done or the
+// source ends. Ticks without new data are waited out. When nothing can be read
+// (the sim isn't connected) the connection is polled again with backoff and
+// when the source fails it's reconnected with backoff, so a live export keeps
+// going until it's stopped.
+func ConnectionFrames(ctx context.Context, conn *irsdk.Connection) FrameSource {
+	return &connectionSource{
+		ctx:  ctx,
+		conn: conn,
+	}
+}
+
+func (s *connectionSource) Next() (*irsdk.Frame, error) {
+	backoff := irsdk.DEFAULT_MIN_BACKOFF
+	for {
+		select {
+		case <-s.ctx.Done():
+			return nil, io.EOF
+		default:
+		}
+
+		frame, err := s.conn.GetFrame()
+		reconnect := true
+		switch err {
+		case nil:
+			if frame != nil {
+				s.synced = true
+				return frame, nil
+			}
+			// Nothing to read without an error: poll again after the backoff
+			reconnect = false
+		case io.EOF:
+			return nil, io.EOF
+		case utils.ErrNothingChanged, utils.ErrDataChanged:
+			// No new tick yet or a torn read: try again
+			continue
+		case utils.ErrDisconnected:
+			if !s.synced {
+				s.synced = true
+				continue
+			}
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return nil, io.EOF
+		case <-time.After(backoff):
+		}
+
+		if reconnect {
+			s.conn.Connect()
+			s.synced = false
+		}
+		backoff = backoff * 2
+		if backoff > irsdk.DEFAULT_MAX_BACKOFF {
+			backoff = irsdk.DEFAULT_MAX_BACKOFF
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol types, the encoding of the Parquet metadata
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter writes the Thrift compact protocol. It only knows what the
+// Parquet metadata needs.
+type thriftWriter struct {
+	buf bytes.Buffer
+	// lastID is the id of the last field of the current struct, fields are
+	// written as a delta to it. stack holds it for the enclosing structs.
+	lastID int16
+	stack  []int16
+}
+
+func (t *thriftWriter) Bytes() []byte {
+	return t.buf.Bytes()
+}
+
+func (t *thriftWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf.Write(b[:n])
+}
+
+func (t *thriftWriter) zigzag(v int64) {
+	t.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	delta := id - t.lastID
+	if delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.zigzag(int64(id))
+	}
+	t.lastID = id
+}
+
+func (t *thriftWriter) I32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) I64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.zigzag(v)
+}
+
+func (t *thriftWriter) String(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.StringElem(s)
+}
+
+// Struct starts a struct field, end it with End
+func (t *thriftWriter) Struct(id int16) {
+	t.field(id, thriftStruct)
+	t.StructElem()
+}
+
+// StructElem starts a struct that's a list element or the top level struct
+func (t *thriftWriter) StructElem() {
+	t.stack = append(t.stack, t.lastID)
+	t.lastID = 0
+}
+
+// End ends the current struct
+func (t *thriftWriter) End() {
+	t.buf.WriteByte(0)
+	t.lastID = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// List starts a list field of n elements of type elemType. Write the elements
+// with the *Elem functions.
+func (t *thriftWriter) List(id int16, elemType byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elemType)
+		return
+	}
+
+	t.buf.WriteByte(0xf0 | elemType)
+	t.uvarint(uint64(n))
+}
+
+func (t *thriftWriter) I32Elem(v int32) {
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) StringElem(s string) {
+	t.uvarint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
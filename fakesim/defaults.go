@@ -0,0 +1,314 @@
+package fakesim
+
+import (
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+const (
+	// DEFAULT_LAPTIME is the lap time of the cars in DefaultVars
+	DEFAULT_LAPTIME = 90 * time.Second
+	// DEFAULT_NUMCARS is the number of cars in DefaultSessionInfo
+	DEFAULT_NUMCARS = 3
+)
+
+// DefaultVars returns a car lapping Lime Rock Park every DEFAULT_LAPTIME with
+// two other cars close behind
+func DefaultVars() []Var {
+	lapDistPct := Ramp(0, 1, DEFAULT_LAPTIME)
+	carIdxLapDistPct := func(t time.Duration, index int) float64 {
+		if index >= DEFAULT_NUMCARS {
+			return -1
+		}
+		return lapDistPct(t-time.Duration(index)*2*time.Second, index)
+	}
+
+	return []Var{
+		{Name: "SessionTime", Desc: "Seconds since session start", Unit: "s", Type: utils.DoubleType, Signal: Elapsed()},
+		{Name: "SessionNum", Desc: "Session number", Type: utils.IntType, Signal: Constant(0)},
+		{Name: "SessionState", Desc: "Session state", Unit: "irsdk_SessionState", Type: utils.IntType, Signal: Constant(float64(utils.StateRacing))},
+		{Name: "SessionFlags", Desc: "Session flags", Unit: "irsdk_Flags", Type: utils.BitfieldType, Signal: Constant(float64(utils.GreenFlag | utils.ServicibleFlag))},
+		{Name: "IsOnTrack", Desc: "1=Car on track physics running with player in car", Type: utils.BoolType, Signal: Constant(1)},
+		{Name: "OnPitRoad", Desc: "Is the player car on pit road between the cones", Type: utils.BoolType, Signal: Constant(0)},
+		{Name: "Speed", Desc: "GPS vehicle speed", Unit: "m/s", Type: utils.FloatType, Signal: Sine(20, 60, DEFAULT_LAPTIME/6)},
+		{Name: "RPM", Desc: "Engine rpm", Unit: "revs/min", Type: utils.FloatType, Signal: Sine(4000, 7500, DEFAULT_LAPTIME/12)},
+		{Name: "Gear", Desc: "-1=reverse  0=neutral  1..n=current gear", Type: utils.IntType, Signal: Script(
+			Point{0, 2}, Point{5 * time.Second, 3}, Point{10 * time.Second, 4}, Point{15 * time.Second, 3}, Point{DEFAULT_LAPTIME / 6, 2},
+		)},
+		{Name: "Throttle", Desc: "0=off throttle to 1=full throttle", Unit: "%", Type: utils.FloatType, Signal: Square(1, 0.2, DEFAULT_LAPTIME/6)},
+		{Name: "Brake", Desc: "0=brake released to 1=max pedal force", Unit: "%", Type: utils.FloatType, Signal: Square(0, 0.8, DEFAULT_LAPTIME/6)},
+		{Name: "Clutch", Desc: "0=disengaged to 1=fully engaged", Unit: "%", Type: utils.FloatType, Signal: Constant(1)},
+		{Name: "Lap", Desc: "Lap count", Type: utils.IntType, Signal: Counter(1, DEFAULT_LAPTIME)},
+		{Name: "LapDist", Desc: "Meters traveled from S/F this lap", Unit: "m", Type: utils.FloatType, Signal: Scale(lapDistPct, 2410)},
+		{Name: "LapDistPct", Desc: "Percentage distance around lap", Unit: "%", Type: utils.FloatType, Signal: lapDistPct},
+		{Name: "FuelLevel", Desc: "Liters of fuel remaining", Unit: "l", Type: utils.FloatType, Signal: Ramp(60, 0, 60*DEFAULT_LAPTIME)},
+		{Name: "EngineWarnings", Desc: "Bitfield for warning lights", Unit: "irsdk_EngineWarnings", Type: utils.BitfieldType, Signal: Constant(0)},
+		{Name: "CarIdxLap", Desc: "Lap count by car index", Type: utils.IntType, Count: 64, Signal: Stagger(Counter(1, DEFAULT_LAPTIME), -2*time.Second)},
+		{Name: "CarIdxLapDistPct", Desc: "Percentage distance around lap by car index", Unit: "%", Type: utils.FloatType, Count: 64, Signal: carIdxLapDistPct},
+		{Name: "CarIdxOnPitRoad", Desc: "On pit road between the cones by car index", Type: utils.BoolType, Count: 64, Signal: Constant(0)},
+		{Name: "CarIdxPosition", Desc: "Cars position in race by car index", Type: utils.IntType, Count: 64, Signal: func(t time.Duration, index int) float64 {
+			if index >= DEFAULT_NUMCARS {
+				return 0
+			}
+			return float64(index + 1)
+		}},
+	}
+}
+
+// DefaultSessionInfo describes a race with DEFAULT_NUMCARS cars at Lime Rock
+// Park
+const DefaultSessionInfo = `---
+WeekendInfo:
+ TrackName: limerock
+ TrackID: 6
+ TrackLength: 2.41 km
+ TrackDisplayName: Lime Rock Park
+ TrackDisplayShortName: Lime Rock
+ TrackConfigName: Full Course
+ TrackCity: Lakeville
+ TrackCountry: USA
+ TrackAltitude: 177.00 m
+ TrackLatitude: 41.928427 m
+ TrackLongitude: -73.381024 m
+ TrackNumTurns: 7
+ TrackPitSpeedLimit: 72.42 kph
+ TrackType: road course
+ TrackWeatherType: Constant
+ TrackSkies: Partly Cloudy
+ TrackSurfaceTemp: 33.45 C
+ TrackAirTemp: 25.55 C
+ TrackAirPressure: 29.09 Hg
+ TrackWindVel: 0.89 m/s
+ TrackWindDir: 0.00 rad
+ TrackRelativeHumidity: 55 %
+ TrackFogLevel: 0 %
+ SeriesID: 0
+ SeasonID: 0
+ SessionID: 0
+ SubSessionID: 0
+ LeagueID: 0
+ Official: 0
+ RaceWeek: 0
+ EventType: Race
+ Category: Road
+ SimMode: full
+ TeamRacing: 0
+ MinDrivers: 0
+ MaxDrivers: 0
+ DCRuleSet: None
+ QualifierMustStartRace: 0
+ NumCarClasses: 1
+ NumCarTypes: 1
+ WeekendOptions:
+  NumStarters: 3
+  StartingGrid: single file
+  QualifyScoring: best lap
+  CourseCautions: off
+  StandingStart: 0
+  Restarts: single file
+  WeatherType: Constant
+  Skies: Partly Cloudy
+  WindDirection: N
+  WindSpeed: 3.22 km/h
+  WeatherTemp: 25.56 C
+  RelativeHumidity: 55 %
+  FogLevel: 0 %
+  Unofficial: 1
+  CommercialMode: consumer
+  NightMode: 0
+  IsFixedSetup: 0
+  StrictLapsChecking: default
+  HasOpenRegistration: 0
+  HardcoreLevel: 1
+ TelemetryOptions:
+  TelemetryDiskFile: ""
+
+SessionInfo:
+ Sessions:
+ - SessionNum: 0
+   SessionLaps: unlimited
+   SessionTime: unlimited
+   SessionNumLapsToAvg: 0
+   SessionType: Race
+   ResultsPositions:
+   - Position: 1
+     ClassPosition: 0
+     CarIdx: 0
+     Lap: 1
+     Time: 90.0000
+     FastestLap: 1
+     FastestTime: 90.0000
+     LastTime: 90.0000
+     LapsLed: 1
+     LapsComplete: 1
+     LapsDriven: 1
+     Incidents: 0
+     ReasonOutId: 0
+     ReasonOutStr: Running
+   ResultsFastestLap:
+   - CarIdx: 0
+     FastestLap: 1
+     FastestTime: 90.0000
+   ResultsAverageLapTime: -1.0000
+   ResultsNumCautionFlags: 0
+   ResultsNumCautionLaps: 0
+   ResultsNumLeadChanges: 0
+   ResultsLapsComplete: -1
+   ResultsOfficial: 0
+
+CameraInfo:
+ Groups:
+ - GroupNum: 1
+   GroupName: Nose
+   Cameras:
+   - CameraNum: 1
+     CameraName: CamNose
+ - GroupNum: 2
+   GroupName: TV1
+   IsScenic: true
+   Cameras:
+   - CameraNum: 1
+     CameraName: CamTV1 01
+
+RadioInfo:
+ SelectedRadioNum: 0
+ Radios:
+ - RadioNum: 0
+   HopCount: 2
+   NumFrequencies: 1
+   TunedToFrequencyNum: 0
+   ScanningIsOn: 1
+   Frequencies:
+   - FrequencyNum: 0
+     FrequencyName: "@ALLTEAMS"
+     Priority: 12
+     CarIdx: -1
+     EntryIdx: -1
+     ClubID: 0
+     CanScan: 1
+     CanSquawk: 1
+     Muted: 0
+     IsMutable: 1
+     IsDeletable: 0
+
+DriverInfo:
+ DriverCarIdx: 0
+ DriverHeadPosX: -0.023
+ DriverHeadPosY: 0.323
+ DriverHeadPosZ: 0.551
+ DriverCarIdleRPM: 900.000
+ DriverCarRedLine: 7500.000
+ DriverCarFuelKgPerLtr: 0.750
+ DriverCarSLFirstRPM: 6000.000
+ DriverCarSLShiftRPM: 6850.000
+ DriverCarSLLastRPM: 6850.000
+ DriverCarSLBlinkRPM: 7000.000
+ DriverPitTrkPct: 0.943
+ Drivers:
+ - CarIdx: 0
+   UserName: Fake Driver
+   AbbrevName: Driver, F
+   Initials: FD
+   UserID: 1
+   TeamID: 0
+   TeamName: Fake Driver
+   CarNumber: "1"
+   CarNumberRaw: 1
+   CarPath: mx5 mx52016
+   CarClassID: 74
+   CarID: 67
+   CarScreenName: Global Mazda MX-5 Cup
+   CarScreenNameShort: MX-5 Cup
+   CarClassShortName: MX5 Cup
+   CarClassRelSpeed: 0
+   CarClassLicenseLevel: 0
+   CarClassMaxFuel: 1.000 %
+   CarClassWeightPenalty: 0.000 kg
+   CarClassColor: 0xffffff
+   IRating: 1350
+   LicLevel: 6
+   LicSubLevel: 249
+   LicString: D 2.49
+   LicColor: 0xfc8a27
+   IsSpectator: 0
+   CarDesignStr: 0,FFFFFF,ED2129,2A3795
+   HelmetDesignStr: 56,000000,000000,000000
+   SuitDesignStr: 0,000000,000000,000000
+   CarNumberDesignStr: 0,0,FFFFFF,777777,000000
+   CarSponsor_1: 0
+   CarSponsor_2: 0
+ - CarIdx: 1
+   UserName: Second Driver
+   AbbrevName: Driver, S
+   Initials: SD
+   UserID: 2
+   TeamID: 0
+   TeamName: Second Driver
+   CarNumber: "2"
+   CarNumberRaw: 2
+   CarPath: mx5 mx52016
+   CarClassID: 74
+   CarID: 67
+   CarScreenName: Global Mazda MX-5 Cup
+   CarScreenNameShort: MX-5 Cup
+   CarClassShortName: MX5 Cup
+   CarClassRelSpeed: 0
+   CarClassLicenseLevel: 0
+   CarClassMaxFuel: 1.000 %
+   CarClassWeightPenalty: 0.000 kg
+   CarClassColor: 0xffffff
+   IRating: 2150
+   LicLevel: 14
+   LicSubLevel: 312
+   LicString: B 3.12
+   LicColor: 0x00c702
+   IsSpectator: 0
+   CarDesignStr: 1,000000,FFD700,2A3795
+   HelmetDesignStr: 12,FFD700,000000,000000
+   SuitDesignStr: 3,000000,FFD700,000000
+   CarNumberDesignStr: 0,0,FFFFFF,777777,000000
+   CarSponsor_1: 0
+   CarSponsor_2: 0
+ - CarIdx: 2
+   UserName: Third Driver
+   AbbrevName: Driver, T
+   Initials: TD
+   UserID: 3
+   TeamID: 0
+   TeamName: Third Driver
+   CarNumber: "3"
+   CarNumberRaw: 3
+   CarPath: mx5 mx52016
+   CarClassID: 74
+   CarID: 67
+   CarScreenName: Global Mazda MX-5 Cup
+   CarScreenNameShort: MX-5 Cup
+   CarClassShortName: MX5 Cup
+   CarClassRelSpeed: 0
+   CarClassLicenseLevel: 0
+   CarClassMaxFuel: 1.000 %
+   CarClassWeightPenalty: 0.000 kg
+   CarClassColor: 0xffffff
+   IRating: 4020
+   LicLevel: 18
+   LicSubLevel: 450
+   LicString: A 4.50
+   LicColor: 0x0153db
+   IsSpectator: 0
+   CarDesignStr: 4,2A3795,FFFFFF,ED2129
+   HelmetDesignStr: 0,2A3795,FFFFFF,ED2129
+   SuitDesignStr: 0,2A3795,FFFFFF,ED2129
+   CarNumberDesignStr: 0,0,FFFFFF,777777,000000
+   CarSponsor_1: 0
+   CarSponsor_2: 0
+
+SplitTimeInfo:
+ Sectors:
+ - SectorNum: 0
+   SectorStartPct: 0.000000
+ - SectorNum: 1
+   SectorStartPct: 0.301440
+ - SectorNum: 2
+   SectorStartPct: 0.639600
+`
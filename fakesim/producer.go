@@ -0,0 +1,379 @@
+// Package fakesim writes a synthetic iRacing memory map into a file, so
+// everything that reads the shared memory can be tested without running the
+// sim. Use irsdk.NewMmapSource(path, nil) to read it.
+package fakesim
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	mmap "github.com/edsrzf/mmap-go"
+	"github.com/leonb/irsdk-go/utils"
+)
+
+const (
+	DEFAULT_TICKRATE         = 60
+	DEFAULT_NUMBUF           = 3
+	DEFAULT_SESSION_INFO_MAX = 256 * 1024
+)
+
+var (
+	ErrSessionInfoTooLarge = errors.New("Session info doesn't fit in the memory map")
+	ErrMemoryMapTooSmall   = errors.New("Variables don't fit in the memory map")
+	ErrTooManyBufs         = errors.New("More var buffers than utils.MAX_BUFS")
+	ErrClosed              = errors.New("Producer is closed")
+)
+
+// Var is a variable the producer publishes. Count > 1 makes it an array
+// variable (like the CarIdx* variables).
+type Var struct {
+	Name   string
+	Desc   string
+	Unit   string
+	Type   utils.VarType
+	Count  int
+	Signal Signal
+}
+
+type Options struct {
+	// TickRate is the number of var buffers written per second (default 60)
+	TickRate int
+	// NumBuf is the number of rotating var buffers (default 3)
+	NumBuf int
+	// Size is the size of the memory map (default utils.MEMMAPFILESIZE)
+	Size int
+	// SessionInfoMax is the space reserved for the session info string
+	SessionInfoMax int
+	// SessionInfo is the initial session info YAML (default
+	// DefaultSessionInfo)
+	SessionInfo []byte
+}
+
+// Producer writes a valid utils.Header, the VarHeader table, rotating var
+// buffers and the session info into a file backed memory map
+type Producer struct {
+	mu sync.Mutex
+
+	opts    Options
+	vars    []Var
+	offsets []int
+
+	file   *os.File
+	mem    mmap.MMap
+	header *utils.Header
+
+	tick    int32
+	elapsed time.Duration
+}
+
+// NewProducer creates (or truncates) path and writes the headers for vars into
+// it. When vars is empty DefaultVars() is used.
+func NewProducer(path string, vars []Var, opts Options) (*Producer, error) {
+	if len(vars) == 0 {
+		vars = DefaultVars()
+	}
+
+	if opts.TickRate <= 0 {
+		opts.TickRate = DEFAULT_TICKRATE
+	}
+	if opts.NumBuf <= 0 {
+		opts.NumBuf = DEFAULT_NUMBUF
+	}
+	if opts.NumBuf > utils.MAX_BUFS {
+		return nil, ErrTooManyBufs
+	}
+	if opts.Size <= 0 {
+		opts.Size = utils.MEMMAPFILESIZE
+	}
+	if opts.SessionInfoMax <= 0 {
+		opts.SessionInfoMax = DEFAULT_SESSION_INFO_MAX
+	}
+	if opts.SessionInfo == nil {
+		opts.SessionInfo = []byte(DefaultSessionInfo)
+	}
+
+	p := &Producer{
+		opts: opts,
+		vars: vars,
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	p.file = file
+
+	err = file.Truncate(int64(opts.Size))
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	p.mem, err = mmap.Map(file, mmap.RDWR, 0)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	err = p.writeHeaders()
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	err = p.SetSessionInfo(opts.SessionInfo)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// writeHeaders lays out the memory map: header, var headers, session info and
+// the var buffers
+func (p *Producer) writeHeaders() error {
+	headerSize := int(unsafe.Sizeof(utils.Header{}))
+	varHeaderSize := int(unsafe.Sizeof(utils.VarHeader{}))
+
+	p.header = (*utils.Header)(unsafe.Pointer(&p.mem[0]))
+	header := p.header
+	header.Ver = 2
+	header.Status = 0
+	header.TickRate = int32(p.opts.TickRate)
+
+	// Var headers directly after the header
+	varHeaderOffset := align16(headerSize)
+	header.NumVars = int32(len(p.vars))
+	header.VarHeaderOffset = int32(varHeaderOffset)
+
+	// Session info after the var headers
+	sessionInfoOffset := align16(varHeaderOffset + len(p.vars)*varHeaderSize)
+	header.SessionInfoOffset = int32(sessionInfoOffset)
+
+	// Var buffers after the session info
+	p.offsets = make([]int, len(p.vars))
+	bufLen := 0
+	for i, v := range p.vars {
+		count := v.Count
+		if count < 1 {
+			count = 1
+		}
+		size := int(utils.VarTypeBytes[v.Type])
+		bufLen = alignTo(bufLen, size)
+		p.offsets[i] = bufLen
+		bufLen = bufLen + size*count
+	}
+	bufLen = align16(bufLen)
+	header.BufLen = int32(bufLen)
+	header.NumBuf = int32(p.opts.NumBuf)
+
+	bufOffset := align16(sessionInfoOffset + p.opts.SessionInfoMax)
+	for i := 0; i < p.opts.NumBuf; i++ {
+		header.VarBuf[i].TickCount = 0
+		header.VarBuf[i].BufOffset = int32(bufOffset + i*bufLen)
+	}
+
+	if bufOffset+p.opts.NumBuf*bufLen > len(p.mem) {
+		return ErrMemoryMapTooSmall
+	}
+
+	for i, v := range p.vars {
+		start := varHeaderOffset + i*varHeaderSize
+		vh := (*utils.VarHeader)(unsafe.Pointer(&p.mem[start]))
+		vh.Type = v.Type
+		vh.Offset = int32(p.offsets[i])
+		vh.Count = int32(v.Count)
+		if vh.Count < 1 {
+			vh.Count = 1
+		}
+		copyCString(vh.Name[:], v.Name)
+		copyCString(vh.Desc[:], v.Desc)
+		copyCString(vh.Unit[:], v.Unit)
+	}
+
+	return nil
+}
+
+// SetSessionInfo replaces the session info YAML and increments
+// SessionInfoUpdate
+func (p *Producer) SetSessionInfo(yaml []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.header == nil {
+		return ErrClosed
+	}
+
+	// The sim terminates the string with "\n...\n" and a 0 byte
+	if len(yaml) > 0 && yaml[len(yaml)-1] != '\n' {
+		yaml = append(yaml, '\n')
+	}
+	yaml = append(yaml, []byte("...\n")...)
+
+	if len(yaml)+1 > p.opts.SessionInfoMax {
+		return ErrSessionInfoTooLarge
+	}
+
+	start := int(p.header.SessionInfoOffset)
+	region := p.mem[start : start+p.opts.SessionInfoMax]
+	n := copy(region, yaml)
+	for i := n; i < len(region); i++ {
+		region[i] = 0
+	}
+
+	p.header.SessionInfoLen = int32(n)
+	p.header.SessionInfoUpdate = p.header.SessionInfoUpdate + 1
+	return nil
+}
+
+// SetConnected sets or clears the StatusConnected bit
+func (p *Producer) SetConnected(connected bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.header == nil {
+		return
+	}
+
+	if connected {
+		p.header.Status = p.header.Status | utils.StatusConnected
+	} else {
+		p.header.Status = p.header.Status &^ utils.StatusConnected
+	}
+}
+
+// Tick writes the values of the next tick into the next var buffer
+func (p *Producer) Tick() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	header := p.header
+	if header == nil {
+		return ErrClosed
+	}
+	header.Status = header.Status | utils.StatusConnected
+
+	p.tick = p.tick + 1
+	p.elapsed = time.Duration(p.tick-1) * time.Second / time.Duration(p.opts.TickRate)
+
+	n := int(p.tick) % p.opts.NumBuf
+	start := int(header.VarBuf[n].BufOffset)
+	buf := p.mem[start : start+int(header.BufLen)]
+
+	for i, v := range p.vars {
+		count := v.Count
+		if count < 1 {
+			count = 1
+		}
+		size := int(utils.VarTypeBytes[v.Type])
+
+		for j := 0; j < count; j++ {
+			value := 0.0
+			if v.Signal != nil {
+				value = v.Signal(p.elapsed, j)
+			}
+			offset := p.offsets[i] + j*size
+			putValue(buf[offset:offset+size], v.Type, value)
+		}
+	}
+
+	// Only publish the tick count when the buffer is complete
+	header.VarBuf[n].TickCount = p.tick
+	return nil
+}
+
+// Run writes a tick at TickRate until stop is closed or the producer is
+// closed
+func (p *Producer) Run(stop <-chan struct{}) error {
+	ticker := time.NewTicker(time.Second / time.Duration(p.opts.TickRate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			err := p.Tick()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Elapsed returns the simulated time of the last tick
+func (p *Producer) Elapsed() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.elapsed
+}
+
+// Close marks the sim as disconnected and unmaps the file
+func (p *Producer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.mem != nil {
+		if p.header != nil {
+			p.header.Status = 0
+		}
+		p.mem.Flush()
+		p.mem.Unmap()
+	}
+
+	var err error
+	if p.file != nil {
+		err = p.file.Close()
+	}
+
+	p.mem = nil
+	p.header = nil
+	p.file = nil
+	return err
+}
+
+func putValue(b []byte, varType utils.VarType, value float64) {
+	switch varType {
+	case utils.CharType:
+		b[0] = byte(value)
+	case utils.BoolType:
+		if value != 0 {
+			b[0] = 1
+		} else {
+			b[0] = 0
+		}
+	case utils.IntType:
+		binary.LittleEndian.PutUint32(b, uint32(int32(value)))
+	case utils.BitfieldType:
+		binary.LittleEndian.PutUint32(b, uint32(int64(value)))
+	case utils.FloatType:
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(value)))
+	case utils.DoubleType:
+		binary.LittleEndian.PutUint64(b, math.Float64bits(value))
+	}
+}
+
+func copyCString(dst []byte, s string) {
+	n := copy(dst[:len(dst)-1], s)
+	for i := n; i < len(dst); i++ {
+		dst[i] = 0
+	}
+}
+
+func align16(n int) int {
+	return alignTo(n, 16)
+}
+
+func alignTo(n int, size int) int {
+	if size <= 1 {
+		return n
+	}
+	return (n + size - 1) / size * size
+}
@@ -0,0 +1,251 @@
+package fakesim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+var testVars = []Var{
+	{Name: "SessionTime", Unit: "s", Type: utils.DoubleType, Signal: Elapsed()},
+	{Name: "OnPitRoad", Type: utils.BoolType, Signal: Constant(1)},
+	{Name: "CarIdxLap", Type: utils.IntType, Count: 3, Signal: func(t time.Duration, index int) float64 {
+		return float64(index + 1)
+	}},
+}
+
+// memoryMap reads the memory map the way a second process sees it
+type memoryMap struct {
+	b      []byte
+	header utils.Header
+}
+
+func readMemoryMap(t *testing.T, path string) *memoryMap {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &memoryMap{b: b}
+	err = binary.Read(bytes.NewReader(b), binary.LittleEndian, &m.header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func (m *memoryMap) varHeader(t *testing.T, i int) *utils.VarHeader {
+	vh := &utils.VarHeader{}
+	start := int(m.header.VarHeaderOffset) + i*binary.Size(vh)
+	err := binary.Read(bytes.NewReader(m.b[start:]), binary.LittleEndian, vh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return vh
+}
+
+func (m *memoryMap) varBuf(n int) []byte {
+	start := int(m.header.VarBuf[n].BufOffset)
+	return m.b[start : start+int(m.header.BufLen)]
+}
+
+func newTestProducer(t *testing.T, opts Options) (*Producer, string) {
+	path := filepath.Join(t.TempDir(), "irsdk.mmap")
+	p, err := NewProducer(path, testVars, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+
+	return p, path
+}
+
+func TestProducerHeader(t *testing.T) {
+	_, path := newTestProducer(t, Options{TickRate: 10, NumBuf: 2})
+	m := readMemoryMap(t, path)
+	header := m.header
+
+	if header.Ver != 2 || header.Status != 0 || header.TickRate != 10 {
+		t.Errorf("got Ver %d, Status %d, TickRate %d", header.Ver, header.Status, header.TickRate)
+	}
+	if header.NumVars != 3 || header.VarHeaderOffset != 112 {
+		t.Errorf("got %d vars at %d", header.NumVars, header.VarHeaderOffset)
+	}
+	if header.NumBuf != 2 || header.BufLen != 32 {
+		t.Errorf("got %d bufs of %d bytes", header.NumBuf, header.BufLen)
+	}
+
+	// The var buffers follow each other after the session info
+	first := header.VarBuf[0].BufOffset
+	if first < header.SessionInfoOffset+DEFAULT_SESSION_INFO_MAX || first%16 != 0 {
+		t.Errorf("first var buffer at %d", first)
+	}
+	if header.VarBuf[1].BufOffset != first+header.BufLen {
+		t.Errorf("second var buffer at %d", header.VarBuf[1].BufOffset)
+	}
+
+	tests := []struct {
+		name   string
+		typ    utils.VarType
+		offset int32
+		count  int32
+	}{
+		{"SessionTime", utils.DoubleType, 0, 1},
+		{"OnPitRoad", utils.BoolType, 8, 1},
+		{"CarIdxLap", utils.IntType, 12, 3},
+	}
+	for i, test := range tests {
+		vh := m.varHeader(t, i)
+		if utils.CToGoString(vh.Name[:]) != test.name || vh.Type != test.typ || vh.Offset != test.offset || vh.Count != test.count {
+			t.Errorf("var %d: got %s, type %d at %d, count %d", i, utils.CToGoString(vh.Name[:]), vh.Type, vh.Offset, vh.Count)
+		}
+	}
+
+	start := int(header.SessionInfoOffset)
+	sessionInfo := string(m.b[start : start+int(header.SessionInfoLen)])
+	if header.SessionInfoUpdate != 1 || sessionInfo != DefaultSessionInfo+"...\n" {
+		t.Errorf("session info %d: got %q", header.SessionInfoUpdate, sessionInfo)
+	}
+}
+
+func TestProducerTick(t *testing.T) {
+	p, path := newTestProducer(t, Options{TickRate: 10, NumBuf: 3})
+
+	for tick := int32(1); tick <= 5; tick++ {
+		err := p.Tick()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := readMemoryMap(t, path)
+		if m.header.Status != utils.StatusConnected {
+			t.Errorf("tick %d: Status is %d", tick, m.header.Status)
+		}
+
+		// The buffers rotate and the newest one has the highest tick count
+		n := int(tick) % 3
+		if latest := m.header.GetLatestVarBufN(); latest != n || m.header.VarBuf[n].TickCount != tick {
+			t.Errorf("tick %d: latest buffer %d with tick count %d", tick, latest, m.header.VarBuf[n].TickCount)
+		}
+		if prev := m.header.VarBuf[(n+2)%3].TickCount; prev != tick-1 {
+			t.Errorf("tick %d: previous buffer has tick count %d", tick, prev)
+		}
+
+		elapsed := time.Duration(tick-1) * 100 * time.Millisecond
+		if p.Elapsed() != elapsed {
+			t.Errorf("tick %d: Elapsed is %v, want %v", tick, p.Elapsed(), elapsed)
+		}
+
+		buf := m.varBuf(n)
+		sessionTime := math.Float64frombits(binary.LittleEndian.Uint64(buf[0:]))
+		if sessionTime != elapsed.Seconds() || buf[8] != 1 {
+			t.Errorf("tick %d: got SessionTime %v, OnPitRoad %d", tick, sessionTime, buf[8])
+		}
+		for car := 0; car < 3; car++ {
+			if lap := binary.LittleEndian.Uint32(buf[12+car*4:]); lap != uint32(car+1) {
+				t.Errorf("tick %d: CarIdxLap[%d] is %d", tick, car, lap)
+			}
+		}
+	}
+}
+
+func TestProducerSessionInfo(t *testing.T) {
+	p, path := newTestProducer(t, Options{SessionInfoMax: 64, SessionInfo: []byte("---\n")})
+
+	err := p.SetSessionInfo([]byte("WeekendInfo:\n TrackName: limerock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := readMemoryMap(t, path)
+	start := int(m.header.SessionInfoOffset)
+	region := m.b[start : start+64]
+	if m.header.SessionInfoUpdate != 2 || !strings.HasPrefix(string(region), "WeekendInfo:\n TrackName: limerock\n...\n\x00") {
+		t.Errorf("session info %d: got %q", m.header.SessionInfoUpdate, region)
+	}
+
+	err = p.SetSessionInfo(bytes.Repeat([]byte("x"), 64))
+	if err != ErrSessionInfoTooLarge {
+		t.Errorf("got %v, want %v", err, ErrSessionInfoTooLarge)
+	}
+}
+
+func TestProducerConnected(t *testing.T) {
+	p, path := newTestProducer(t, Options{})
+
+	p.Tick()
+	p.SetConnected(false)
+	if status := readMemoryMap(t, path).header.Status; status != 0 {
+		t.Errorf("after SetConnected(false): Status is %d", status)
+	}
+
+	p.SetConnected(true)
+	p.Close()
+	if status := readMemoryMap(t, path).header.Status; status != 0 {
+		t.Errorf("after Close: Status is %d", status)
+	}
+
+	// A Run that is still ticking stops after Close
+	if err := p.Tick(); err != ErrClosed {
+		t.Errorf("Tick after Close: got %v, want %v", err, ErrClosed)
+	}
+	if err := p.Run(make(chan struct{})); err != ErrClosed {
+		t.Errorf("Run after Close: got %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestProducerOptions(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewProducer(filepath.Join(dir, "bufs.mmap"), nil, Options{NumBuf: utils.MAX_BUFS + 1})
+	if err != ErrTooManyBufs {
+		t.Errorf("NumBuf: got %v, want %v", err, ErrTooManyBufs)
+	}
+
+	_, err = NewProducer(filepath.Join(dir, "small.mmap"), nil, Options{Size: 4096})
+	if err != ErrMemoryMapTooSmall {
+		t.Errorf("Size: got %v, want %v", err, ErrMemoryMapTooSmall)
+	}
+}
+
+func TestSignals(t *testing.T) {
+	script := Script(Point{0, 0}, Point{time.Second, 10}, Point{2 * time.Second, 10})
+
+	tests := []struct {
+		name string
+		sig  Signal
+		t    time.Duration
+		want float64
+	}{
+		{"Constant", Constant(3), time.Hour, 3},
+		{"Ramp", Ramp(0, 100, 4*time.Second), 5 * time.Second, 25},
+		{"Square low", Square(1, 2, time.Second), 1200 * time.Millisecond, 1},
+		{"Square high", Square(1, 2, time.Second), 1700 * time.Millisecond, 2},
+		{"Counter", Counter(1, time.Minute), 150 * time.Second, 3},
+		{"Elapsed", Elapsed(), 1500 * time.Millisecond, 1.5},
+		{"Script", script, 500 * time.Millisecond, 5},
+		{"Script hold", script, 1500 * time.Millisecond, 10},
+		{"Script repeat", script, 2500 * time.Millisecond, 5},
+		{"Scale", Scale(Constant(2), 1.5), 0, 3},
+	}
+
+	for _, test := range tests {
+		if got := test.sig(test.t, 0); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	staggered := Stagger(Elapsed(), time.Second)
+	if got := staggered(time.Second, 2); got != 3 {
+		t.Errorf("Stagger: got %v, want 3", got)
+	}
+}
@@ -0,0 +1,109 @@
+package fakesim
+
+import (
+	"math"
+	"time"
+)
+
+// Signal generates the value of a variable at time t since the producer
+// started. For array variables index is the position in the array (the carIdx
+// for CarIdx* variables), for scalars it's always 0.
+type Signal func(t time.Duration, index int) float64
+
+// Point is a keyframe of a scripted signal
+type Point struct {
+	At    time.Duration
+	Value float64
+}
+
+// Constant always returns v
+func Constant(v float64) Signal {
+	return func(t time.Duration, index int) float64 {
+		return v
+	}
+}
+
+// Sine oscillates between min and max
+func Sine(min, max float64, period time.Duration) Signal {
+	return func(t time.Duration, index int) float64 {
+		phase := 2 * math.Pi * t.Seconds() / period.Seconds()
+		return min + (max-min)*(math.Sin(phase)+1)/2
+	}
+}
+
+// Ramp rises linearly from "from" to "to" in period and then starts over (a
+// sawtooth)
+func Ramp(from, to float64, period time.Duration) Signal {
+	return func(t time.Duration, index int) float64 {
+		pct := math.Mod(t.Seconds(), period.Seconds()) / period.Seconds()
+		return from + (to-from)*pct
+	}
+}
+
+// Square alternates between low and high every half period
+func Square(low, high float64, period time.Duration) Signal {
+	return func(t time.Duration, index int) float64 {
+		if math.Mod(t.Seconds(), period.Seconds()) < period.Seconds()/2 {
+			return low
+		}
+		return high
+	}
+}
+
+// Counter counts up by one every period, starting at start
+func Counter(start float64, period time.Duration) Signal {
+	return func(t time.Duration, index int) float64 {
+		return start + math.Floor(t.Seconds()/period.Seconds())
+	}
+}
+
+// Elapsed returns the time since the start in seconds (SessionTime)
+func Elapsed() Signal {
+	return func(t time.Duration, index int) float64 {
+		return t.Seconds()
+	}
+}
+
+// Script interpolates linearly between keyframes. After the last keyframe the
+// script starts over, so points should be sorted by At.
+func Script(points ...Point) Signal {
+	return func(t time.Duration, index int) float64 {
+		if len(points) == 0 {
+			return 0
+		}
+
+		last := points[len(points)-1]
+		if last.At > 0 {
+			t = t % last.At
+		}
+
+		prev := points[0]
+		for _, p := range points {
+			if p.At >= t {
+				if p.At == prev.At {
+					return p.Value
+				}
+				pct := float64(t-prev.At) / float64(p.At-prev.At)
+				return prev.Value + (p.Value-prev.Value)*pct
+			}
+			prev = p
+		}
+
+		return last.Value
+	}
+}
+
+// Scale multiplies a signal with factor
+func Scale(sig Signal, factor float64) Signal {
+	return func(t time.Duration, index int) float64 {
+		return sig(t, index) * factor
+	}
+}
+
+// Stagger shifts the time of every array element by index*offset, so every
+// car in a CarIdx* array gets its own value
+func Stagger(sig Signal, offset time.Duration) Signal {
+	return func(t time.Duration, index int) float64 {
+		return sig(t+time.Duration(index)*offset, index)
+	}
+}
@@ -0,0 +1,73 @@
+package irsdk
+
+import (
+	"time"
+)
+
+// IbtInfo summarizes an .ibt file
+type IbtInfo struct {
+	Track       string
+	TrackConfig string
+	Car         string
+	Driver      string
+
+	// StartDate is the wall clock time the recording started
+	StartDate time.Time
+	// StartTime and EndTime are the SessionTime of the first and last record
+	StartTime float64
+	EndTime   float64
+	Duration  time.Duration
+
+	Laps    int
+	Records int
+	// TickRate is the number of records per second
+	TickRate int
+	Vars     []*VarInfo
+}
+
+// Info reads the summary of the file from its headers and session info
+func (tr *TelemetryReader) Info() (*IbtInfo, error) {
+	header, err := tr.GetHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	subHeader, err := tr.GetSubHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := tr.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &IbtInfo{
+		StartDate: subHeader.StartDate(),
+		StartTime: subHeader.SessionStartTime,
+		EndTime:   subHeader.SessionEndTime,
+		Duration:  time.Duration((subHeader.SessionEndTime - subHeader.SessionStartTime) * float64(time.Second)),
+		Laps:      int(subHeader.SessionLapCount),
+		TickRate:  int(header.TickRate),
+		Vars:      schema.Vars(),
+	}
+
+	info.Records, err = tr.RecordCount()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := tr.GetSessionData()
+	if err != nil {
+		return nil, err
+	}
+
+	info.Track = session.WeekendInfo.TrackDisplayName
+	info.TrackConfig = session.WeekendInfo.TrackConfigName
+	if driver := session.PlayerDriver(); driver != nil {
+		info.Car = driver.CarScreenName
+		info.Driver = driver.UserName
+	}
+
+	return info, nil
+}
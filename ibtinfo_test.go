@@ -0,0 +1,171 @@
+package irsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/leonb/irsdk-go/fakesim"
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// testIbt describes an .ibt file with the variables SessionTime, Lap and
+// Speed (the index of the record) at 60 Hz
+type testIbt struct {
+	Records int
+	// Lap returns the lap of record i (i / 100 when nil)
+	Lap func(i int) int
+	// Gap is added to the SessionTime of the records from GapAt on
+	Gap   float64
+	GapAt int
+	// SubHeaderRecords is the record count of the sub header (Records when
+	// 0, -1 writes 0 like a crashed sim)
+	SubHeaderRecords int
+	// Trailing is the number of garbage bytes after the records
+	Trailing int
+}
+
+func (ti testIbt) sessionTime(i int) float64 {
+	t := float64(i) / 60
+	if ti.Gap > 0 && i >= ti.GapAt {
+		t = t + ti.Gap
+	}
+
+	return t
+}
+
+func (ti testIbt) lap(i int) int {
+	if ti.Lap == nil {
+		return i / 100
+	}
+
+	return ti.Lap(i)
+}
+
+func testVarHeader(name string, varType utils.VarType, offset int32) *utils.VarHeader {
+	vh := &utils.VarHeader{Type: varType, Offset: offset, Count: 1}
+	copy(vh.Name[:], name)
+	return vh
+}
+
+func testVarHeaders() []*utils.VarHeader {
+	return []*utils.VarHeader{
+		testVarHeader("SessionTime", utils.DoubleType, 0),
+		testVarHeader("Lap", utils.IntType, 8),
+		testVarHeader("Speed", utils.FloatType, 12),
+	}
+}
+
+func (ti testIbt) Bytes() []byte {
+	const bufLen = 16
+	varHeaders := testVarHeaders()
+	session := []byte(fakesim.DefaultSessionInfo)
+
+	header := utils.Header{Ver: 2, TickRate: 60, NumVars: int32(len(varHeaders)), NumBuf: 1, BufLen: bufLen}
+	header.VarHeaderOffset = int32(binary.Size(header) + binary.Size(utils.DiskSubHeader{}))
+	header.SessionInfoOffset = header.VarHeaderOffset + int32(binary.Size(utils.VarHeader{})*len(varHeaders))
+	header.SessionInfoLen = int32(len(session))
+	header.VarBuf[0].BufOffset = header.SessionInfoOffset + header.SessionInfoLen
+
+	records := ti.SubHeaderRecords
+	if records == 0 {
+		records = ti.Records
+	} else if records < 0 {
+		records = 0
+	}
+	subHeader := utils.DiskSubHeader{
+		SessionStartDate:   1700000000,
+		SessionStartTime:   ti.sessionTime(0),
+		SessionEndTime:     ti.sessionTime(ti.Records - 1),
+		SessionLapCount:    int32(ti.lap(ti.Records-1) - ti.lap(0) + 1),
+		SessionRecordCount: int32(records),
+	}
+
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, header)
+	binary.Write(buf, binary.LittleEndian, subHeader)
+	for _, vh := range varHeaders {
+		binary.Write(buf, binary.LittleEndian, vh)
+	}
+	buf.Write(session)
+
+	record := make([]byte, bufLen)
+	for i := 0; i < ti.Records; i++ {
+		binary.LittleEndian.PutUint64(record[0:], math.Float64bits(ti.sessionTime(i)))
+		binary.LittleEndian.PutUint32(record[8:], uint32(int32(ti.lap(i))))
+		binary.LittleEndian.PutUint32(record[12:], math.Float32bits(float32(i)))
+		buf.Write(record)
+	}
+	buf.Write(bytes.Repeat([]byte{0xff}, ti.Trailing))
+
+	return buf.Bytes()
+}
+
+func (ti testIbt) Reader() *TelemetryReader {
+	return NewTelemetryReader(bytes.NewReader(ti.Bytes()))
+}
+
+func TestReadSubHeader(t *testing.T) {
+	// irsdk_diskSubHeader as the sim writes it, after the 112 bytes of the
+	// header
+	b := make([]byte, 112+32)
+	binary.LittleEndian.PutUint64(b[112:], 1700000000)
+	binary.LittleEndian.PutUint64(b[120:], math.Float64bits(12.5))
+	binary.LittleEndian.PutUint64(b[128:], math.Float64bits(612.5))
+	binary.LittleEndian.PutUint32(b[136:], 7)
+	binary.LittleEndian.PutUint32(b[140:], 36000)
+
+	if size := binary.Size(utils.Header{}); size != 112 {
+		t.Fatalf("header is %d bytes, want 112", size)
+	}
+
+	subHeader, err := NewTelemetryReader(bytes.NewReader(b)).GetSubHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := utils.DiskSubHeader{
+		SessionStartDate:   1700000000,
+		SessionStartTime:   12.5,
+		SessionEndTime:     612.5,
+		SessionLapCount:    7,
+		SessionRecordCount: 36000,
+	}
+	if *subHeader != want {
+		t.Errorf("got %+v, want %+v", *subHeader, want)
+	}
+	if !subHeader.StartDate().Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("StartDate: got %v", subHeader.StartDate())
+	}
+}
+
+func TestIbtInfo(t *testing.T) {
+	info, err := testIbt{Records: 600}.Reader().Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Track != "Lime Rock Park" || info.TrackConfig != "Full Course" {
+		t.Errorf("track: got %q, %q", info.Track, info.TrackConfig)
+	}
+	if info.Car != "Global Mazda MX-5 Cup" || info.Driver != "Fake Driver" {
+		t.Errorf("car and driver: got %q, %q", info.Car, info.Driver)
+	}
+	if info.Records != 600 || info.Laps != 6 || info.TickRate != 60 {
+		t.Errorf("got %d records, %d laps, %d Hz", info.Records, info.Laps, info.TickRate)
+	}
+	if info.StartTime != 0 || info.EndTime != 599.0/60 {
+		t.Errorf("got StartTime %v, EndTime %v", info.StartTime, info.EndTime)
+	}
+	if d := info.Duration.Seconds(); math.Abs(d-599.0/60) > 1e-6 {
+		t.Errorf("Duration: got %v", info.Duration)
+	}
+	if !info.StartDate.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("StartDate: got %v", info.StartDate)
+	}
+	if len(info.Vars) != 3 || info.Vars[2].Name != "Speed" {
+		t.Errorf("Vars: got %v", info.Vars)
+	}
+}
@@ -0,0 +1,323 @@
+package irsdk
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+var (
+	ErrRecordOutOfRange = errors.New("Record out of range")
+	ErrTimeOutOfRange   = errors.New("Session time not in file")
+	ErrLapNotFound      = errors.New("Lap not in file")
+)
+
+// RecordCount returns the number of datapoints in the file. That's the count
+// of the sub header, or when it wasn't written (the sim crashed while
+// recording) the number of records that fit in the file.
+func (tr *TelemetryReader) RecordCount() (int, error) {
+	header, err := tr.GetHeader()
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := tr.data.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	start := int64(header.VarBuf[header.GetLatestVarBufN()].BufOffset)
+	if header.BufLen <= 0 || size <= start {
+		return 0, nil
+	}
+	count := int((size - start) / int64(header.BufLen))
+
+	subHeader, err := tr.GetSubHeader()
+	if err == nil && subHeader.SessionRecordCount > 0 && int(subHeader.SessionRecordCount) < count {
+		count = int(subHeader.SessionRecordCount)
+	}
+
+	return count, nil
+}
+
+// FrameIterator walks over the datapoints of an .ibt file without loading them
+// all:
+//
+//	it, err := tr.Frames()
+//	for it.Next() {
+//		speed, _ := it.Frame().Float("Speed")
+//	}
+//	err = it.Err()
+//
+// It reads through the TelemetryReader, so don't use the reader for anything
+// else while iterating.
+type FrameIterator struct {
+	tr     *TelemetryReader
+	schema *Schema
+	count  int
+	// next is the index of the record the next call to Next reads
+	next  int
+	frame *Frame
+	err   error
+
+	// laps maps every lap to the index of its first record. It's built by
+	// the first SeekLap, so iterating without seeking laps doesn't read the
+	// whole file up front.
+	laps map[int]int
+}
+
+// Frames returns an iterator positioned before the first datapoint
+func (tr *TelemetryReader) Frames() (*FrameIterator, error) {
+	schema, err := tr.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := tr.RecordCount()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FrameIterator{
+		tr:     tr,
+		schema: schema,
+		count:  count,
+	}, nil
+}
+
+// Next reads the next datapoint. It returns false after the last one or on an
+// error (see Err).
+func (it *FrameIterator) Next() bool {
+	if it.err != nil || it.next >= it.count {
+		it.frame = nil
+		return false
+	}
+
+	b, err := it.tr.ReadRawDataPointN(it.next)
+	if err != nil || b == nil {
+		it.err = err
+		it.frame = nil
+		return false
+	}
+
+	it.frame = it.schema.NewFrame(b)
+	it.next = it.next + 1
+	return true
+}
+
+// Frame returns the datapoint read by the last call to Next. Every frame has
+// its own buffer, so it can be kept.
+func (it *FrameIterator) Frame() *Frame {
+	return it.frame
+}
+
+// Index returns the index of the current datapoint
+func (it *FrameIterator) Index() int {
+	return it.next - 1
+}
+
+// Len returns the number of datapoints in the file
+func (it *FrameIterator) Len() int {
+	return it.count
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *FrameIterator) Err() error {
+	return it.err
+}
+
+// Seek positions the iterator so the next call to Next reads datapoint i
+func (it *FrameIterator) Seek(i int) error {
+	if i < 0 || i > it.count {
+		return ErrRecordOutOfRange
+	}
+
+	it.next = i
+	it.frame = nil
+	it.err = nil
+	return nil
+}
+
+// SeekTime positions the iterator at the first datapoint at or after
+// sessionTime (seconds, the SessionTime variable). Without gaps in the
+// recording the position is calculated from the tick rate; otherwise it's
+// searched.
+func (it *FrameIterator) SeekTime(sessionTime float64) error {
+	v := it.schema.Lookup("SessionTime")
+	if v == nil {
+		return ErrUnknownVar
+	}
+	if it.count == 0 {
+		return ErrTimeOutOfRange
+	}
+
+	first, err := it.readDouble(v, 0)
+	if err != nil {
+		return err
+	}
+	last, err := it.readDouble(v, it.count-1)
+	if err != nil {
+		return err
+	}
+	if sessionTime < first || sessionTime > last {
+		return ErrTimeOutOfRange
+	}
+
+	header, err := it.tr.GetHeader()
+	if err != nil {
+		return err
+	}
+
+	if header.TickRate > 0 {
+		guess := int(math.Ceil((sessionTime - first) * float64(header.TickRate)))
+		if guess >= 0 && guess < it.count {
+			ok, err := it.isFirstAtOrAfter(v, guess, sessionTime)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return it.Seek(guess)
+			}
+		}
+	}
+
+	i, err := it.search(func(i int) (bool, error) {
+		t, err := it.readDouble(v, i)
+		return t >= sessionTime, err
+	})
+	if err != nil {
+		return err
+	}
+
+	return it.Seek(i)
+}
+
+// isFirstAtOrAfter reports if record i is the first one at or after
+// sessionTime
+func (it *FrameIterator) isFirstAtOrAfter(v *VarInfo, i int, sessionTime float64) (bool, error) {
+	t, err := it.readDouble(v, i)
+	if err != nil || t < sessionTime {
+		return false, err
+	}
+	if i == 0 {
+		return true, nil
+	}
+
+	prev, err := it.readDouble(v, i-1)
+	return prev < sessionTime, err
+}
+
+// SeekLap positions the iterator at the first datapoint of lap (the Lap
+// variable). The first call is O(n): it reads the Lap of every datapoint in the
+// file to build a lap table. After that seeking to a lap is a lookup.
+func (it *FrameIterator) SeekLap(lap int) error {
+	if it.laps == nil {
+		err := it.readLaps()
+		if err != nil {
+			return err
+		}
+	}
+
+	i, ok := it.laps[lap]
+	if !ok {
+		return ErrLapNotFound
+	}
+
+	return it.Seek(i)
+}
+
+// readLaps builds the lap table. The lap counter doesn't only go up (it's
+// reset when a new session starts), so the first record of a lap wins.
+func (it *FrameIterator) readLaps() error {
+	v := it.schema.Lookup("Lap")
+	if v == nil {
+		return ErrUnknownVar
+	}
+
+	laps := map[int]int{}
+	for i := 0; i < it.count; i++ {
+		lap, err := it.readInt(v, i)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := laps[lap]; !ok {
+			laps[lap] = i
+		}
+	}
+
+	it.laps = laps
+	return nil
+}
+
+// search returns the first index for which f is true (like sort.Search)
+func (it *FrameIterator) search(f func(i int) (bool, error)) (int, error) {
+	lo, hi := 0, it.count
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		ok, err := f(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	return lo, nil
+}
+
+// readVar reads only the bytes of v of record i
+func (it *FrameIterator) readVar(v *VarInfo, i int) ([]byte, error) {
+	header, err := it.tr.GetHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	start := int64(header.VarBuf[header.GetLatestVarBufN()].BufOffset)
+	offset := start + int64(i)*int64(header.BufLen) + int64(v.Offset)
+
+	b := make([]byte, utils.VarTypeBytes[v.Type])
+	_, err = it.tr.data.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.ReadFull(it.tr.data, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (it *FrameIterator) readDouble(v *VarInfo, i int) (float64, error) {
+	if v.Type != utils.DoubleType {
+		return 0, ErrWrongVarType
+	}
+
+	b, err := it.readVar(v, i)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (it *FrameIterator) readInt(v *VarInfo, i int) (int, error) {
+	if v.Type != utils.IntType {
+		return 0, ErrWrongVarType
+	}
+
+	b, err := it.readVar(v, i)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(int32(binary.LittleEndian.Uint32(b))), nil
+}
@@ -0,0 +1,139 @@
+package irsdk
+
+import "testing"
+
+func TestRecordCount(t *testing.T) {
+	tests := []struct {
+		ibt  testIbt
+		want int
+	}{
+		{testIbt{Records: 100}, 100},
+		// Something after the records
+		{testIbt{Records: 100, Trailing: 1000}, 100},
+		// No record count written, the size counts
+		{testIbt{Records: 100, SubHeaderRecords: -1}, 100},
+		// Truncated file
+		{testIbt{Records: 100, SubHeaderRecords: 200}, 100},
+	}
+
+	for _, test := range tests {
+		count, err := test.ibt.Reader().RecordCount()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != test.want {
+			t.Errorf("%+v: got %d records, want %d", test.ibt, count, test.want)
+		}
+	}
+}
+
+func TestFrameIterator(t *testing.T) {
+	it, err := testIbt{Records: 250, Trailing: 100}.Reader().Frames()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for it.Next() {
+		speed, err := it.Frame().Float("Speed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int(speed) != n || it.Index() != n {
+			t.Fatalf("record %d: got Speed %v at index %d", n, speed, it.Index())
+		}
+		n = n + 1
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if n != 250 || it.Len() != 250 {
+		t.Errorf("read %d of %d records, want 250", n, it.Len())
+	}
+
+	err = it.Seek(251)
+	if err != ErrRecordOutOfRange {
+		t.Errorf("Seek(251): got %v, want %v", err, ErrRecordOutOfRange)
+	}
+}
+
+func TestSeekTime(t *testing.T) {
+	ti := testIbt{Records: 1000, Gap: 100, GapAt: 500}
+	it, err := ti.Reader().Frames()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		time float64
+		want int
+	}{
+		{0, 0},
+		{1, 60},
+		{1.001, 61},
+		// In the gap: the first record after it
+		{9, 500},
+		{ti.sessionTime(700), 700},
+		{ti.sessionTime(999), 999},
+	}
+
+	for _, test := range tests {
+		err := it.SeekTime(test.time)
+		if err != nil {
+			t.Fatalf("SeekTime(%v): %v", test.time, err)
+		}
+		it.Next()
+		if it.Index() != test.want {
+			t.Errorf("SeekTime(%v): at record %d, want %d", test.time, it.Index(), test.want)
+		}
+	}
+
+	err = it.SeekTime(-1)
+	if err != ErrTimeOutOfRange {
+		t.Errorf("SeekTime(-1): got %v, want %v", err, ErrTimeOutOfRange)
+	}
+	err = it.SeekTime(1000)
+	if err != ErrTimeOutOfRange {
+		t.Errorf("SeekTime(1000): got %v, want %v", err, ErrTimeOutOfRange)
+	}
+}
+
+func TestSeekLap(t *testing.T) {
+	// Laps 1-4, then a new session starts at lap 0
+	ti := testIbt{Records: 600, Lap: func(i int) int {
+		if i >= 400 {
+			return (i - 400) / 100
+		}
+		return i/100 + 1
+	}}
+	it, err := ti.Reader().Frames()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lap  int
+		want int
+	}{
+		{0, 400},
+		{1, 0},
+		{2, 100},
+		{4, 300},
+	}
+
+	for _, test := range tests {
+		err := it.SeekLap(test.lap)
+		if err != nil {
+			t.Fatalf("SeekLap(%d): %v", test.lap, err)
+		}
+		it.Next()
+		if it.Index() != test.want {
+			t.Errorf("SeekLap(%d): at record %d, want %d", test.lap, it.Index(), test.want)
+		}
+	}
+
+	err = it.SeekLap(5)
+	if err != ErrLapNotFound {
+		t.Errorf("SeekLap(5): got %v, want %v", err, ErrLapNotFound)
+	}
+}
@@ -0,0 +1,361 @@
+package irsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+var (
+	ErrWriterClosed        = errors.New("Writer is closed")
+	ErrNoVars              = errors.New("No variables to write")
+	ErrSessionInfoTooLarge = errors.New("Session info doesn't fit in the reserved space")
+)
+
+// IbtWriterOptions configures an IbtWriter. The zero value writes every
+// variable at 60 Hz.
+type IbtWriterOptions struct {
+	// Vars are the variables to write, in this order. Unknown names are
+	// skipped. Empty means every variable of the schema.
+	Vars []string
+	// TickRate is the number of records per second (60 when 0)
+	TickRate int
+	// StartDate is written to the sub header (now when zero)
+	StartDate time.Time
+	// SessionInfoReserve is the number of bytes kept for the session info, so
+	// it can be replaced with SetSessionInfo. It's never less than the
+	// initial session info.
+	SessionInfoReserve int
+}
+
+// IbtWriter writes an .ibt file like the sim does when disk logging is on:
+// the header, the disk sub header, the var headers, the session info and then
+// one record per frame.
+//
+// The variables are laid out again (aligned to their size, like the sim does),
+// so a filtered file is only as big as the variables it holds. Frames are
+// matched by variable name, so they can come from any source with a compatible
+// schema. Variables a frame doesn't have are written as zeroes.
+//
+// The header and sub header are only complete after Close.
+type IbtWriter struct {
+	w         io.WriteSeeker
+	schema    *Schema
+	header    utils.Header
+	subHeader utils.DiskSubHeader
+	// sources caches, per frame schema, the source variable of every output
+	// variable (nil when the frame doesn't have it)
+	sources map[*Schema][]*VarInfo
+	buf     []byte
+
+	sessionTime *VarInfo
+	lap         *VarInfo
+	firstLap    int
+	lastLap     int
+
+	closed bool
+}
+
+// NewIbtWriter writes the headers, the var headers and sessionInfo (the
+// session info YAML as the sim publishes it, not converted to UTF-8) to w and
+// returns a writer for the records
+func NewIbtWriter(w io.WriteSeeker, schema *Schema, sessionInfo []byte, opts IbtWriterOptions) (*IbtWriter, error) {
+	vars := schema.Vars()
+	if len(opts.Vars) > 0 {
+		vars = schema.Filter(opts.Vars).Vars()
+	}
+	if len(vars) == 0 {
+		return nil, ErrNoVars
+	}
+
+	varHeaders := make([]*utils.VarHeader, 0, len(vars))
+	offset := 0
+	for _, v := range vars {
+		offset = alignTo(offset, int(utils.VarTypeBytes[v.Type]))
+		vh := &utils.VarHeader{
+			Type:   v.Type,
+			Offset: int32(offset),
+			Count:  int32(v.Count),
+		}
+		copyCString(vh.Name[:], v.Name)
+		copyCString(vh.Desc[:], v.Desc)
+		copyCString(vh.Unit[:], v.Unit)
+
+		varHeaders = append(varHeaders, vh)
+		offset = offset + v.Size()
+	}
+	offset = alignTo(offset, 4)
+
+	outSchema, err := NewSchema(varHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	iw := &IbtWriter{
+		w:       w,
+		schema:  outSchema,
+		sources: make(map[*Schema][]*VarInfo),
+		buf:     make([]byte, offset),
+	}
+
+	iw.sessionTime = iw.schema.Lookup("SessionTime")
+	if iw.sessionTime != nil && iw.sessionTime.Type != utils.DoubleType {
+		iw.sessionTime = nil
+	}
+	iw.lap = iw.schema.Lookup("Lap")
+	if iw.lap != nil && iw.lap.Type != utils.IntType {
+		iw.lap = nil
+	}
+
+	tickRate := opts.TickRate
+	if tickRate <= 0 {
+		tickRate = 60
+	}
+
+	startDate := opts.StartDate
+	if startDate.IsZero() {
+		startDate = time.Now()
+	}
+
+	sessionInfo = terminateSessionInfo(sessionInfo)
+	reserve := opts.SessionInfoReserve
+	if reserve < len(sessionInfo) {
+		reserve = len(sessionInfo)
+	}
+
+	varHeaderOffset := binary.Size(iw.header) + binary.Size(iw.subHeader)
+	sessionInfoOffset := varHeaderOffset + len(varHeaders)*binary.Size(utils.VarHeader{})
+
+	iw.header = utils.Header{
+		Ver:               2,
+		Status:            utils.StatusConnected,
+		TickRate:          int32(tickRate),
+		SessionInfoUpdate: 0,
+		SessionInfoLen:    int32(reserve),
+		SessionInfoOffset: int32(sessionInfoOffset),
+		NumVars:           int32(len(varHeaders)),
+		VarHeaderOffset:   int32(varHeaderOffset),
+		NumBuf:            1,
+		BufLen:            int32(offset),
+	}
+	iw.header.VarBuf[0].BufOffset = int32(sessionInfoOffset + reserve)
+	iw.subHeader.SessionStartDate = startDate.Unix()
+
+	err = iw.writeHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vh := range varHeaders {
+		err = binary.Write(w, binary.LittleEndian, vh)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = iw.writeSessionInfo(sessionInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return iw, nil
+}
+
+// Schema returns the layout of the written records
+func (iw *IbtWriter) Schema() *Schema {
+	return iw.schema
+}
+
+// Records returns the number of records written so far
+func (iw *IbtWriter) Records() int {
+	return int(iw.subHeader.SessionRecordCount)
+}
+
+// WriteFrame appends f as a record
+func (iw *IbtWriter) WriteFrame(f *Frame) error {
+	if iw.closed {
+		return ErrWriterClosed
+	}
+
+	sources := iw.sourcesFor(f.Schema())
+	raw := f.Raw()
+	for i, v := range iw.schema.Vars() {
+		dst := iw.buf[v.Offset : v.Offset+v.Size()]
+		src := sources[i]
+		if src == nil || src.Offset+src.Size() > len(raw) {
+			zero(dst)
+			continue
+		}
+
+		n := copy(dst, raw[src.Offset:src.Offset+src.Size()])
+		zero(dst[n:])
+	}
+
+	_, err := iw.w.Write(iw.buf)
+	if err != nil {
+		return err
+	}
+
+	iw.track(f)
+	return nil
+}
+
+// SetSessionInfo replaces the session info, for when it's updated while
+// logging. It fails with ErrSessionInfoTooLarge when it's bigger than the
+// reserved space.
+func (iw *IbtWriter) SetSessionInfo(sessionInfo []byte) error {
+	if iw.closed {
+		return ErrWriterClosed
+	}
+
+	sessionInfo = terminateSessionInfo(sessionInfo)
+	if len(sessionInfo) > int(iw.header.SessionInfoLen) {
+		return ErrSessionInfoTooLarge
+	}
+
+	_, err := iw.w.Seek(int64(iw.header.SessionInfoOffset), io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	err = iw.writeSessionInfo(sessionInfo)
+	if err != nil {
+		return err
+	}
+
+	iw.header.SessionInfoUpdate = iw.header.SessionInfoUpdate + 1
+	_, err = iw.w.Seek(0, io.SeekEnd)
+	return err
+}
+
+// Close writes the final header and sub header (record count, lap count, start
+// and end time). It doesn't close the underlying writer.
+func (iw *IbtWriter) Close() error {
+	if iw.closed {
+		return nil
+	}
+	iw.closed = true
+
+	_, err := iw.w.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	err = iw.writeHeaders()
+	if err != nil {
+		return err
+	}
+
+	_, err = iw.w.Seek(0, io.SeekEnd)
+	return err
+}
+
+// sourcesFor maps the output variables to the variables of schema
+func (iw *IbtWriter) sourcesFor(schema *Schema) []*VarInfo {
+	if sources, ok := iw.sources[schema]; ok {
+		return sources
+	}
+
+	sources := make([]*VarInfo, iw.schema.Len())
+	for i, v := range iw.schema.Vars() {
+		src := schema.Lookup(v.Name)
+		if src == nil || src.Type != v.Type {
+			continue
+		}
+		sources[i] = src
+	}
+
+	iw.sources[schema] = sources
+	return sources
+}
+
+// track updates the header and sub header with the record just written
+func (iw *IbtWriter) track(f *Frame) {
+	first := iw.subHeader.SessionRecordCount == 0
+	iw.subHeader.SessionRecordCount = iw.subHeader.SessionRecordCount + 1
+
+	if f.TickCount > 0 {
+		iw.header.VarBuf[0].TickCount = f.TickCount
+	} else {
+		iw.header.VarBuf[0].TickCount = iw.subHeader.SessionRecordCount
+	}
+
+	written := iw.schema.NewFrame(iw.buf)
+	if iw.sessionTime != nil {
+		t, _ := written.Double(iw.sessionTime.Name)
+		if first {
+			iw.subHeader.SessionStartTime = t
+		}
+		iw.subHeader.SessionEndTime = t
+	}
+
+	if iw.lap != nil {
+		lap, _ := written.Int(iw.lap.Name)
+		if first || lap < iw.firstLap {
+			iw.firstLap = lap
+		}
+		if first || lap > iw.lastLap {
+			iw.lastLap = lap
+		}
+		iw.subHeader.SessionLapCount = int32(iw.lastLap - iw.firstLap + 1)
+	}
+}
+
+func (iw *IbtWriter) writeHeaders() error {
+	err := binary.Write(iw.w, binary.LittleEndian, &iw.header)
+	if err != nil {
+		return err
+	}
+
+	return binary.Write(iw.w, binary.LittleEndian, &iw.subHeader)
+}
+
+// writeSessionInfo writes sessionInfo padded with NUL bytes to the reserved
+// length
+func (iw *IbtWriter) writeSessionInfo(sessionInfo []byte) error {
+	b := make([]byte, iw.header.SessionInfoLen)
+	copy(b, sessionInfo)
+
+	_, err := iw.w.Write(b)
+	return err
+}
+
+// terminateSessionInfo makes sure the YAML document ends with "...", like the
+// sim writes it
+func terminateSessionInfo(b []byte) []byte {
+	b = bytes.TrimRight(b, "\x00\r\n ")
+
+	terminated := make([]byte, 0, len(b)+5)
+	terminated = append(terminated, b...)
+	if bytes.HasSuffix(b, []byte("\n...")) {
+		return append(terminated, '\n')
+	}
+
+	return append(terminated, "\n...\n"...)
+}
+
+// copyCString copies s into the fixed size buffer dst, always leaving a
+// terminating NUL byte
+func copyCString(dst []byte, s string) {
+	n := copy(dst[:len(dst)-1], s)
+	zero(dst[n:])
+}
+
+// alignTo rounds n up to a multiple of size
+func alignTo(n int, size int) int {
+	if size <= 1 {
+		return n
+	}
+
+	return (n + size - 1) / size * size
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,188 @@
+package irsdk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leonb/irsdk-go/fakesim"
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// createIbt returns a file to write an .ibt file to, and a function that
+// reopens it for reading
+func createIbt(t *testing.T) (*os.File, func() *TelemetryReader) {
+	path := filepath.Join(t.TempDir(), "test.ibt")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	return f, func() *TelemetryReader {
+		r, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.Close() })
+
+		return NewTelemetryReader(r)
+	}
+}
+
+func TestIbtWriterRoundTrip(t *testing.T) {
+	in := testIbt{Records: 300}.Reader()
+	schema, err := in.GetSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionInfo, err := in.ReadRawSessionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, reopen := createIbt(t)
+	w, err := NewIbtWriter(f, schema, sessionInfo, IbtWriterOptions{
+		Vars: []string{"Speed", "SessionTime", "Lap", "NoSuchVar"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Aligned to their size: the double after the float
+	vars := w.Schema().Vars()
+	if len(vars) != 3 || vars[0].Offset != 0 || vars[1].Offset != 8 || vars[2].Offset != 16 {
+		t.Errorf("layout: got %v at %d, %v at %d and %v at %d", vars[0].Name, vars[0].Offset, vars[1].Name, vars[1].Offset, vars[2].Name, vars[2].Offset)
+	}
+
+	it, err := in.Frames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for it.Next() {
+		err = w.WriteFrame(it.Frame())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Records() != 300 {
+		t.Errorf("Records: got %d, want 300", w.Records())
+	}
+
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.WriteFrame(it.Frame()); err != ErrWriterClosed {
+		t.Errorf("WriteFrame after Close: got %v, want %v", err, ErrWriterClosed)
+	}
+
+	out := reopen()
+	info, err := out.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Records != 300 || info.Laps != 3 || info.TickRate != 60 || info.Track != "Lime Rock Park" {
+		t.Errorf("Info: got %+v", info)
+	}
+	if info.StartTime != 0 || info.EndTime != 299.0/60 {
+		t.Errorf("Info: got start %v and end %v", info.StartTime, info.EndTime)
+	}
+
+	it, err = out.Frames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for it.Next() {
+		i := it.Index()
+		speed, _ := it.Frame().Float("Speed")
+		lap, _ := it.Frame().Int("Lap")
+		sessionTime, _ := it.Frame().Double("SessionTime")
+		if int(speed) != i || lap != i/100 || sessionTime != float64(i)/60 {
+			t.Fatalf("record %d: got Speed %v, Lap %v, SessionTime %v", i, speed, lap, sessionTime)
+		}
+	}
+	if it.Err() != nil || it.Len() != 300 {
+		t.Errorf("read %d records: %v", it.Len(), it.Err())
+	}
+}
+
+func TestIbtWriterMissingVars(t *testing.T) {
+	schema, err := NewSchema(testVarHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewSchema([]*utils.VarHeader{testVarHeader("Speed", utils.FloatType, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, reopen := createIbt(t)
+	w, err := NewIbtWriter(f, schema, []byte(fakesim.DefaultSessionInfo), IbtWriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A frame of another source with only Speed
+	data := []byte{0, 0, 0x28, 0x42}
+	err = w.WriteFrame(other.NewFrame(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	frame, err := reopen().ReadFrameN(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	speed, _ := frame.Float("Speed")
+	lap, _ := frame.Int("Lap")
+	if speed != 42 || lap != 0 {
+		t.Errorf("got Speed %v and Lap %v, want 42 and 0", speed, lap)
+	}
+
+	_, err = NewIbtWriter(f, schema, nil, IbtWriterOptions{Vars: []string{"NoSuchVar"}})
+	if err != ErrNoVars {
+		t.Errorf("no vars: got %v, want %v", err, ErrNoVars)
+	}
+}
+
+func TestIbtWriterSetSessionInfo(t *testing.T) {
+	schema, err := NewSchema(testVarHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, reopen := createIbt(t)
+	w, err := NewIbtWriter(f, schema, []byte(fakesim.DefaultSessionInfo), IbtWriterOptions{
+		SessionInfoReserve: len(fakesim.DefaultSessionInfo) + 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := strings.Replace(fakesim.DefaultSessionInfo, "Lime Rock Park", "Lime Rock", 1)
+	err = w.SetSessionInfo([]byte(updated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.SetSessionInfo([]byte(fakesim.DefaultSessionInfo + strings.Repeat("#", 200)))
+	if err != ErrSessionInfoTooLarge {
+		t.Errorf("got %v, want %v", err, ErrSessionInfoTooLarge)
+	}
+
+	err = w.WriteFrame(schema.NewFrame(make([]byte, 16)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	session, err := reopen().GetSessionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.WeekendInfo.TrackDisplayName != "Lime Rock" {
+		t.Errorf("TrackDisplayName: got %q", session.WeekendInfo.TrackDisplayName)
+	}
+}
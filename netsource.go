@@ -0,0 +1,397 @@
+package irsdk
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+const (
+	NETSOURCE_SERVICE = "Source"
+)
+
+// Errors that are transported as strings by net/rpc and have to be mapped back
+// to the original error values
+var remoteErrors = []error{
+	utils.ErrInitialize,
+	utils.ErrDataChanged,
+	utils.ErrDisconnected,
+	utils.ErrNothingChanged,
+	ErrEmptySessionData,
+	io.EOF,
+}
+
+type WaitForTickArgs struct {
+	LastSeq uint64
+	TimeOut time.Duration
+}
+
+type WaitForTickReply struct {
+	Seq  uint64
+	Data []byte
+}
+
+// SourceCommands exposes a Source over net/rpc. Ticks are read once by the
+// server and broadcasted to every client. Only the pump reads from src, the
+// handlers return the snapshot it took after the last read.
+type SourceCommands struct {
+	src Source
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	seq     uint64
+	data    []byte
+	lastErr error
+
+	header         *utils.Header
+	varHeaders     []utils.VarHeader
+	sessionInfo    []byte
+	sessionInfoErr error
+	connected      bool
+}
+
+func (sc *SourceCommands) Ping(args *string, ret *bool) error {
+	*ret = true
+	return nil
+}
+
+func (sc *SourceCommands) Header(args *string, header *utils.Header) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.header == nil {
+		return utils.ErrInitialize
+	}
+
+	*header = *sc.header
+	return nil
+}
+
+func (sc *SourceCommands) VarHeaders(args *string, varHeaders *[]utils.VarHeader) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	*varHeaders = sc.varHeaders
+	return nil
+}
+
+func (sc *SourceCommands) SessionInfo(args *string, b *[]byte) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	*b = sc.sessionInfo
+	return sc.sessionInfoErr
+}
+
+func (sc *SourceCommands) IsConnected(args *string, ret *bool) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	*ret = sc.connected
+	return nil
+}
+
+func (sc *SourceCommands) WaitForTick(args *WaitForTickArgs, reply *WaitForTickReply) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	// Wake up the waiting goroutine when the timeout has passed
+	timedOut := false
+	if args.TimeOut > 0 {
+		timer := time.AfterFunc(args.TimeOut, func() {
+			sc.mu.Lock()
+			timedOut = true
+			sc.mu.Unlock()
+			sc.cond.Broadcast()
+		})
+		defer timer.Stop()
+	}
+
+	for sc.seq <= args.LastSeq && sc.lastErr == nil && args.TimeOut > 0 && !timedOut {
+		sc.cond.Wait()
+	}
+
+	if sc.seq <= args.LastSeq {
+		if sc.lastErr != nil {
+			return sc.lastErr
+		}
+		return utils.ErrNothingChanged
+	}
+
+	reply.Seq = sc.seq
+	reply.Data = sc.data
+	return nil
+}
+
+// snapshot reads the header and connection state of the source for the
+// handlers. The var headers and session info are only read again after the
+// source was (re)opened or when SessionInfoUpdate changed.
+func (sc *SourceCommands) snapshot(opened bool) {
+	var header *utils.Header
+	h, err := sc.src.Header()
+	if err == nil && h != nil {
+		copied := *h
+		header = &copied
+	}
+	connected := sc.src.IsConnected()
+
+	// Only the pump writes the snapshot, so it reads it without the lock
+	varHeaders := sc.varHeaders
+	sessionInfo := sc.sessionInfo
+	sessionInfoErr := sc.sessionInfoErr
+	if opened || sc.header == nil || header == nil || header.SessionInfoUpdate != sc.header.SessionInfoUpdate {
+		varHeaders = nil
+		vhs, err := sc.src.VarHeaders()
+		if err == nil {
+			varHeaders = make([]utils.VarHeader, len(vhs))
+			for i, vh := range vhs {
+				varHeaders[i] = *vh
+			}
+		}
+
+		sessionInfo, sessionInfoErr = sc.src.SessionInfo()
+		sessionInfo = append([]byte(nil), sessionInfo...)
+	}
+
+	sc.mu.Lock()
+	sc.header = header
+	sc.connected = connected
+	sc.varHeaders = varHeaders
+	sc.sessionInfo = sessionInfo
+	sc.sessionInfoErr = sessionInfoErr
+	sc.mu.Unlock()
+}
+
+// pump reads ticks from the source and wakes up every waiting client until
+// done is closed. After an error it backs off for timeOut and when the source
+// was disconnected (the sim restarted) it's opened again.
+func (sc *SourceCommands) pump(timeOut time.Duration, done <-chan struct{}) {
+	// The first read after opening a live source reports
+	// utils.ErrDisconnected while it syncs to the tick count of the sim
+	synced := false
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		data, err := sc.src.WaitForTick(timeOut)
+		sc.snapshot(false)
+		if err == utils.ErrNothingChanged || err == utils.ErrDataChanged || (err == nil && data == nil) {
+			continue
+		}
+		if err == utils.ErrDisconnected && !synced {
+			synced = true
+			continue
+		}
+
+		sc.mu.Lock()
+		if err != nil {
+			sc.lastErr = err
+		} else {
+			sc.seq = sc.seq + 1
+			sc.data = data
+			sc.lastErr = nil
+			synced = true
+		}
+		sc.mu.Unlock()
+		sc.cond.Broadcast()
+
+		if err == nil {
+			continue
+		}
+		if err == io.EOF {
+			return
+		}
+
+		select {
+		case <-done:
+			return
+		case <-time.After(timeOut):
+		}
+
+		if err == utils.ErrDisconnected {
+			sc.src.Close()
+			sc.src.Open()
+			sc.snapshot(true)
+			synced = false
+		}
+	}
+}
+
+// ServeSource opens src and serves it to network sources connecting to l until
+// l is closed
+func ServeSource(l net.Listener, src Source) error {
+	err := src.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	sc := &SourceCommands{src: src}
+	sc.cond = sync.NewCond(&sc.mu)
+	sc.snapshot(true)
+
+	// Stop reading before the source is closed
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		sc.pump(time.Second, done)
+		close(stopped)
+	}()
+	defer func() {
+		close(done)
+		<-stopped
+	}()
+
+	s := rpc.NewServer()
+	err = s.RegisterName(NETSOURCE_SERVICE, sc)
+	if err != nil {
+		return err
+	}
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// netSource reads telemetry from a server started with ServeSource
+type netSource struct {
+	addr    string
+	client  *rpc.Client
+	lastSeq uint64
+}
+
+// NewNetSource creates a Source that reads from the ServeSource server
+// listening on addr
+func NewNetSource(addr string) Source {
+	return &netSource{addr: addr}
+}
+
+func (s *netSource) Open() error {
+	client, err := rpc.Dial("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	s.client = client
+	s.lastSeq = 0
+	return nil
+}
+
+func (s *netSource) Close() error {
+	if s.client == nil {
+		return nil
+	}
+
+	err := s.client.Close()
+	s.client = nil
+	return err
+}
+
+func (s *netSource) call(method string, args interface{}, reply interface{}) error {
+	if s.client == nil {
+		return utils.ErrInitialize
+	}
+
+	err := s.client.Call(NETSOURCE_SERVICE+"."+method, args, reply)
+	if err == rpc.ErrShutdown {
+		s.client = nil
+		return utils.ErrDisconnected
+	}
+
+	return remoteError(err)
+}
+
+func (s *netSource) IsConnected() bool {
+	ret := false
+	args := ""
+	err := s.call("IsConnected", &args, &ret)
+	if err != nil {
+		return false
+	}
+
+	return ret
+}
+
+func (s *netSource) Header() (*utils.Header, error) {
+	header := &utils.Header{}
+	args := ""
+	err := s.call("Header", &args, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+func (s *netSource) VarHeaders() ([]*utils.VarHeader, error) {
+	vhs := []utils.VarHeader{}
+	args := ""
+	err := s.call("VarHeaders", &args, &vhs)
+	if err != nil {
+		return nil, err
+	}
+
+	varHeaders := make([]*utils.VarHeader, len(vhs))
+	for i := range vhs {
+		varHeaders[i] = &vhs[i]
+	}
+
+	return varHeaders, nil
+}
+
+func (s *netSource) SessionInfo() ([]byte, error) {
+	b := []byte{}
+	args := ""
+	err := s.call("SessionInfo", &args, &b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (s *netSource) NextVarBuf() ([]byte, error) {
+	return s.WaitForTick(0)
+}
+
+func (s *netSource) WaitForTick(timeOut time.Duration) ([]byte, error) {
+	args := &WaitForTickArgs{LastSeq: s.lastSeq, TimeOut: timeOut}
+	reply := &WaitForTickReply{}
+	err := s.call("WaitForTick", args, reply)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lastSeq = reply.Seq
+	return reply.Data, nil
+}
+
+// remoteError maps errors returned by the rpc server back to the known error
+// values
+func remoteError(err error) error {
+	serverErr, ok := err.(rpc.ServerError)
+	if !ok {
+		return err
+	}
+
+	for _, e := range remoteErrors {
+		if string(serverErr) == e.Error() {
+			return e
+		}
+	}
+
+	return errors.New(string(serverErr))
+}
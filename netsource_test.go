@@ -0,0 +1,194 @@
+package irsdk
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// funcSource is a Source whose WaitForTick returns whatever tick returns for
+// the nth call
+type funcSource struct {
+	tick func(n int) ([]byte, error)
+
+	mu    sync.Mutex
+	waits int
+	opens int
+}
+
+func (s *funcSource) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.opens = s.opens + 1
+	return nil
+}
+
+func (s *funcSource) Close() error                            { return nil }
+func (s *funcSource) IsConnected() bool                       { return true }
+func (s *funcSource) Header() (*utils.Header, error)          { return &utils.Header{}, nil }
+func (s *funcSource) VarHeaders() ([]*utils.VarHeader, error) { return nil, nil }
+func (s *funcSource) SessionInfo() ([]byte, error)            { return nil, nil }
+func (s *funcSource) NextVarBuf() ([]byte, error)             { return s.WaitForTick(0) }
+
+func (s *funcSource) WaitForTick(timeOut time.Duration) ([]byte, error) {
+	s.mu.Lock()
+	n := s.waits
+	s.waits = s.waits + 1
+	s.mu.Unlock()
+
+	return s.tick(n)
+}
+
+func (s *funcSource) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.waits, s.opens
+}
+
+// runPump runs the pump of src for d
+func runPump(src Source, d time.Duration) *SourceCommands {
+	sc := &SourceCommands{src: src}
+	sc.cond = sync.NewCond(&sc.mu)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		sc.pump(10*time.Millisecond, done)
+		close(stopped)
+	}()
+
+	time.Sleep(d)
+	close(done)
+	<-stopped
+	return sc
+}
+
+func TestPumpBacksOffAfterErrors(t *testing.T) {
+	broken := errors.New("broken")
+	src := &funcSource{tick: func(n int) ([]byte, error) {
+		return nil, broken
+	}}
+
+	sc := runPump(src, 100*time.Millisecond)
+
+	waits, opens := src.counts()
+	if waits > 15 {
+		t.Errorf("source read %d times in 100ms with a back off of 10ms", waits)
+	}
+	if opens != 0 {
+		t.Errorf("source opened %d times, it wasn't disconnected", opens)
+	}
+	if sc.lastErr != broken {
+		t.Errorf("lastErr: got %v, want %v", sc.lastErr, broken)
+	}
+}
+
+func TestPumpReopensAfterDisconnect(t *testing.T) {
+	src := &funcSource{tick: func(n int) ([]byte, error) {
+		switch {
+		case n == 0:
+			// Syncing to the tick count after opening
+			return nil, utils.ErrDisconnected
+		case n < 5:
+			return []byte{byte(n)}, nil
+		}
+		return nil, utils.ErrDisconnected
+	}}
+
+	sc := runPump(src, 100*time.Millisecond)
+
+	waits, opens := src.counts()
+	if sc.seq != 4 {
+		t.Errorf("got %d ticks, want 4", sc.seq)
+	}
+	if opens < 2 || waits > 30 {
+		t.Errorf("source opened %d times and read %d times in 100ms", opens, waits)
+	}
+}
+
+func TestNetSource(t *testing.T) {
+	p, path := newFakeSim(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go ServeSource(l, NewMmapSource(path, nil))
+
+	conn := NewConnectionFromSource(NewNetSource(l.Addr().String()))
+	err = conn.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Disconnect()
+
+	data, err := conn.GetSessionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.WeekendInfo.TrackDisplayName != "Lime Rock Park" {
+		t.Errorf("TrackDisplayName: got %q", data.WeekendInfo.TrackDisplayName)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		p.Tick()
+		td, err := conn.GetTelemetryData()
+		if err != nil || td == nil {
+			continue
+		}
+
+		if td.SessionTime != p.Elapsed().Seconds() || td.Lap != 1 {
+			t.Errorf("got SessionTime %v and Lap %v, want %v and 1", td.SessionTime, td.Lap, p.Elapsed().Seconds())
+		}
+		return
+	}
+
+	t.Fatal("no telemetry from the server")
+}
+
+func TestNetSourceWhilePumping(t *testing.T) {
+	// The pump and the handlers share the reader of the .ibt file, which is
+	// big enough to keep the pump reading during the calls
+	ibt := testIbt{Records: 200000}.Bytes()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go ServeSource(l, NewDiskSource(bytes.NewReader(ibt)))
+
+	src := NewNetSource(l.Addr().String())
+	err = src.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	for i := 0; i < 50; i++ {
+		header, err := src.Header()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.NumVars != 3 || header.TickRate != 60 {
+			t.Fatalf("call %d: got header %+v", i, header)
+		}
+
+		sessionInfo, err := src.SessionInfo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(sessionInfo, []byte("---\nWeekendInfo:")) {
+			t.Fatalf("call %d: got session info %.20q", i, sessionInfo)
+		}
+	}
+}
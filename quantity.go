@@ -0,0 +1,247 @@
+package irsdk
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidQuantity   = errors.New("Invalid quantity")
+	ErrUnknownUnit       = errors.New("Unknown unit")
+	ErrIncompatibleUnits = errors.New("Incompatible units")
+)
+
+// Quantity is a value with a unit as written in the session info ("25.55 C",
+// "2.41 km", "29.09 Hg")
+type Quantity struct {
+	Value float64
+	Unit  string
+	// Raw is the value of the session info when it isn't a quantity, Value
+	// and Unit are unset then
+	Raw string
+}
+
+type dimension int
+
+const (
+	dimensionless dimension = iota
+	length
+	speed
+	temperature
+	pressure
+	angle
+	mass
+	volume
+)
+
+type system int
+
+const (
+	// neutral units (rad, %) are used in every system
+	neutral system = iota
+	metric
+	imperial
+)
+
+// unitDef converts a unit to the SI unit of its dimension: si = value*factor +
+// offset
+type unitDef struct {
+	dimension dimension
+	system    system
+	factor    float64
+	offset    float64
+}
+
+var units = map[string]unitDef{
+	"%":    {dimensionless, neutral, 1, 0},
+	"m":    {length, metric, 1, 0},
+	"km":   {length, metric, 1000, 0},
+	"ft":   {length, imperial, 0.3048, 0},
+	"mi":   {length, imperial, 1609.344, 0},
+	"m/s":  {speed, metric, 1, 0},
+	"km/h": {speed, metric, 1 / 3.6, 0},
+	"kph":  {speed, metric, 1 / 3.6, 0},
+	"mph":  {speed, imperial, 0.44704, 0},
+	"K":    {temperature, metric, 1, 0},
+	"C":    {temperature, metric, 1, 273.15},
+	"F":    {temperature, imperial, 5.0 / 9.0, 273.15 - 32*5.0/9.0},
+	"Pa":   {pressure, metric, 1, 0},
+	"kPa":  {pressure, metric, 1000, 0},
+	"mbar": {pressure, metric, 100, 0},
+	"bar":  {pressure, metric, 100000, 0},
+	"psi":  {pressure, imperial, 6894.757293168, 0},
+	"Hg":   {pressure, imperial, 3386.389, 0},
+	"rad":  {angle, neutral, 1, 0},
+	"deg":  {angle, neutral, 0.017453292519943295, 0},
+	"kg":   {mass, metric, 1, 0},
+	"lb":   {mass, imperial, 0.45359237, 0},
+	"m3":   {volume, metric, 1, 0},
+	"L":    {volume, metric, 0.001, 0},
+	"l":    {volume, metric, 0.001, 0},
+	"gal":  {volume, imperial, 0.003785411784, 0},
+}
+
+// SI, metric and imperial unit per dimension
+var (
+	siUnits = map[dimension]string{
+		dimensionless: "%",
+		length:        "m",
+		speed:         "m/s",
+		temperature:   "K",
+		pressure:      "Pa",
+		angle:         "rad",
+		mass:          "kg",
+		volume:        "m3",
+	}
+	metricUnits = map[dimension]string{
+		length:      "km",
+		speed:       "km/h",
+		temperature: "C",
+		pressure:    "kPa",
+		mass:        "kg",
+		volume:      "L",
+	}
+	imperialUnits = map[dimension]string{
+		length:      "mi",
+		speed:       "mph",
+		temperature: "F",
+		pressure:    "psi",
+		mass:        "lb",
+		volume:      "gal",
+	}
+)
+
+// ParseQuantity parses "<value> <unit>". The unit is optional.
+func ParseQuantity(s string) (Quantity, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return Quantity{}, ErrInvalidQuantity
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Quantity{}, ErrInvalidQuantity
+	}
+
+	q := Quantity{Value: value}
+	if len(fields) == 2 {
+		q.Unit = fields[1]
+	}
+
+	return q, nil
+}
+
+func (q *Quantity) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	err := unmarshal(&s)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseQuantity(s)
+	if err != nil {
+		*q = Quantity{Raw: s}
+		return nil
+	}
+
+	*q = parsed
+	return nil
+}
+
+func (q Quantity) String() string {
+	if q.Raw != "" {
+		return q.Raw
+	}
+
+	value := strconv.FormatFloat(q.Value, 'f', -1, 64)
+	if q.Unit == "" {
+		return value
+	}
+
+	return value + " " + q.Unit
+}
+
+// Convert returns the quantity in unit
+func (q Quantity) Convert(unit string) (Quantity, error) {
+	if q.Raw != "" {
+		return q, ErrInvalidQuantity
+	}
+	if unit == q.Unit {
+		return q, nil
+	}
+
+	from, ok := units[q.Unit]
+	if !ok {
+		return q, ErrUnknownUnit
+	}
+	to, ok := units[unit]
+	if !ok {
+		return q, ErrUnknownUnit
+	}
+	if from.dimension != to.dimension {
+		return q, ErrIncompatibleUnits
+	}
+
+	si := q.Value*from.factor + from.offset
+	return Quantity{
+		Value: (si - to.offset) / to.factor,
+		Unit:  unit,
+	}, nil
+}
+
+// SI returns the quantity in the SI unit of its dimension (m, m/s, K, Pa, rad,
+// kg, m3). Unknown units are returned as is.
+func (q Quantity) SI() Quantity {
+	return q.convertTo(siUnits, neutral)
+}
+
+// Metric returns the quantity in km, km/h, C, kPa, kg or L. Units that are
+// already metric (or neutral, like rad and %) are kept.
+func (q Quantity) Metric() Quantity {
+	return q.convertTo(metricUnits, metric)
+}
+
+// Imperial returns the quantity in mi, mph, F, psi, lb or gal. Units that are
+// already imperial (Hg) or neutral are kept.
+func (q Quantity) Imperial() Quantity {
+	return q.convertTo(imperialUnits, imperial)
+}
+
+func (q Quantity) convertTo(targets map[dimension]string, sys system) Quantity {
+	def, ok := units[q.Unit]
+	if !ok {
+		return q
+	}
+
+	if sys != neutral && (def.system == sys || def.system == neutral) {
+		return q
+	}
+
+	target, ok := targets[def.dimension]
+	if !ok {
+		return q
+	}
+
+	converted, err := q.Convert(target)
+	if err != nil {
+		return q
+	}
+
+	return converted
+}
+
+// Coordinate is a latitude or longitude in degrees. The sim writes them with a
+// bogus unit ("41.928427 m"), so the unit is dropped.
+type Coordinate float64
+
+func (c *Coordinate) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var q Quantity
+	err := unmarshal(&q)
+	if err != nil {
+		return err
+	}
+
+	*c = Coordinate(q.Value)
+	return nil
+}
@@ -0,0 +1,97 @@
+package irsdk
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		s     string
+		value float64
+		unit  string
+	}{
+		{"25.55 C", 25.55, "C"},
+		{"2.41 km", 2.41, "km"},
+		{"-0.5", -0.5, ""},
+	}
+
+	for _, test := range tests {
+		q, err := ParseQuantity(test.s)
+		if err != nil {
+			t.Fatalf("ParseQuantity(%q): %v", test.s, err)
+		}
+		if q.Value != test.value || q.Unit != test.unit {
+			t.Errorf("ParseQuantity(%q) = %+v", test.s, q)
+		}
+		if q.String() != test.s {
+			t.Errorf("Quantity.String: got %q, want %q", q.String(), test.s)
+		}
+	}
+
+	for _, s := range []string{"", "fast", "1 2 3"} {
+		_, err := ParseQuantity(s)
+		if err != ErrInvalidQuantity {
+			t.Errorf("ParseQuantity(%q): got %v, want %v", s, err, ErrInvalidQuantity)
+		}
+	}
+}
+
+func TestQuantityConvert(t *testing.T) {
+	tests := []struct {
+		q    Quantity
+		unit string
+		want float64
+	}{
+		{Quantity{Value: 25, Unit: "C"}, "F", 77},
+		{Quantity{Value: 100, Unit: "km/h"}, "mph", 62.137},
+		{Quantity{Value: 1, Unit: "mi"}, "km", 1.609},
+	}
+
+	for _, test := range tests {
+		got, err := test.q.Convert(test.unit)
+		if err != nil {
+			t.Fatalf("%v.Convert(%q): %v", test.q, test.unit, err)
+		}
+		if math.Abs(got.Value-test.want) > 0.001 || got.Unit != test.unit {
+			t.Errorf("%v.Convert(%q) = %v, want %v", test.q, test.unit, got, test.want)
+		}
+	}
+
+	_, err := Quantity{Value: 1, Unit: "km"}.Convert("C")
+	if err != ErrIncompatibleUnits {
+		t.Errorf("km to C: got %v, want %v", err, ErrIncompatibleUnits)
+	}
+	_, err = Quantity{Value: 1, Unit: "km"}.Convert("parsec")
+	if err != ErrUnknownUnit {
+		t.Errorf("km to parsec: got %v, want %v", err, ErrUnknownUnit)
+	}
+}
+
+func TestUnparseableQuantity(t *testing.T) {
+	yaml := `---
+WeekendInfo:
+ TrackLength: 2.41 km
+ TrackAirTemp: unknown
+...
+`
+	data, err := NewSessionDataFromBytes([]byte(yaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	temp := data.WeekendInfo.TrackAirTemp
+	if temp.Value != 0 || temp.Unit != "" || temp.Raw != "unknown" {
+		t.Errorf("TrackAirTemp: got %+v", temp)
+	}
+	if temp.String() != "unknown" {
+		t.Errorf("TrackAirTemp.String: got %q", temp.String())
+	}
+	_, err = temp.Convert("F")
+	if err != ErrInvalidQuantity {
+		t.Errorf("Convert: got %v, want %v", err, ErrInvalidQuantity)
+	}
+	if data.WeekendInfo.TrackLength.Value != 2.41 {
+		t.Errorf("TrackLength: got %+v", data.WeekendInfo.TrackLength)
+	}
+}
@@ -0,0 +1,575 @@
+package irsdk
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// A recording starts with RECORDING_MAGIC followed by flate compressed
+// streams of records, a new stream after every reconnect. Every record starts with a kind byte:
+//
+//	'H' header:      utils.Header (little endian)
+//	'V' var headers: uvarint count, count * utils.VarHeader (little endian)
+//	'S' session:     varint SessionInfoUpdate, uvarint length, YAML
+//	'F' frame:       varint tick count, uvarint nanoseconds since the previous
+//	                 frame, uvarint length, var buffer XOR-ed with the previous
+//	                 var buffer (when it has the same length)
+//
+// Header and var headers are written again when the layout changes, sessions
+// whenever SessionInfoUpdate changes.
+const (
+	RECORDING_MAGIC = "IRREC\x00\x01"
+
+	recordHeader     = 'H'
+	recordVarHeaders = 'V'
+	recordSession    = 'S'
+	recordFrame      = 'F'
+)
+
+var (
+	ErrNoRecording   = errors.New("Not an irsdk recording")
+	ErrUnknownRecord = errors.New("Unknown record in recording")
+)
+
+// Recorder is a Source that writes everything read from the wrapped Source to
+// a recording
+type Recorder struct {
+	src Source
+	w   io.Writer
+
+	mu            sync.Mutex
+	fw            *flate.Writer
+	started       bool
+	header        utils.Header
+	sessionInfo   int32
+	lastFrame     []byte
+	lastFrameAt   time.Time
+	scratch       []byte
+	layoutWritten bool
+}
+
+// NewRecorder creates a Source that records everything read from src to w
+func NewRecorder(src Source, w io.Writer) *Recorder {
+	return &Recorder{src: src, w: w}
+}
+
+func (r *Recorder) Open() error {
+	err := r.src.Open()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fw != nil {
+		return nil
+	}
+
+	if !r.started {
+		_, err = r.w.Write([]byte(RECORDING_MAGIC))
+		if err != nil {
+			return err
+		}
+		r.started = true
+	}
+
+	// Every connection gets its own flate stream, Close ended the previous one
+	r.fw, err = flate.NewWriter(r.w, flate.DefaultCompression)
+	if err != nil {
+		return err
+	}
+
+	// Write the layout and session info again, the source may have changed
+	// while disconnected
+	r.layoutWritten = false
+	r.sessionInfo = -1
+	return nil
+}
+
+// Close flushes the recording and closes the wrapped source. It doesn't close
+// the writer.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.fw != nil {
+		r.fw.Close()
+		r.fw = nil
+	}
+	r.mu.Unlock()
+
+	return r.src.Close()
+}
+
+// Flush writes everything recorded so far to the writer
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fw == nil {
+		return nil
+	}
+
+	return r.fw.Flush()
+}
+
+func (r *Recorder) IsConnected() bool {
+	return r.src.IsConnected()
+}
+
+func (r *Recorder) Header() (*utils.Header, error) {
+	return r.src.Header()
+}
+
+func (r *Recorder) VarHeaders() ([]*utils.VarHeader, error) {
+	return r.src.VarHeaders()
+}
+
+func (r *Recorder) SessionInfo() ([]byte, error) {
+	return r.src.SessionInfo()
+}
+
+func (r *Recorder) NextVarBuf() ([]byte, error) {
+	data, err := r.src.NextVarBuf()
+	return data, r.record(data, err)
+}
+
+func (r *Recorder) WaitForTick(timeOut time.Duration) ([]byte, error) {
+	data, err := r.src.WaitForTick(timeOut)
+	return data, r.record(data, err)
+}
+
+// record writes the layout, session info and frame of a var buffer returned by
+// the source
+func (r *Recorder) record(data []byte, err error) error {
+	if err != nil || data == nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fw == nil {
+		// Not recording (closed), the frame is still fine
+		return nil
+	}
+
+	header, err := r.src.Header()
+	if err != nil {
+		return err
+	}
+
+	if header == nil {
+		return nil
+	}
+
+	// Layout changed: write the header and var headers again
+	if !r.layoutWritten || layoutChanged(&r.header, header) {
+		err = r.writeLayout(header)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Session info changed
+	if header.SessionInfoUpdate != r.sessionInfo {
+		err = r.writeSession(header.SessionInfoUpdate)
+		if err != nil {
+			return err
+		}
+	}
+
+	tickCount := header.VarBuf[header.GetLatestVarBufN()].TickCount
+	if tc, ok := r.src.(tickCounter); ok {
+		tickCount = tc.LastTickCount()
+	}
+
+	return r.writeFrame(tickCount, data)
+}
+
+func (r *Recorder) writeLayout(header *utils.Header) error {
+	varHeaders, err := r.src.VarHeaders()
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteByte(recordHeader)
+	binary.Write(buf, binary.LittleEndian, header)
+
+	buf.WriteByte(recordVarHeaders)
+	buf.Write(appendUvarint(nil, uint64(len(varHeaders))))
+	for _, vh := range varHeaders {
+		binary.Write(buf, binary.LittleEndian, vh)
+	}
+
+	_, err = r.fw.Write(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	r.header = *header
+	r.layoutWritten = true
+	r.lastFrame = nil
+	return nil
+}
+
+func (r *Recorder) writeSession(update int32) error {
+	b, err := r.src.SessionInfo()
+	if err != nil {
+		return err
+	}
+
+	record := []byte{recordSession}
+	record = appendVarint(record, int64(update))
+	record = appendUvarint(record, uint64(len(b)))
+	record = append(record, b...)
+
+	_, err = r.fw.Write(record)
+	if err != nil {
+		return err
+	}
+
+	r.sessionInfo = update
+	return nil
+}
+
+func (r *Recorder) writeFrame(tickCount int32, data []byte) error {
+	now := time.Now()
+	elapsed := time.Duration(0)
+	if !r.lastFrameAt.IsZero() {
+		elapsed = now.Sub(r.lastFrameAt)
+	}
+
+	record := r.scratch[:0]
+	record = append(record, recordFrame)
+	record = appendVarint(record, int64(tickCount))
+	record = appendUvarint(record, uint64(elapsed))
+	record = appendUvarint(record, uint64(len(data)))
+
+	start := len(record)
+	record = append(record, data...)
+	if len(r.lastFrame) == len(data) {
+		xorBytes(record[start:], r.lastFrame)
+	}
+
+	_, err := r.fw.Write(record)
+	if err != nil {
+		return err
+	}
+
+	r.scratch = record
+	r.lastFrame = append(r.lastFrame[:0], data...)
+	r.lastFrameAt = now
+	return nil
+}
+
+// recordingSource plays back a recording made with a Recorder
+type recordingSource struct {
+	r     io.Reader
+	speed float64
+
+	br     *bufio.Reader
+	header utils.Header
+	layout bool
+
+	varHeaders  []*utils.VarHeader
+	sessionInfo []byte
+	lastFrame   []byte
+	tickCount   int32
+	eof         bool
+
+	// pending frame: read from the recording but not yet due
+	pending     []byte
+	pendingTick int32
+	pendingAt   time.Duration
+
+	elapsed time.Duration
+	started time.Time
+}
+
+// NewRecordingSource creates a Source that plays back a recording made with a
+// Recorder. speed 1 plays it in real time, 2 twice as fast, etc. Speed 0 plays
+// it back as fast as possible. A recording can't be rewound, so opening the
+// source again (after a reconnect) continues the playback. The caller closes r.
+func NewRecordingSource(r io.Reader, speed float64) Source {
+	return &recordingSource{r: r, speed: speed}
+}
+
+func (s *recordingSource) Open() error {
+	if s.br != nil {
+		return nil
+	}
+
+	magic := make([]byte, len(RECORDING_MAGIC))
+	_, err := io.ReadFull(s.r, magic)
+	if err != nil {
+		return err
+	}
+
+	if string(magic) != RECORDING_MAGIC {
+		return ErrNoRecording
+	}
+
+	s.br = bufio.NewReader(&flateStreams{r: bufio.NewReader(s.r)})
+
+	// Read up to the first frame so the header and session info are available
+	// right away
+	return s.readPending()
+}
+
+func (s *recordingSource) Close() error {
+	// r is kept open so the playback goes on when the source is opened again
+	return nil
+}
+
+func (s *recordingSource) IsConnected() bool {
+	return s.br != nil && !s.eof
+}
+
+func (s *recordingSource) Header() (*utils.Header, error) {
+	if !s.layout {
+		return nil, nil
+	}
+
+	header := s.header
+	header.NumBuf = 1
+	header.VarBuf[0].TickCount = s.tickCount
+	for i := 1; i < len(header.VarBuf); i++ {
+		header.VarBuf[i].TickCount = 0
+	}
+
+	return &header, nil
+}
+
+func (s *recordingSource) VarHeaders() ([]*utils.VarHeader, error) {
+	return s.varHeaders, nil
+}
+
+func (s *recordingSource) SessionInfo() ([]byte, error) {
+	return s.sessionInfo, nil
+}
+
+func (s *recordingSource) LastTickCount() int32 {
+	return s.tickCount
+}
+
+func (s *recordingSource) NextVarBuf() ([]byte, error) {
+	return s.WaitForTick(0)
+}
+
+func (s *recordingSource) WaitForTick(timeOut time.Duration) ([]byte, error) {
+	if s.br == nil {
+		return nil, utils.ErrInitialize
+	}
+
+	if s.pending == nil {
+		err := s.readPending()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.started.IsZero() {
+		s.started = time.Now()
+	}
+
+	// Wait till the frame is due
+	if s.speed > 0 {
+		due := s.started.Add(time.Duration(float64(s.pendingAt) / s.speed))
+		wait := due.Sub(time.Now())
+		if wait > timeOut {
+			time.Sleep(timeOut)
+			return nil, utils.ErrNothingChanged
+		}
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+
+	data := s.pending
+	s.tickCount = s.pendingTick
+	s.pending = nil
+	return data, nil
+}
+
+// readPending reads records until the next frame
+func (s *recordingSource) readPending() error {
+	if s.eof {
+		return io.EOF
+	}
+
+	for {
+		kind, err := s.br.ReadByte()
+		if err == io.EOF {
+			s.eof = true
+			return io.EOF
+		}
+		if err != nil {
+			return err
+		}
+
+		switch kind {
+		case recordHeader:
+			err = binary.Read(s.br, binary.LittleEndian, &s.header)
+			s.layout = true
+			s.lastFrame = nil
+		case recordVarHeaders:
+			err = s.readVarHeaders()
+		case recordSession:
+			err = s.readSession()
+		case recordFrame:
+			return s.readFrame()
+		default:
+			return ErrUnknownRecord
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *recordingSource) readVarHeaders() error {
+	count, err := binary.ReadUvarint(s.br)
+	if err != nil {
+		return err
+	}
+
+	varHeaders := make([]utils.VarHeader, count)
+	err = binary.Read(s.br, binary.LittleEndian, varHeaders)
+	if err != nil {
+		return err
+	}
+
+	s.varHeaders = make([]*utils.VarHeader, count)
+	for i := range varHeaders {
+		s.varHeaders[i] = &varHeaders[i]
+	}
+
+	return nil
+}
+
+func (s *recordingSource) readSession() error {
+	update, err := binary.ReadVarint(s.br)
+	if err != nil {
+		return err
+	}
+
+	length, err := binary.ReadUvarint(s.br)
+	if err != nil {
+		return err
+	}
+
+	b := make([]byte, length)
+	_, err = io.ReadFull(s.br, b)
+	if err != nil {
+		return err
+	}
+
+	s.header.SessionInfoUpdate = int32(update)
+	s.sessionInfo = b
+	return nil
+}
+
+func (s *recordingSource) readFrame() error {
+	tickCount, err := binary.ReadVarint(s.br)
+	if err != nil {
+		return err
+	}
+
+	elapsed, err := binary.ReadUvarint(s.br)
+	if err != nil {
+		return err
+	}
+
+	length, err := binary.ReadUvarint(s.br)
+	if err != nil {
+		return err
+	}
+
+	data := make([]byte, length)
+	_, err = io.ReadFull(s.br, data)
+	if err != nil {
+		return err
+	}
+
+	// Keep a copy of the frame: the returned data belongs to the caller
+	if len(s.lastFrame) == len(data) {
+		xorBytes(data, s.lastFrame)
+		copy(s.lastFrame, data)
+	} else {
+		s.lastFrame = append([]byte(nil), data...)
+	}
+
+	s.elapsed = s.elapsed + time.Duration(elapsed)
+	s.pending = data
+	s.pendingTick = int32(tickCount)
+	s.pendingAt = s.elapsed
+	return nil
+}
+
+// layoutChanged compares the parts of the headers that describe the layout of
+// the var buffers
+func layoutChanged(a, b *utils.Header) bool {
+	return a.NumVars != b.NumVars ||
+		a.VarHeaderOffset != b.VarHeaderOffset ||
+		a.BufLen != b.BufLen ||
+		a.TickRate != b.TickRate
+}
+
+func xorBytes(dst []byte, src []byte) {
+	for i := range dst {
+		dst[i] = dst[i] ^ src[i]
+	}
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, v)
+	return append(b, buf[:n]...)
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutVarint(buf, v)
+	return append(b, buf[:n]...)
+}
+
+// flateStreams reads flate streams written one after the other as one stream
+type flateStreams struct {
+	// r is a bufio.Reader so flate doesn't read past the end of a stream
+	r  *bufio.Reader
+	fr io.ReadCloser
+}
+
+func (f *flateStreams) Read(p []byte) (int, error) {
+	for {
+		if f.fr == nil {
+			_, err := f.r.Peek(1)
+			if err != nil {
+				return 0, err
+			}
+			f.fr = flate.NewReader(f.r)
+		}
+
+		n, err := f.fr.Read(p)
+		if err == io.EOF {
+			f.fr.Close()
+			f.fr = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+
+		return n, err
+	}
+}
@@ -0,0 +1,148 @@
+package irsdk
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/leonb/irsdk-go/fakesim"
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// closingReader fails to read after it's closed, like a file
+type closingReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closingReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	return r.Reader.Read(p)
+}
+
+func (r *closingReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+// recordTicks writes n ticks and reads them through conn. It returns the
+// SessionTime of every frame read.
+func recordTicks(t *testing.T, p *fakesim.Producer, conn *Connection, n int) []float64 {
+	times := []float64{}
+	for i := 0; i < n; i++ {
+		err := p.Tick()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		frame, err := conn.GetFrame()
+		if err != nil {
+			t.Fatalf("tick %d: %v", i, err)
+		}
+
+		sessionTime, err := frame.Double("SessionTime")
+		if err != nil {
+			t.Fatal(err)
+		}
+		times = append(times, sessionTime)
+	}
+
+	return times
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	p, path := newFakeSim(t)
+
+	buf := &bytes.Buffer{}
+	rec := NewRecorder(NewMmapSource(path, nil), buf)
+	conn := NewConnectionFromSource(rec)
+	err := conn.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.WaitForDataReady(0)
+
+	recorded := recordTicks(t, p, conn, 30)
+
+	// Reconnect, with new session info. The recording should go on.
+	err = p.SetSessionInfo([]byte("WeekendInfo:\n TrackName: recorded\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.WaitForDataReady(0)
+
+	recorded = append(recorded, recordTicks(t, p, conn, 30)...)
+
+	err = conn.Disconnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	play := NewConnectionFromSource(NewRecordingSource(&closingReader{Reader: buf}, 0))
+	err = play.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	played := []float64{}
+	for {
+		// A reconnect halfway continues the playback
+		if len(played) == 10 {
+			play.Disconnect()
+			err = play.Connect()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		frame, err := play.GetFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("frame %d: %v", len(played), err)
+		}
+
+		sessionTime, err := frame.Double("SessionTime")
+		if err != nil {
+			t.Fatal(err)
+		}
+		played = append(played, sessionTime)
+	}
+
+	if len(played) != len(recorded) {
+		t.Fatalf("played %d frames, recorded %d", len(played), len(recorded))
+	}
+	for i := range recorded {
+		if played[i] != recorded[i] {
+			t.Fatalf("frame %d: SessionTime is %v, want %v", i, played[i], recorded[i])
+		}
+	}
+
+	data, err := play.GetSessionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.WeekendInfo.TrackName != "recorded" {
+		t.Errorf("TrackName is %q, want the session info after the reconnect", data.WeekendInfo.TrackName)
+	}
+}
+
+func TestRecordingSourceRejectsOtherData(t *testing.T) {
+	src := NewRecordingSource(bytes.NewReader([]byte("not a recording")), 0)
+	if err := src.Open(); err != ErrNoRecording {
+		t.Errorf("Open returned %v, want ErrNoRecording", err)
+	}
+
+	if _, err := src.WaitForTick(0); err != utils.ErrInitialize {
+		t.Errorf("WaitForTick returned %v, want utils.ErrInitialize", err)
+	}
+}
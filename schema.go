@@ -0,0 +1,384 @@
+package irsdk
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// VarInfo describes a single telemetry variable as published by the sim
+type VarInfo struct {
+	Name   string
+	Desc   string
+	Unit   string
+	Type   utils.VarType
+	Count  int
+	Offset int
+	// Index is the position of the variable in the var headers
+	Index int
+}
+
+// Size returns the number of bytes the variable occupies in a var buffer
+func (v *VarInfo) Size() int {
+	return int(utils.VarTypeBytes[v.Type]) * v.Count
+}
+
+// IsArray reports if the variable has more than one entry (CarIdx*)
+func (v *VarInfo) IsArray() bool {
+	return v.Count > 1
+}
+
+// Schema describes the layout of a var buffer. It's built from the var headers
+// so every variable the sim publishes can be read, not only the ones in
+// TelemetryData.
+type Schema struct {
+	vars   []*VarInfo
+	byName map[string]*VarInfo
+	bufLen int
+}
+
+// NewSchema builds a Schema from the var headers of a source. It returns
+// ErrInvalidVarType when a var header has a type that doesn't exist.
+func NewSchema(varHeaders []*utils.VarHeader) (*Schema, error) {
+	s := &Schema{
+		vars:   make([]*VarInfo, 0, len(varHeaders)),
+		byName: make(map[string]*VarInfo, len(varHeaders)),
+	}
+
+	for i, vh := range varHeaders {
+		if vh == nil {
+			continue
+		}
+		if vh.Type < 0 || vh.Type >= utils.ETCount {
+			return nil, ErrInvalidVarType
+		}
+
+		count := int(vh.Count)
+		if count < 1 {
+			count = 1
+		}
+
+		v := &VarInfo{
+			Name:   utils.CToGoString(vh.Name[:]),
+			Desc:   utils.CToGoString(vh.Desc[:]),
+			Unit:   utils.CToGoString(vh.Unit[:]),
+			Type:   vh.Type,
+			Count:  count,
+			Offset: int(vh.Offset),
+			Index:  i,
+		}
+
+		s.vars = append(s.vars, v)
+		s.byName[v.Name] = v
+
+		if end := v.Offset + v.Size(); end > s.bufLen {
+			s.bufLen = end
+		}
+	}
+
+	return s, nil
+}
+
+// Vars returns all variables in var header order
+func (s *Schema) Vars() []*VarInfo {
+	return s.vars
+}
+
+// Names returns the sorted names of all variables
+func (s *Schema) Names() []string {
+	names := make([]string, 0, len(s.vars))
+	for _, v := range s.vars {
+		names = append(names, v.Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the variable called name or nil when the sim doesn't publish
+// it
+func (s *Schema) Lookup(name string) *VarInfo {
+	return s.byName[name]
+}
+
+// Filter returns a schema with only the variables in names. Frames read with it
+// return ErrUnknownVar for the others.
+func (s *Schema) Filter(names []string) *Schema {
+	filtered := &Schema{
+		vars:   make([]*VarInfo, 0, len(names)),
+		byName: make(map[string]*VarInfo, len(names)),
+	}
+
+	for _, name := range names {
+		v := s.byName[name]
+		if v == nil || filtered.byName[name] != nil {
+			continue
+		}
+
+		filtered.vars = append(filtered.vars, v)
+		filtered.byName[name] = v
+		if end := v.Offset + v.Size(); end > filtered.bufLen {
+			filtered.bufLen = end
+		}
+	}
+
+	return filtered
+}
+
+// Len returns the number of variables
+func (s *Schema) Len() int {
+	return len(s.vars)
+}
+
+// MinBufLen returns the minimal length of a var buffer holding every variable
+func (s *Schema) MinBufLen() int {
+	return s.bufLen
+}
+
+// NewFrame wraps a var buffer. The frame reads directly from data, so data
+// shouldn't be modified while the frame is in use.
+func (s *Schema) NewFrame(data []byte) *Frame {
+	return &Frame{
+		schema: s,
+		data:   data,
+	}
+}
+
+// Frame is a single var buffer (one tick) read through a Schema
+type Frame struct {
+	schema *Schema
+	data   []byte
+
+	// TickCount is the tick the var buffer was written at, if the source
+	// knows it
+	TickCount int32
+}
+
+// Schema returns the schema the frame is read with
+func (f *Frame) Schema() *Schema {
+	return f.schema
+}
+
+// Raw returns the underlying var buffer
+func (f *Frame) Raw() []byte {
+	return f.data
+}
+
+// Has reports if the frame contains the variable called name
+func (f *Frame) Has(name string) bool {
+	return f.schema.Lookup(name) != nil
+}
+
+// lookup finds a variable of type varType and checks that all of its entries
+// are inside the var buffer
+func (f *Frame) lookup(name string, varType utils.VarType) (*VarInfo, error) {
+	v := f.schema.Lookup(name)
+	if v == nil {
+		return nil, ErrUnknownVar
+	}
+
+	if v.Type != varType {
+		return nil, ErrWrongVarType
+	}
+
+	if v.Offset < 0 || v.Offset+v.Size() > len(f.data) {
+		return nil, ErrIndexOutOfRange
+	}
+
+	return v, nil
+}
+
+func (f *Frame) entry(v *VarInfo, i int) []byte {
+	size := int(utils.VarTypeBytes[v.Type])
+	offset := v.Offset + i*size
+	return f.data[offset : offset+size]
+}
+
+// Char returns the first entry of a char variable
+func (f *Frame) Char(name string) (byte, error) {
+	v, err := f.lookup(name, utils.CharType)
+	if err != nil {
+		return 0, err
+	}
+
+	return f.entry(v, 0)[0], nil
+}
+
+// Bool returns the first entry of a bool variable
+func (f *Frame) Bool(name string) (bool, error) {
+	v, err := f.lookup(name, utils.BoolType)
+	if err != nil {
+		return false, err
+	}
+
+	return f.entry(v, 0)[0] != 0, nil
+}
+
+// BoolArray returns all entries of a bool variable (CarIdxOnPitRoad)
+func (f *Frame) BoolArray(name string) ([]bool, error) {
+	v, err := f.lookup(name, utils.BoolType)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]bool, v.Count)
+	for i := range values {
+		values[i] = f.entry(v, i)[0] != 0
+	}
+
+	return values, nil
+}
+
+// Int returns the first entry of an int variable
+func (f *Frame) Int(name string) (int, error) {
+	v, err := f.lookup(name, utils.IntType)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(int32(binary.LittleEndian.Uint32(f.entry(v, 0)))), nil
+}
+
+// IntArray returns all entries of an int variable (CarIdxPosition)
+func (f *Frame) IntArray(name string) ([]int, error) {
+	v, err := f.lookup(name, utils.IntType)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]int, v.Count)
+	for i := range values {
+		values[i] = int(int32(binary.LittleEndian.Uint32(f.entry(v, i))))
+	}
+
+	return values, nil
+}
+
+// Bitfield returns the first entry of a bitfield variable (SessionFlags)
+func (f *Frame) Bitfield(name string) (uint32, error) {
+	v, err := f.lookup(name, utils.BitfieldType)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(f.entry(v, 0)), nil
+}
+
+// BitfieldArray returns all entries of a bitfield variable
+// (CarIdxSessionFlags)
+func (f *Frame) BitfieldArray(name string) ([]uint32, error) {
+	v, err := f.lookup(name, utils.BitfieldType)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]uint32, v.Count)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(f.entry(v, i))
+	}
+
+	return values, nil
+}
+
+// Float returns the first entry of a float variable
+func (f *Frame) Float(name string) (float32, error) {
+	v, err := f.lookup(name, utils.FloatType)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float32frombits(binary.LittleEndian.Uint32(f.entry(v, 0))), nil
+}
+
+// FloatArray returns all entries of a float variable (CarIdxRPM)
+func (f *Frame) FloatArray(name string) ([]float32, error) {
+	v, err := f.lookup(name, utils.FloatType)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float32, v.Count)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(f.entry(v, i)))
+	}
+
+	return values, nil
+}
+
+// Double returns the first entry of a double variable (SessionTime)
+func (f *Frame) Double(name string) (float64, error) {
+	v, err := f.lookup(name, utils.DoubleType)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(f.entry(v, 0))), nil
+}
+
+// DoubleArray returns all entries of a double variable
+func (f *Frame) DoubleArray(name string) ([]float64, error) {
+	v, err := f.lookup(name, utils.DoubleType)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, v.Count)
+	for i := range values {
+		values[i] = math.Float64frombits(binary.LittleEndian.Uint64(f.entry(v, i)))
+	}
+
+	return values, nil
+}
+
+// Value returns any variable as a Go value: a scalar (byte, bool, int,
+// uint32, float32 or float64) for single entry variables and a slice for
+// arrays
+func (f *Frame) Value(name string) (interface{}, error) {
+	v := f.schema.Lookup(name)
+	if v == nil {
+		return nil, ErrUnknownVar
+	}
+
+	switch v.Type {
+	case utils.CharType:
+		if v.IsArray() {
+			v, err := f.lookup(name, utils.CharType)
+			if err != nil {
+				return nil, err
+			}
+			values := make([]byte, v.Count)
+			copy(values, f.data[v.Offset:v.Offset+v.Count])
+			return values, nil
+		}
+		return f.Char(name)
+	case utils.BoolType:
+		if v.IsArray() {
+			return f.BoolArray(name)
+		}
+		return f.Bool(name)
+	case utils.IntType:
+		if v.IsArray() {
+			return f.IntArray(name)
+		}
+		return f.Int(name)
+	case utils.BitfieldType:
+		if v.IsArray() {
+			return f.BitfieldArray(name)
+		}
+		return f.Bitfield(name)
+	case utils.FloatType:
+		if v.IsArray() {
+			return f.FloatArray(name)
+		}
+		return f.Float(name)
+	case utils.DoubleType:
+		if v.IsArray() {
+			return f.DoubleArray(name)
+		}
+		return f.Double(name)
+	}
+
+	return nil, ErrWrongVarType
+}
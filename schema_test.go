@@ -0,0 +1,84 @@
+package irsdk
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+func TestNewSchema(t *testing.T) {
+	varHeaders := testVarHeaders()
+	carIdx := testVarHeader("CarIdxLap", utils.IntType, 16)
+	carIdx.Count = 4
+	varHeaders = append(varHeaders, carIdx)
+
+	schema, err := NewSchema(varHeaders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schema.Len() != 4 || schema.MinBufLen() != 32 {
+		t.Errorf("got %d vars and a buffer of %d bytes", schema.Len(), schema.MinBufLen())
+	}
+	if v := schema.Lookup("CarIdxLap"); v == nil || !v.IsArray() || v.Size() != 16 || v.Index != 3 {
+		t.Errorf("CarIdxLap: got %+v", v)
+	}
+	if schema.Lookup("RPM") != nil {
+		t.Error("found a variable that isn't there")
+	}
+
+	filtered := schema.Filter([]string{"Speed", "RPM", "Speed"})
+	if filtered.Len() != 1 || filtered.MinBufLen() != 16 {
+		t.Errorf("filtered: got %d vars and a buffer of %d bytes", filtered.Len(), filtered.MinBufLen())
+	}
+}
+
+func TestNewSchemaInvalidType(t *testing.T) {
+	for _, varType := range []utils.VarType{-1, utils.ETCount, 1000} {
+		varHeaders := append(testVarHeaders(), testVarHeader("Broken", varType, 16))
+		_, err := NewSchema(varHeaders)
+		if err != ErrInvalidVarType {
+			t.Errorf("type %d: got %v, want %v", varType, err, ErrInvalidVarType)
+		}
+	}
+}
+
+func TestFrame(t *testing.T) {
+	schema, err := NewSchema(testVarHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint64(data[0:], math.Float64bits(12.5))
+	binary.LittleEndian.PutUint32(data[8:], 3)
+	binary.LittleEndian.PutUint32(data[12:], math.Float32bits(42))
+	frame := schema.NewFrame(data)
+
+	if v, err := frame.Double("SessionTime"); err != nil || v != 12.5 {
+		t.Errorf("SessionTime: got %v, %v", v, err)
+	}
+	if v, err := frame.Int("Lap"); err != nil || v != 3 {
+		t.Errorf("Lap: got %v, %v", v, err)
+	}
+	if v, err := frame.Float("Speed"); err != nil || v != 42 {
+		t.Errorf("Speed: got %v, %v", v, err)
+	}
+	if v, err := frame.Value("Lap"); err != nil || v != 3 {
+		t.Errorf("Value(Lap): got %v, %v", v, err)
+	}
+
+	if _, err := frame.Float("Lap"); err != ErrWrongVarType {
+		t.Errorf("Float(Lap): got %v, want %v", err, ErrWrongVarType)
+	}
+	if _, err := frame.Float("RPM"); err != ErrUnknownVar {
+		t.Errorf("Float(RPM): got %v, want %v", err, ErrUnknownVar)
+	}
+
+	short := schema.NewFrame(data[:12])
+	if _, err := short.Float("Speed"); err != ErrIndexOutOfRange {
+		t.Errorf("Speed of a short buffer: got %v, want %v", err, ErrIndexOutOfRange)
+	}
+}
@@ -2,50 +2,59 @@ package irsdk
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
-
-	yaml "gopkg.in/yaml.v2"
 )
 
 type SessionData struct {
-	WeekendInfo   WeekendInfo   `yaml:"WeekendInfo"`
-	SessionInfo   SessionInfo   `yaml:"SessionInfo"`
-	CameraInfo    CameraInfo    `yaml:"CameraInfo"`
-	RadioInfo     RadioInfo     `yaml:"RadioInfo"`
-	DriverInfo    DriverInfo    `yaml:"DriverInfo"`
-	SplitTimeInfo SplitTimeInfo `yaml:"SplitTimeInfo"`
-}
+	WeekendInfo        WeekendInfo        `yaml:"WeekendInfo"`
+	SessionInfo        SessionInfo        `yaml:"SessionInfo"`
+	QualifyResultsInfo QualifyResultsInfo `yaml:"QualifyResultsInfo"`
+	CameraInfo         CameraInfo         `yaml:"CameraInfo"`
+	RadioInfo          RadioInfo          `yaml:"RadioInfo"`
+	DriverInfo         DriverInfo         `yaml:"DriverInfo"`
+	SplitTimeInfo      SplitTimeInfo      `yaml:"SplitTimeInfo"`
+	CarSetup           CarSetup           `yaml:"CarSetup"`
 
-// https://github.com/smyrman/units
-// quantity + unit
-type unit string
+	// Raw contains the complete session info, including everything the
+	// structs above don't know (yet)
+	Raw map[string]interface{} `yaml:"-"`
+}
 
 type WeekendInfo struct {
 	// TrackName string -> TrackName string `yaml:"TrackName"`
-	// vim: s/\(\t\(.\{-}\) \).*/\0 `yaml:"\2"`
+	// Does yaml.v2 need the tags? Maybe just rely on the automatic naming?
 	TrackName              string           `yaml:"TrackName"`
 	TrackID                int              `yaml:"TrackID"`
-	TrackLength            string           `yaml:"TrackLength"`
+	TrackLength            Quantity         `yaml:"TrackLength"`
+	TrackLengthOfficial    Quantity         `yaml:"TrackLengthOfficial"`
 	TrackDisplayName       string           `yaml:"TrackDisplayName"`
 	TrackDisplayShortName  string           `yaml:"TrackDisplayShortName"`
 	TrackConfigName        string           `yaml:"TrackConfigName"`
 	TrackCity              string           `yaml:"TrackCity"`
+	TrackState             string           `yaml:"TrackState"`
 	TrackCountry           string           `yaml:"TrackCountry"`
-	TrackAltitude          unit             `yaml:"TrackAltitude"`
-	TrackLatitude          unit             `yaml:"TrackLatitude"`
-	TrackLongitude         unit             `yaml:"TrackLongitude"`
+	TrackAltitude          Quantity         `yaml:"TrackAltitude"`
+	TrackLatitude          Coordinate       `yaml:"TrackLatitude"`
+	TrackLongitude         Coordinate       `yaml:"TrackLongitude"`
+	TrackNorthOffset       Quantity         `yaml:"TrackNorthOffset"`
 	TrackNumTurns          int              `yaml:"TrackNumTurns"`
-	TrackPitSpeedLimit     unit             `yaml:"TrackPitSpeedLimit"`
+	TrackPitSpeedLimit     Quantity         `yaml:"TrackPitSpeedLimit"`
 	TrackType              string           `yaml:"TrackType"`
+	TrackDirection         string           `yaml:"TrackDirection"`
 	TrackWeatherType       string           `yaml:"TrackWeatherType"`
 	TrackSkies             string           `yaml:"TrackSkies"`
-	TrackSurfaceTemp       unit             `yaml:"TrackSurfaceTemp"`
-	TrackAirTemp           unit             `yaml:"TrackAirTemp"`
-	TrackAirPressure       unit             `yaml:"TrackAirPressure"`
-	TrackWindVel           unit             `yaml:"TrackWindVel"`
-	TrackWindDir           unit             `yaml:"TrackWindDir"`
-	TrackRelativeHumidity  unit             `yaml:"TrackRelativeHumidity"`
-	TrackFogLevel          unit             `yaml:"TrackFogLevel"`
+	TrackSurfaceTemp       Quantity         `yaml:"TrackSurfaceTemp"`
+	TrackAirTemp           Quantity         `yaml:"TrackAirTemp"`
+	TrackAirPressure       Quantity         `yaml:"TrackAirPressure"`
+	TrackWindVel           Quantity         `yaml:"TrackWindVel"`
+	TrackWindDir           Quantity         `yaml:"TrackWindDir"`
+	TrackRelativeHumidity  Quantity         `yaml:"TrackRelativeHumidity"`
+	TrackFogLevel          Quantity         `yaml:"TrackFogLevel"`
+	TrackPrecipitation     Quantity         `yaml:"TrackPrecipitation"`
+	TrackCleanup           intToBool        `yaml:"TrackCleanup"`
+	TrackDynamicTrack      intToBool        `yaml:"TrackDynamicTrack"`
+	TrackVersion           string           `yaml:"TrackVersion"`
 	SeriesID               int              `yaml:"SeriesID"`
 	SeasonID               int              `yaml:"SeasonID"`
 	SessionID              int              `yaml:"SessionID"`
@@ -63,31 +72,44 @@ type WeekendInfo struct {
 	QualifierMustStartRace intToBool        `yaml:"QualifierMustStartRace"`
 	NumCarClasses          int              `yaml:"NumCarClasses"`
 	NumCarTypes            int              `yaml:"NumCarTypes"`
+	HeatRacing             intToBool        `yaml:"HeatRacing"`
+	BuildType              string           `yaml:"BuildType"`
+	BuildTarget            string           `yaml:"BuildTarget"`
+	BuildVersion           string           `yaml:"BuildVersion"`
+	RaceFarm               string           `yaml:"RaceFarm"`
 	WeekendOptions         WeekendOptions   `yaml:"WeekendOptions"`
 	TelemetryOptions       TelemetryOptions `yaml:"TelemetryOptions"`
 }
 
 type WeekendOptions struct {
-	NumStarters         int       `yaml:"NumStarters"`
-	StartingGrid        string    `yaml:"StartingGrid"`
-	QualifyScoring      string    `yaml:"QualifyScoring"`
-	CourseCautions      string    `yaml:"CourseCautions"`
-	StandingStart       intToBool `yaml:"StandingStart"`
-	Restarts            string    `yaml:"Restarts"`
-	WeatherType         string    `yaml:"WeatherType"`
-	Skies               string    `yaml:"Skies"`
-	WindDirection       unit      `yaml:"WindDirection"`
-	WindSpeed           unit      `yaml:"WindSpeed"`
-	WeatherTemp         unit      `yaml:"WeatherTemp"`
-	RelativeHumidity    unit      `yaml:"RelativeHumidity"`
-	FogLevel            unit      `yaml:"FogLevel"`
-	Unofficial          intToBool `yaml:"Unofficial"`
-	CommercialMode      string    `yaml:"CommercialMode"`
-	NightMode           intToBool `yaml:"NightMode"`
-	IsFixedSetup        intToBool `yaml:"IsFixedSetup"`
-	StrictLapsChecking  string    `yaml:"StrictLapsChecking"`
-	HasOpenRegistration intToBool `yaml:"HasOpenRegistration"`
-	HardcoreLevel       int       `yaml:"HardcoreLevel"`
+	NumStarters                int       `yaml:"NumStarters"`
+	StartingGrid               string    `yaml:"StartingGrid"`
+	QualifyScoring             string    `yaml:"QualifyScoring"`
+	CourseCautions             string    `yaml:"CourseCautions"`
+	StandingStart              intToBool `yaml:"StandingStart"`
+	ShortParadeLap             intToBool `yaml:"ShortParadeLap"`
+	Restarts                   string    `yaml:"Restarts"`
+	WeatherType                string    `yaml:"WeatherType"`
+	Skies                      string    `yaml:"Skies"`
+	WindDirection              string    `yaml:"WindDirection"`
+	WindSpeed                  Quantity  `yaml:"WindSpeed"`
+	WeatherTemp                Quantity  `yaml:"WeatherTemp"`
+	RelativeHumidity           Quantity  `yaml:"RelativeHumidity"`
+	FogLevel                   Quantity  `yaml:"FogLevel"`
+	TimeOfDay                  string    `yaml:"TimeOfDay"`
+	Date                       string    `yaml:"Date"`
+	EarthRotationSpeedupFactor int       `yaml:"EarthRotationSpeedupFactor"`
+	Unofficial                 intToBool `yaml:"Unofficial"`
+	CommercialMode             string    `yaml:"CommercialMode"`
+	NightMode                  intToBool `yaml:"NightMode"`
+	IsFixedSetup               intToBool `yaml:"IsFixedSetup"`
+	StrictLapsChecking         string    `yaml:"StrictLapsChecking"`
+	HasOpenRegistration        intToBool `yaml:"HasOpenRegistration"`
+	HardcoreLevel              int       `yaml:"HardcoreLevel"`
+	NumJokerLaps               int       `yaml:"NumJokerLaps"`
+	IncidentLimit              string    `yaml:"IncidentLimit"`
+	FastRepairsLimit           string    `yaml:"FastRepairsLimit"`
+	GreenWhiteCheckeredLimit   int       `yaml:"GreenWhiteCheckeredLimit"`
 }
 
 type TelemetryOptions struct {
@@ -99,31 +121,44 @@ type SessionInfo struct {
 }
 
 type Session struct {
-	SessionNum             int                `yaml:"SessionNum"`
-	SessionLaps            string             `yaml:"SessionLaps"`
-	SessionTime            string             `yaml:"SessionTime"`
-	SessionNumLapsToAvg    int                `yaml:"SessionNumLapsToAvg"`
-	SessionType            string             `yaml:"SessionType"`
-	ResultsPositions       []ResultPosition   `yaml:"ResultsPositions"`
-	ResultsFastestLap      []ResultFastestLap `yaml:"ResultsFastestLap"`
-	ResultsAverageLapTime  float32            `yaml:"ResultsAverageLapTime"`
-	ResultsNumCautionFlags int                `yaml:"ResultsNumCautionFlags"`
-	ResultsNumCautionLaps  int                `yaml:"ResultsNumCautionLaps"`
-	ResultsNumLeadChanges  int                `yaml:"ResultsNumLeadChanges"`
-	ResultsLapsComplete    int                `yaml:"ResultsLapsComplete"`
-	ResultsOfficial        int                `yaml:"ResultsOfficial"`
+	SessionNum                       int                `yaml:"SessionNum"`
+	SessionLaps                      LapLimit           `yaml:"SessionLaps"`
+	SessionTime                      TimeLimit          `yaml:"SessionTime"`
+	SessionNumLapsToAvg              int                `yaml:"SessionNumLapsToAvg"`
+	SessionType                      string             `yaml:"SessionType"`
+	SessionTrackRubberState          string             `yaml:"SessionTrackRubberState"`
+	SessionName                      string             `yaml:"SessionName"`
+	SessionSubType                   string             `yaml:"SessionSubType"`
+	SessionSkipped                   intToBool          `yaml:"SessionSkipped"`
+	SessionRunGroupsUsed             intToBool          `yaml:"SessionRunGroupsUsed"`
+	SessionEnforceTireCompoundChange intToBool          `yaml:"SessionEnforceTireCompoundChange"`
+	ResultsPositions                 []ResultPosition   `yaml:"ResultsPositions"`
+	ResultsFastestLap                []ResultFastestLap `yaml:"ResultsFastestLap"`
+	ResultsAverageLapTime            float32            `yaml:"ResultsAverageLapTime"`
+	ResultsNumCautionFlags           int                `yaml:"ResultsNumCautionFlags"`
+	ResultsNumCautionLaps            int                `yaml:"ResultsNumCautionLaps"`
+	ResultsNumLeadChanges            int                `yaml:"ResultsNumLeadChanges"`
+	ResultsLapsComplete              int                `yaml:"ResultsLapsComplete"`
+	ResultsOfficial                  int                `yaml:"ResultsOfficial"`
 }
 
 type ResultPosition struct {
-	CarIdx        int     `yaml:"CarIdx"`
-	Position      int     `yaml:"Position"`
-	ClassPosition int     `yaml:"ClassPosition"`
-	FastestTime   float32 `yaml:"FastestTime"`
-	Lap           int     `yaml:"Lap"`
-	LastTime      float32 `yaml:"LastTime"`
-	LapsComplete  int     `yaml:"LapsComplete"`
-	LapsDriven    int     `yaml:"LapsDriven"`
-	ReasonOutId   int     `yaml:"ReasonOutId"`
+	Position          int     `yaml:"Position"`
+	ClassPosition     int     `yaml:"ClassPosition"`
+	CarIdx            int     `yaml:"CarIdx"`
+	Lap               int     `yaml:"Lap"`
+	Time              float32 `yaml:"Time"`
+	FastestLap        int     `yaml:"FastestLap"`
+	FastestTime       float32 `yaml:"FastestTime"`
+	LastTime          float32 `yaml:"LastTime"`
+	LapsLed           int     `yaml:"LapsLed"`
+	LapsComplete      int     `yaml:"LapsComplete"`
+	JokerLapsComplete int     `yaml:"JokerLapsComplete"`
+	// LapsDriven is fractional (laps started at the line count partially)
+	LapsDriven   float32 `yaml:"LapsDriven"`
+	Incidents    int     `yaml:"Incidents"`
+	ReasonOutId  int     `yaml:"ReasonOutId"`
+	ReasonOutStr string  `yaml:"ReasonOutStr"`
 }
 
 type ResultFastestLap struct {
@@ -132,15 +167,27 @@ type ResultFastestLap struct {
 	FastestTime float32 `yaml:"FastestTime"`
 }
 
+type QualifyResultsInfo struct {
+	Results []QualifyResult `yaml:"Results"`
+}
+
+type QualifyResult struct {
+	Position      int     `yaml:"Position"`
+	ClassPosition int     `yaml:"ClassPosition"`
+	CarIdx        int     `yaml:"CarIdx"`
+	FastestLap    int     `yaml:"FastestLap"`
+	FastestTime   float32 `yaml:"FastestTime"`
+}
+
 type CameraInfo struct {
 	Groups []CameraGroup `yaml:"Groups"`
 }
 
 type CameraGroup struct {
-	GroupNum  int      `yaml:"GroupNum"`
-	GroupName string   `yaml:"GroupName"`
-	IsScenic  bool     `yaml:"IsSenic"`
-	Cameras   []Camera `yaml:"Cameras"`
+	GroupNum  int       `yaml:"GroupNum"`
+	GroupName string    `yaml:"GroupName"`
+	IsScenic  intToBool `yaml:"IsScenic"`
+	Cameras   []Camera  `yaml:"Cameras"`
 }
 
 type Camera struct {
@@ -177,19 +224,35 @@ type Frequency struct {
 }
 
 type DriverInfo struct {
-	DriverCarIdx          int      `yaml:"DriverCarIdx"`
-	DriverHeadPosX        float32  `yaml:"DriverHeadPosX"`
-	DriverHeadPosY        float32  `yaml:"DriverHeadPosY"`
-	DriverHeadPosZ        float32  `yaml:"DriverHeadPosZ"`
-	DriverCarIdleRPM      float32  `yaml:"DriverCarIdleRPM"`
-	DriverCarRedLine      float32  `yaml:"DriverCarRedLine"`
-	DriverCarFuelKgPerLtr float32  `yaml:"DriverCarFuelKgPerLtr"`
-	DriverCarSLFirstRPM   float32  `yaml:"DriverCarSLFirstRPM"`
-	DriverCarSLShiftRPM   float32  `yaml:"DriverCarSLShiftRPM"`
-	DriverCarSLLastRPM    float32  `yaml:"DriverCarSLLastRPM"`
-	DriverCarSLBlinkRPM   float32  `yaml:"DriverCarSLBlinkRPM"`
-	DriverPitTrkPct       float32  `yaml:"DriverPitTrkPct"`
-	Drivers               []Driver `yaml:"Drivers"`
+	DriverCarIdx              int       `yaml:"DriverCarIdx"`
+	DriverUserID              int       `yaml:"DriverUserID"`
+	PaceCarIdx                int       `yaml:"PaceCarIdx"`
+	DriverHeadPosX            float32   `yaml:"DriverHeadPosX"`
+	DriverHeadPosY            float32   `yaml:"DriverHeadPosY"`
+	DriverHeadPosZ            float32   `yaml:"DriverHeadPosZ"`
+	DriverCarIsElectric       intToBool `yaml:"DriverCarIsElectric"`
+	DriverCarIdleRPM          float32   `yaml:"DriverCarIdleRPM"`
+	DriverCarRedLine          float32   `yaml:"DriverCarRedLine"`
+	DriverCarEngCylinderCount int       `yaml:"DriverCarEngCylinderCount"`
+	DriverCarFuelKgPerLtr     float32   `yaml:"DriverCarFuelKgPerLtr"`
+	DriverCarFuelMaxLtr       float32   `yaml:"DriverCarFuelMaxLtr"`
+	DriverCarMaxFuelPct       float32   `yaml:"DriverCarMaxFuelPct"`
+	DriverCarGearNumForward   int       `yaml:"DriverCarGearNumForward"`
+	DriverCarGearNeutral      int       `yaml:"DriverCarGearNeutral"`
+	DriverCarGearReverse      int       `yaml:"DriverCarGearReverse"`
+	DriverCarSLFirstRPM       float32   `yaml:"DriverCarSLFirstRPM"`
+	DriverCarSLShiftRPM       float32   `yaml:"DriverCarSLShiftRPM"`
+	DriverCarSLLastRPM        float32   `yaml:"DriverCarSLLastRPM"`
+	DriverCarSLBlinkRPM       float32   `yaml:"DriverCarSLBlinkRPM"`
+	DriverCarVersion          string    `yaml:"DriverCarVersion"`
+	DriverPitTrkPct           float32   `yaml:"DriverPitTrkPct"`
+	DriverCarEstLapTime       float32   `yaml:"DriverCarEstLapTime"`
+	DriverSetupName           string    `yaml:"DriverSetupName"`
+	DriverSetupIsModified     intToBool `yaml:"DriverSetupIsModified"`
+	DriverSetupLoadTypeName   string    `yaml:"DriverSetupLoadTypeName"`
+	DriverSetupPassedTech     intToBool `yaml:"DriverSetupPassedTech"`
+	DriverIncidentCount       int       `yaml:"DriverIncidentCount"`
+	Drivers                   []Driver  `yaml:"Drivers"`
 }
 
 type Driver struct {
@@ -201,31 +264,47 @@ type Driver struct {
 	TeamID     int    `yaml:"TeamID"`
 	TeamName   string `yaml:"TeamName"`
 	// Or shoud CarNumber be an int?
-	CarNumber             string `yaml:"CarNumber"`
-	CarNumberRaw          int    `yaml:"CarNumberRaw"`
-	CarPath               string `yaml:"CarPath"`
-	CarClassID            int    `yaml:"CarClassID"`
-	CarID                 int    `yaml:"CarID"`
-	CarScreenName         string `yaml:"CarScreenName"`
-	CarScreenNameShort    string `yaml:"CarScreenNameShort"`
-	CarClassShortName     string `yaml:"CarClassShortName"`
-	CarClassRelSpeed      int    `yaml:"CarClassRelSpeed"`
-	CarClassLicenseLevel  int    `yaml:"CarClassLicenseLevel"`
-	CarClassMaxFuel       unit   `yaml:"CarClassMaxFuel"`
-	CarClassWeightPenalty unit   `yaml:"CarClassWeightPenalty"`
-	// CarClassColor: 0xffffff
-	IRating     int    `yaml:"IRating"`
-	LicLevel    int    `yaml:"LicLevel"`
-	LicSubLevel int    `yaml:"LicSubLevel"`
-	LicString   string `yaml:"LicString"`
-	// LicColor: 0xfc8a27
-	IsSpectator intToBool `yaml:"IsSpectator"`
-	// CarDesignStr: 0,FFFFFF,ED2129,2A3795
-	// HelmetDesignStr: 56,000000,000000,000000
-	// SuitDesignStr: 0,000000,000000,000000
-	// CarNumberDesignStr: 0,0,FFFFFF,777777,000000
-	CarSponsor_1 int `yaml:"CarSponsor_1"`
-	CarSponsor_2 int `yaml:"CarSponsor_2"`
+	CarNumber               string       `yaml:"CarNumber"`
+	CarNumberRaw            int          `yaml:"CarNumberRaw"`
+	CarPath                 string       `yaml:"CarPath"`
+	CarClassID              int          `yaml:"CarClassID"`
+	CarID                   int          `yaml:"CarID"`
+	CarIsPaceCar            intToBool    `yaml:"CarIsPaceCar"`
+	CarIsAI                 intToBool    `yaml:"CarIsAI"`
+	CarIsElectric           intToBool    `yaml:"CarIsElectric"`
+	CarScreenName           string       `yaml:"CarScreenName"`
+	CarScreenNameShort      string       `yaml:"CarScreenNameShort"`
+	CarClassShortName       string       `yaml:"CarClassShortName"`
+	CarClassRelSpeed        int          `yaml:"CarClassRelSpeed"`
+	CarClassLicenseLevel    int          `yaml:"CarClassLicenseLevel"`
+	CarClassMaxFuel         Quantity     `yaml:"CarClassMaxFuel"`
+	CarClassMaxFuelPct      Quantity     `yaml:"CarClassMaxFuelPct"`
+	CarClassWeightPenalty   Quantity     `yaml:"CarClassWeightPenalty"`
+	CarClassPowerAdjust     Quantity     `yaml:"CarClassPowerAdjust"`
+	CarClassDryTireSetLimit Quantity     `yaml:"CarClassDryTireSetLimit"`
+	CarClassColor           Color        `yaml:"CarClassColor"`
+	CarClassEstLapTime      float32      `yaml:"CarClassEstLapTime"`
+	IRating                 int          `yaml:"IRating"`
+	LicLevel                int          `yaml:"LicLevel"`
+	LicSubLevel             int          `yaml:"LicSubLevel"`
+	LicString               string       `yaml:"LicString"`
+	LicColor                Color        `yaml:"LicColor"`
+	IsSpectator             intToBool    `yaml:"IsSpectator"`
+	CarDesignStr            Design       `yaml:"CarDesignStr"`
+	HelmetDesignStr         Design       `yaml:"HelmetDesignStr"`
+	SuitDesignStr           Design       `yaml:"SuitDesignStr"`
+	BodyType                int          `yaml:"BodyType"`
+	FaceType                int          `yaml:"FaceType"`
+	HelmetType              int          `yaml:"HelmetType"`
+	CarNumberDesignStr      NumberDesign `yaml:"CarNumberDesignStr"`
+	CarSponsor_1            int          `yaml:"CarSponsor_1"`
+	CarSponsor_2            int          `yaml:"CarSponsor_2"`
+	ClubName                string       `yaml:"ClubName"`
+	ClubID                  int          `yaml:"ClubID"`
+	DivisionName            string       `yaml:"DivisionName"`
+	DivisionID              int          `yaml:"DivisionID"`
+	CurDriverIncidentCount  int          `yaml:"CurDriverIncidentCount"`
+	TeamIncidentCount       int          `yaml:"TeamIncidentCount"`
 }
 
 type SplitTimeInfo struct {
@@ -240,6 +319,13 @@ type Sector struct {
 type intToBool bool
 
 func (i *intToBool) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Most flags are written as 0/1, but some (IsScenic) as true/false
+	var boolResult bool
+	if err := unmarshal(&boolResult); err == nil {
+		*i = intToBool(boolResult)
+		return nil
+	}
+
 	var intResult int
 	err := unmarshal(&intResult)
 	if err != nil {
@@ -255,24 +341,62 @@ func (i *intToBool) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
-// @TODO: should this accept an io.Reader?
+// NewSessionDataFromReader parses UTF-8 session info. The raw session info of
+// the sim is Windows-1252: Connection.GetSessionData and
+// TelemetryReader.ReadSessionData convert it before parsing.
+func NewSessionDataFromReader(r io.Reader) (*SessionData, error) {
+	yamlData, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSessionDataFromBytes(yamlData)
+}
+
+// NewSessionDataFromBytes parses UTF-8 session info, see
+// NewSessionDataFromReader. Errors in the YAML are returned as a
+// *SessionDataError.
 func NewSessionDataFromBytes(yamlData []byte) (*SessionData, error) {
 	sessionData := newSessionData()
+	sanitized := sanitizeSessionYAML(yamlData)
 
 	// Convert yaml to struct
-	err := yaml.Unmarshal(yamlData, &sessionData)
-	if err != nil || sessionData == nil {
-		fmt.Println(string(yamlData[:]))
-		ioutil.WriteFile("fucked.yml", yamlData, 0644)
+	err := unmarshalSessionYAML(yamlData, sanitized, sessionData)
+	if err != nil {
+		return nil, err
 	}
 
+	// Keep everything, also the fields SessionData doesn't know about
+	raw := map[interface{}]interface{}{}
+	err = unmarshalSessionYAML(yamlData, sanitized, &raw)
 	if err != nil {
 		return nil, err
 	}
+	sessionData.Raw = stringKeys(raw).(map[string]interface{})
 
 	return sessionData, nil
 }
 
+// stringKeys converts the map[interface{}]interface{} values yaml.v2 produces
+// to map[string]interface{} so Raw can be marshalled to JSON
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = stringKeys(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = stringKeys(val)
+		}
+		return v
+	}
+
+	return v
+}
+
 func newSessionData() *SessionData {
 	return &SessionData{}
 }
@@ -0,0 +1,248 @@
+package irsdk
+
+import "testing"
+
+// testSessionYAML is a (shortened) multi class race as the sim writes it,
+// including the pace car, a spectator and fields SessionData doesn't know
+const testSessionYAML = `---
+WeekendInfo:
+ TrackName: spa up
+ TrackID: 163
+ TrackLength: 6.93 km
+ TrackDisplayName: Circuit de Spa-Francorchamps
+ TrackPitSpeedLimit: 60.00 kph
+ TrackCleanup: 0
+ TrackDynamicTrack: 1
+ TrackBrandNewField: 42
+ SubSessionID: 12345678
+ WeekendOptions:
+  NumStarters: 3
+  StandingStart: 0
+  WindSpeed: 3.22 km/h
+  IncidentLimit: 17
+ TelemetryOptions:
+  TelemetryDiskFile: ""
+
+SessionInfo:
+ Sessions:
+ - SessionNum: 0
+   SessionLaps: unlimited
+   SessionTime: 1200.0000 sec
+   SessionType: Lone Qualify
+   SessionName: QUALIFY
+   ResultsPositions:
+ - SessionNum: 1
+   SessionLaps: 12
+   SessionTime: unlimited
+   SessionType: Race
+   SessionName: RACE
+   ResultsPositions:
+   - Position: 1
+     ClassPosition: 0
+     CarIdx: 1
+     Lap: 4
+     Time: 412.2180
+     FastestLap: 3
+     FastestTime: 137.9470
+     LastTime: 138.0010
+     LapsLed: 4
+     LapsComplete: 4
+     JokerLapsComplete: 0
+     LapsDriven: 4.3210
+     Incidents: 2
+     ReasonOutId: 0
+     ReasonOutStr: Running
+   ResultsFastestLap:
+   - CarIdx: 1
+     FastestLap: 3
+     FastestTime: 137.9470
+   ResultsNumLeadChanges: 1
+
+QualifyResultsInfo:
+ Results:
+ - Position: 0
+   ClassPosition: 0
+   CarIdx: 2
+   FastestLap: 2
+   FastestTime: 137.1250
+
+CameraInfo:
+ Groups:
+ - GroupNum: 1
+   GroupName: Nose
+   Cameras:
+   - CameraNum: 1
+     CameraName: CamNose
+ - GroupNum: 10
+   GroupName: TV1
+   IsScenic: true
+   Cameras:
+   - CameraNum: 1
+     CameraName: CamTV1 01
+
+DriverInfo:
+ DriverCarIdx: 1
+ DriverUserID: 100
+ PaceCarIdx: 0
+ DriverCarIdleRPM: 1000.000
+ DriverCarRedLine: 8800.000
+ DriverSetupName: baseline.sto
+ DriverSetupIsModified: 1
+ Drivers:
+ - CarIdx: 0
+   UserName: Pace Car
+   UserID: -1
+   CarNumber: "0"
+   CarClassID: 11
+   CarIsPaceCar: 1
+   CarClassColor: 0xffffff
+   LicColor: 0xundefined
+   CarDesignStr:
+   IsSpectator: 0
+ - CarIdx: 1
+   UserName: Max Mustermann
+   UserID: 100
+   CarNumber: "7"
+   CarClassID: 4029
+   CarClassShortName: GT3
+   CarClassMaxFuelPct: 1.000 %
+   CarClassColor: 0xffda59
+   IRating: 2650
+   LicString: A 3.12
+   LicColor: 0x0153db
+   CarDesignStr: 0,FFFFFF,ED2129,2A3795
+   CarNumberDesignStr: 0,0,FFFFFF,777777,000000
+   CarBrandNewField: new
+ - CarIdx: 2
+   UserName: Jane Doe
+   UserID: 200
+   CarNumber: "42"
+   CarClassID: 4084
+   CarClassShortName: GT4
+   CarClassColor: 0x33ceff
+ - CarIdx: 3
+   UserName: Watching
+   UserID: 300
+   CarClassID: 4029
+   IsSpectator: 1
+
+SplitTimeInfo:
+ Sectors:
+ - SectorNum: 0
+   SectorStartPct: 0.000000
+ - SectorNum: 1
+   SectorStartPct: 0.334000
+ - SectorNum: 2
+   SectorStartPct: 0.678000
+
+CarSetup:
+ UpdateCount: 3
+ TiresAero:
+  LeftFront:
+   StartingPressure: 138 kPa
+   LastTempsOMI: 28C, 28C, 28C
+ Chassis:
+  Front:
+   ArbBlades: 2
+   BrakePressureBias: 54.0%
+  LeftFront:
+   CornerWeight: 4511 N
+   RideHeight: 55.0 mm
+
+BrandNewSection:
+ Something: 1
+...
+`
+
+func testSessionData(tb testing.TB) *SessionData {
+	data, err := NewSessionDataFromBytes([]byte(testSessionYAML))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return data
+}
+
+func TestSessionData(t *testing.T) {
+	data := testSessionData(t)
+
+	weekend := data.WeekendInfo
+	if weekend.TrackID != 163 || weekend.SubSessionID != 12345678 {
+		t.Errorf("WeekendInfo: TrackID %d, SubSessionID %d", weekend.TrackID, weekend.SubSessionID)
+	}
+	if weekend.TrackLength.Value != 6.93 || weekend.TrackLength.Unit != "km" {
+		t.Errorf("TrackLength: got %v", weekend.TrackLength)
+	}
+	if weekend.TrackCleanup || !weekend.TrackDynamicTrack {
+		t.Errorf("TrackCleanup %v, TrackDynamicTrack %v", weekend.TrackCleanup, weekend.TrackDynamicTrack)
+	}
+	if weekend.WeekendOptions.IncidentLimit != "17" {
+		t.Errorf("IncidentLimit: got %q", weekend.WeekendOptions.IncidentLimit)
+	}
+
+	race := data.SessionInfo.Sessions[1]
+	if len(race.ResultsPositions) != 1 || race.ResultsPositions[0].LapsDriven != 4.321 {
+		t.Errorf("ResultsPositions: got %+v", race.ResultsPositions)
+	}
+	if len(race.ResultsFastestLap) != 1 || race.ResultsFastestLap[0].FastestTime != 137.947 {
+		t.Errorf("ResultsFastestLap: got %+v", race.ResultsFastestLap)
+	}
+
+	if results := data.QualifyResultsInfo.Results; len(results) != 1 || results[0].CarIdx != 2 {
+		t.Errorf("QualifyResultsInfo: got %+v", results)
+	}
+
+	if !data.CameraInfo.Groups[1].IsScenic {
+		t.Error("IsScenic: true isn't decoded")
+	}
+
+	driverInfo := data.DriverInfo
+	if !driverInfo.DriverSetupIsModified || driverInfo.DriverCarRedLine != 8800 {
+		t.Errorf("DriverInfo: got %+v", driverInfo)
+	}
+
+	paceCar := driverInfo.Drivers[0]
+	if !paceCar.CarIsPaceCar || paceCar.LicColor.Raw != "0xundefined" {
+		t.Errorf("pace car: got %+v", paceCar)
+	}
+
+	driver := driverInfo.Drivers[1]
+	if driver.CarClassColor.RGB != 0xffda59 || driver.LicColor.RGB != 0x0153db {
+		t.Errorf("colors: got %v and %v", driver.CarClassColor, driver.LicColor)
+	}
+	if len(driver.CarDesignStr.Colors) != 3 {
+		t.Errorf("CarDesignStr: got %+v", driver.CarDesignStr)
+	}
+	if driver.CarClassMaxFuelPct.Value != 1 || driver.CarClassMaxFuelPct.Unit != "%" {
+		t.Errorf("CarClassMaxFuelPct: got %v", driver.CarClassMaxFuelPct)
+	}
+
+	if len(data.SplitTimeInfo.Sectors) != 3 {
+		t.Errorf("Sectors: got %+v", data.SplitTimeInfo.Sectors)
+	}
+	if data.CarSetup.UpdateCount != 3 {
+		t.Errorf("CarSetup.UpdateCount: got %d", data.CarSetup.UpdateCount)
+	}
+}
+
+func TestSessionDataRaw(t *testing.T) {
+	data := testSessionData(t)
+
+	weekend, ok := data.Raw["WeekendInfo"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Raw[WeekendInfo]: got %T", data.Raw["WeekendInfo"])
+	}
+	if weekend["TrackBrandNewField"] != 42 {
+		t.Errorf("unknown field: got %v", weekend["TrackBrandNewField"])
+	}
+
+	section, ok := data.Raw["BrandNewSection"].(map[string]interface{})
+	if !ok || section["Something"] != 1 {
+		t.Errorf("unknown section: got %v", data.Raw["BrandNewSection"])
+	}
+
+	drivers := data.Raw["DriverInfo"].(map[string]interface{})["Drivers"].([]interface{})
+	if drivers[1].(map[string]interface{})["CarBrandNewField"] != "new" {
+		t.Errorf("unknown driver field: got %v", drivers[1])
+	}
+}
@@ -0,0 +1,394 @@
+package irsdk
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	// SESSION_POLL_INTERVAL is how often SessionChanges checks
+	// SessionInfoUpdate
+	SESSION_POLL_INTERVAL = 100 * time.Millisecond
+	// DEFAULT_SESSION_CHANGES_BUFFER is the capacity of a SessionChanges
+	// channel
+	DEFAULT_SESSION_CHANGES_BUFFER = 4
+)
+
+// SessionChange is sent when the sim published a new session info revision
+type SessionChange struct {
+	// Update is the SessionInfoUpdate counter of Current
+	Update int
+	// Previous is nil for the first revision a watcher receives
+	Previous *SessionData
+	Current  *SessionData
+	Diff     *SessionDiff
+}
+
+// SessionDiff is the structural difference between two session info revisions
+type SessionDiff struct {
+	DriversJoined []Driver
+	DriversLeft   []Driver
+	// ResultsChanged lists the SessionNum of every session whose results
+	// changed
+	ResultsChanged []int
+	// WeatherChanged lists the weather fields that changed
+	WeatherChanged []FieldChange
+	// SessionsChanged is true when sessions were added or removed
+	SessionsChanged bool
+}
+
+// FieldChange is a single changed value
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Empty reports if nothing changed
+func (d *SessionDiff) Empty() bool {
+	return len(d.DriversJoined) == 0 &&
+		len(d.DriversLeft) == 0 &&
+		len(d.ResultsChanged) == 0 &&
+		len(d.WeatherChanged) == 0 &&
+		!d.SessionsChanged
+}
+
+// DiffSessionData compares two session info revisions. When old is nil every
+// driver counts as joined.
+func DiffSessionData(old, new *SessionData) *SessionDiff {
+	diff := &SessionDiff{}
+	if new == nil {
+		new = newSessionData()
+	}
+	if old == nil {
+		old = newSessionData()
+	}
+
+	diff.DriversJoined, diff.DriversLeft = diffDrivers(old.DriverInfo.Drivers, new.DriverInfo.Drivers)
+	diff.WeatherChanged = diffWeather(&old.WeekendInfo, &new.WeekendInfo)
+
+	oldSessions := old.SessionInfo.Sessions
+	newSessions := new.SessionInfo.Sessions
+	diff.SessionsChanged = len(oldSessions) != len(newSessions)
+	for _, ns := range newSessions {
+		found := false
+		for _, os := range oldSessions {
+			if os.SessionNum != ns.SessionNum {
+				continue
+			}
+
+			found = true
+			if !resultsEqual(&os, &ns) {
+				diff.ResultsChanged = append(diff.ResultsChanged, ns.SessionNum)
+			}
+			break
+		}
+
+		if !found {
+			diff.SessionsChanged = true
+			if len(ns.ResultsPositions) > 0 {
+				diff.ResultsChanged = append(diff.ResultsChanged, ns.SessionNum)
+			}
+		}
+	}
+
+	return diff
+}
+
+// diffDrivers matches drivers by CarIdx and UserID, so a driver swap in the
+// same car counts as one leaving and one joining
+func diffDrivers(old, new []Driver) ([]Driver, []Driver) {
+	type key struct {
+		carIdx int
+		userID int
+	}
+
+	oldKeys := make(map[key]bool, len(old))
+	for _, d := range old {
+		oldKeys[key{d.CarIdx, d.UserID}] = true
+	}
+	newKeys := make(map[key]bool, len(new))
+	for _, d := range new {
+		newKeys[key{d.CarIdx, d.UserID}] = true
+	}
+
+	joined := []Driver{}
+	for _, d := range new {
+		if !oldKeys[key{d.CarIdx, d.UserID}] {
+			joined = append(joined, d)
+		}
+	}
+
+	left := []Driver{}
+	for _, d := range old {
+		if !newKeys[key{d.CarIdx, d.UserID}] {
+			left = append(left, d)
+		}
+	}
+
+	return joined, left
+}
+
+func diffWeather(old, new *WeekendInfo) []FieldChange {
+	changes := []FieldChange{}
+	text := func(field, o, n string) {
+		if o != n {
+			changes = append(changes, FieldChange{Field: field, Old: o, New: n})
+		}
+	}
+	quantity := func(field string, o, n Quantity) {
+		if o != n {
+			changes = append(changes, FieldChange{Field: field, Old: o.String(), New: n.String()})
+		}
+	}
+
+	text("TrackWeatherType", old.TrackWeatherType, new.TrackWeatherType)
+	text("TrackSkies", old.TrackSkies, new.TrackSkies)
+	quantity("TrackSurfaceTemp", old.TrackSurfaceTemp, new.TrackSurfaceTemp)
+	quantity("TrackAirTemp", old.TrackAirTemp, new.TrackAirTemp)
+	quantity("TrackAirPressure", old.TrackAirPressure, new.TrackAirPressure)
+	quantity("TrackWindVel", old.TrackWindVel, new.TrackWindVel)
+	quantity("TrackWindDir", old.TrackWindDir, new.TrackWindDir)
+	quantity("TrackRelativeHumidity", old.TrackRelativeHumidity, new.TrackRelativeHumidity)
+	quantity("TrackFogLevel", old.TrackFogLevel, new.TrackFogLevel)
+
+	return changes
+}
+
+func resultsEqual(old, new *Session) bool {
+	return reflect.DeepEqual(old.ResultsPositions, new.ResultsPositions) &&
+		reflect.DeepEqual(old.ResultsFastestLap, new.ResultsFastestLap) &&
+		old.ResultsOfficial == new.ResultsOfficial &&
+		old.ResultsLapsComplete == new.ResultsLapsComplete &&
+		old.ResultsNumCautionFlags == new.ResultsNumCautionFlags &&
+		old.ResultsNumCautionLaps == new.ResultsNumCautionLaps &&
+		old.ResultsNumLeadChanges == new.ResultsNumLeadChanges
+}
+
+// sessionCache holds the parsed session info of the SessionInfoUpdate it was
+// parsed for
+type sessionCache struct {
+	mu       sync.Mutex
+	data     *SessionData
+	update   int32
+	loaded   bool
+	loading  bool
+	watchers []*sessionWatcher
+	watching bool
+
+	// generation is incremented when the cache is reset, so loads for the
+	// previous connection are dropped
+	generation int
+}
+
+type sessionWatcher struct {
+	ctx context.Context
+	ch  chan *SessionChange
+}
+
+// sessionInfoUpdate returns the SessionInfoUpdate counter of the source. The
+// caller must hold c.mu.
+func (c *Connection) sessionInfoUpdate() (int32, bool) {
+	header, err := c.src.Header()
+	if err != nil || header == nil {
+		return 0, false
+	}
+
+	return header.SessionInfoUpdate, true
+}
+
+// cachedSessionData returns the parsed session info if it's still current
+func (c *Connection) cachedSessionData(update int32) *SessionData {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	if c.session.loaded && c.session.update == update {
+		return c.session.data
+	}
+
+	return nil
+}
+
+// loadSessionData reads and parses the current session info and notifies the
+// watchers when it's a new revision
+func (c *Connection) loadSessionData() (*SessionData, error) {
+	c.session.mu.Lock()
+	generation := c.session.generation
+	c.session.mu.Unlock()
+
+	c.mu.Lock()
+	update, _ := c.sessionInfoUpdate()
+	b, err := c.getRawSessionData()
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return nil, ErrEmptySessionData
+	}
+
+	// Parse without holding any lock: this is the slow part
+	data, err := NewSessionDataFromBytes(bytesToUtf8(b))
+	if err != nil {
+		return nil, err
+	}
+
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	if generation != c.session.generation {
+		// Parsed for the previous connection
+		return data, nil
+	}
+	if c.session.loaded && c.session.update >= update {
+		// Someone else was faster, maybe even with a newer revision
+		return c.session.data, nil
+	}
+
+	change := &SessionChange{
+		Update:   int(update),
+		Previous: c.session.data,
+		Current:  data,
+		Diff:     DiffSessionData(c.session.data, data),
+	}
+
+	c.session.data = data
+	c.session.update = update
+	c.session.loaded = true
+
+	for _, w := range c.session.watchers {
+		w.send(change)
+	}
+
+	return data, nil
+}
+
+// resetSessionCache forgets the parsed session info, a new connection can
+// start counting SessionInfoUpdate from the beginning
+func (c *Connection) resetSessionCache() {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	c.session.loaded = false
+	c.session.generation = c.session.generation + 1
+}
+
+// refreshSessionData parses a new revision in the background, so the next
+// GetSessionData call doesn't have to
+func (c *Connection) refreshSessionData(update int32) {
+	c.session.mu.Lock()
+	if c.session.loading || (c.session.loaded && c.session.update == update) {
+		c.session.mu.Unlock()
+		return
+	}
+	c.session.loading = true
+	c.session.mu.Unlock()
+
+	go func() {
+		c.loadSessionData()
+
+		c.session.mu.Lock()
+		c.session.loading = false
+		c.session.mu.Unlock()
+	}()
+}
+
+// SessionChanges returns a channel that receives every new session info
+// revision with its diff to the previous one. The current revision (if any) is
+// sent first, with a nil Previous. The channel is closed when ctx is done.
+func (c *Connection) SessionChanges(ctx context.Context) <-chan *SessionChange {
+	w := &sessionWatcher{
+		ctx: ctx,
+		ch:  make(chan *SessionChange, DEFAULT_SESSION_CHANGES_BUFFER),
+	}
+
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	if c.session.loaded {
+		w.send(&SessionChange{
+			Update:  int(c.session.update),
+			Current: c.session.data,
+			Diff:    DiffSessionData(nil, c.session.data),
+		})
+	}
+
+	c.session.watchers = append(c.session.watchers, w)
+	if !c.session.watching {
+		c.session.watching = true
+		go c.watchSession()
+	}
+
+	return w.ch
+}
+
+// watchSession polls SessionInfoUpdate while there are watchers
+func (c *Connection) watchSession() {
+	ticker := time.NewTicker(SESSION_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if !c.pruneSessionWatchers() {
+			return
+		}
+
+		c.mu.Lock()
+		update, ok := c.sessionInfoUpdate()
+		c.mu.Unlock()
+
+		if ok && c.cachedSessionData(update) == nil {
+			c.loadSessionData()
+		}
+
+		<-ticker.C
+	}
+}
+
+// pruneSessionWatchers closes the channels of watchers whose context is done.
+// It returns false (and stops watching) when no watchers are left.
+func (c *Connection) pruneSessionWatchers() bool {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	active := c.session.watchers[:0]
+	for _, w := range c.session.watchers {
+		if w.ctx.Err() != nil {
+			close(w.ch)
+			continue
+		}
+		active = append(active, w)
+	}
+	c.session.watchers = active
+
+	if len(active) == 0 {
+		c.session.watchers = nil
+		c.session.watching = false
+		return false
+	}
+
+	return true
+}
+
+// send delivers a change without blocking; when the watcher doesn't keep up the
+// oldest change is dropped
+func (w *sessionWatcher) send(change *SessionChange) {
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	for {
+		select {
+		case w.ch <- change:
+			return
+		default:
+		}
+
+		select {
+		case <-w.ch:
+		default:
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package irsdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leonb/irsdk-go/fakesim"
+)
+
+func TestDiffSessionData(t *testing.T) {
+	old := newSessionData()
+	old.DriverInfo.Drivers = []Driver{{CarIdx: 0, UserName: "A"}, {CarIdx: 1, UserName: "B"}}
+	new := newSessionData()
+	new.DriverInfo.Drivers = []Driver{{CarIdx: 0, UserName: "A"}, {CarIdx: 2, UserName: "C"}}
+
+	diff := DiffSessionData(old, new)
+	if len(diff.DriversJoined) != 1 || diff.DriversJoined[0].UserName != "C" {
+		t.Errorf("DriversJoined: got %+v", diff.DriversJoined)
+	}
+	if len(diff.DriversLeft) != 1 || diff.DriversLeft[0].UserName != "B" {
+		t.Errorf("DriversLeft: got %+v", diff.DriversLeft)
+	}
+
+	old.WeekendInfo.TrackSkies = "Clear"
+	old.WeekendInfo.TrackAirTemp = Quantity{Value: 20.5, Unit: "C"}
+	new.WeekendInfo.TrackSkies = "Overcast"
+	new.WeekendInfo.TrackAirTemp = Quantity{Value: 18, Unit: "C"}
+	new.WeekendInfo.TrackFogLevel = Quantity{Value: 0, Unit: "%"}
+	want := []FieldChange{
+		{Field: "TrackSkies", Old: "Clear", New: "Overcast"},
+		{Field: "TrackAirTemp", Old: "20.5 C", New: "18 C"},
+		{Field: "TrackFogLevel", Old: "0", New: "0 %"},
+	}
+	diff = DiffSessionData(old, new)
+	if len(diff.WeatherChanged) != len(want) {
+		t.Fatalf("WeatherChanged: got %+v, want %+v", diff.WeatherChanged, want)
+	}
+	for i := range want {
+		if diff.WeatherChanged[i] != want[i] {
+			t.Errorf("WeatherChanged[%d]: got %+v, want %+v", i, diff.WeatherChanged[i], want[i])
+		}
+	}
+
+	if !DiffSessionData(new, new).Empty() {
+		t.Error("diff of a revision with itself isn't empty")
+	}
+	if len(DiffSessionData(nil, new).DriversJoined) != 2 {
+		t.Error("without a previous revision every driver should have joined")
+	}
+}
+
+func TestSessionChanges(t *testing.T) {
+	p, conn := newFakeConnection(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := conn.SessionChanges(ctx)
+
+	first := <-changes
+	if first.Previous != nil || len(first.Diff.DriversJoined) != fakesim.DEFAULT_NUMCARS {
+		t.Fatalf("first change: got %+v", first.Diff)
+	}
+
+	err := p.SetSessionInfo([]byte(fakesim.DefaultSessionInfo + "WeekendInfo:\n TrackSkies: Overcast\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second := <-changes
+	if second.Update != first.Update+1 || second.Previous != first.Current {
+		t.Errorf("second change: got update %d after %d", second.Update, first.Update)
+	}
+}
+
+func TestSessionCacheNeverGoesBack(t *testing.T) {
+	p, conn := newFakeConnection(t)
+
+	data, err := conn.GetSessionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A load of a newer revision finished before this one
+	newer := newSessionData()
+	conn.session.mu.Lock()
+	update := conn.session.update
+	conn.session.data = newer
+	conn.session.update = update + 1
+	conn.session.mu.Unlock()
+
+	loaded, err := conn.loadSessionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != newer || conn.session.update != update+1 {
+		t.Errorf("cache went back to update %d", conn.session.update)
+	}
+
+	// After reconnecting the counter may start over
+	err = conn.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Tick()
+	loaded, err = conn.GetSessionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == newer || loaded == data || conn.session.update != update {
+		t.Errorf("got update %d after reconnecting, want %d", conn.session.update, update)
+	}
+}
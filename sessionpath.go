@@ -0,0 +1,214 @@
+package irsdk
+
+import (
+	"bytes"
+	"errors"
+)
+
+var (
+	ErrSessionPathNotFound = errors.New("Session info path not found")
+)
+
+type yamlState int
+
+const (
+	yamlSpace yamlState = iota
+	yamlKey
+	yamlKeySep
+	yamlValue
+	yamlNewline
+)
+
+// SessionStrVal looks up path in session info YAML the way irsdk_getSessionStrVal
+// of the C++ SDK does, so the same paths can be used:
+//
+//	WeekendInfo:TrackName:
+//	DriverInfo:Drivers:CarIdx:{5}UserName:
+//	SessionInfo:Sessions:SessionNum:{0}ResultsPositions:Position:{1}CarIdx:
+//
+// Every key ends with a colon. A value in braces after a key selects the list
+// entry whose key has that value. The value is returned as written, without
+// parsing it.
+func SessionStrVal(yamlData []byte, path string) (string, bool) {
+	// Ignore the NUL padding after the session info
+	if i := bytes.IndexByte(yamlData, 0); i >= 0 {
+		yamlData = yamlData[:i]
+	}
+
+	depth := 0
+	state := yamlSpace
+
+	keyStart, keyLen := 0, 0
+	valueStart, valueLen := 0, 0
+
+	pathPos := 0
+	pathDepth := 0
+	// selected is set when the last matched key selected a list entry, so
+	// the keys next to it belong to the block
+	selected := false
+	// entry is set when the line starts a list entry ("- ")
+	entry := false
+
+	// endOfLine checks the line that just ended against the rest of the path
+	endOfLine := func() (string, bool, bool) {
+		if depth < pathDepth {
+			// Left the block of the last matched key
+			return "", false, true
+		}
+
+		// Unlike the C++ SDK the keys after the block of the last matched key
+		// (or list entry) aren't searched
+		if pathPos > 0 && depth == pathDepth && keyLen > 0 && (!selected || entry) {
+			return "", false, true
+		}
+
+		if keyLen == 0 || !hasPrefixAt(path, pathPos, yamlData[keyStart:keyStart+keyLen]) {
+			return "", false, false
+		}
+
+		next := pathPos + keyLen
+		selector := next < len(path) && path[next] == '{'
+		if selector {
+			end := next + 1
+			for end < len(path) && path[end] != '}' {
+				end++
+			}
+
+			want := path[next+1 : end]
+			if string(yamlData[valueStart:valueStart+valueLen]) != want {
+				return "", false, false
+			}
+			next = end + 1
+		}
+
+		pathPos = next
+		pathDepth = depth
+		selected = selector
+		if pathPos >= len(path) {
+			value := bytes.TrimRight(yamlData[valueStart:valueStart+valueLen], " ")
+			return string(value), true, true
+		}
+
+		return "", false, false
+	}
+
+	for i := 0; i <= len(yamlData); i++ {
+		c := byte('\n')
+		if i < len(yamlData) {
+			c = yamlData[i]
+		}
+
+		switch c {
+		case ' ', '-':
+			if state == yamlNewline {
+				state = yamlSpace
+			}
+			switch state {
+			case yamlSpace:
+				depth++
+				if c == '-' {
+					entry = true
+				}
+			case yamlKey:
+				keyLen++
+			case yamlKeySep:
+				// Unlike the C++ SDK negative values keep their sign
+				if c == '-' {
+					state = yamlValue
+					valueStart, valueLen = i, 1
+				}
+			case yamlValue:
+				valueLen++
+			}
+		case ':':
+			switch state {
+			case yamlKey:
+				state = yamlKeySep
+				keyLen++
+			case yamlKeySep:
+				state = yamlValue
+				valueStart, valueLen = i, 1
+			case yamlValue:
+				valueLen++
+			}
+		case '\n', '\r':
+			if state != yamlNewline {
+				value, found, done := endOfLine()
+				if done {
+					return value, found
+				}
+
+				depth = 0
+				entry = false
+				keyLen = 0
+				valueStart, valueLen = 0, 0
+			}
+			state = yamlNewline
+		default:
+			switch state {
+			case yamlSpace, yamlNewline:
+				state = yamlKey
+				keyStart, keyLen = i, 0
+			case yamlKeySep:
+				state = yamlValue
+				valueStart, valueLen = i, 0
+			}
+			if state == yamlKey {
+				keyLen++
+			}
+			if state == yamlValue {
+				valueLen++
+			}
+		}
+	}
+
+	return "", false
+}
+
+func hasPrefixAt(path string, pos int, prefix []byte) bool {
+	if pos+len(prefix) > len(path) {
+		return false
+	}
+
+	return path[pos:pos+len(prefix)] == string(prefix)
+}
+
+// GetSessionStrVal looks up an irsdk_getSessionStrVal path (see SessionStrVal)
+// in the current session info
+func (c *Connection) GetSessionStrVal(path string) (string, error) {
+	b, err := c.GetRawSessionData()
+	if err != nil {
+		return "", err
+	}
+
+	if b == nil {
+		return "", ErrEmptySessionData
+	}
+
+	value, ok := SessionStrVal(bytesToUtf8(b), path)
+	if !ok {
+		return "", ErrSessionPathNotFound
+	}
+
+	return value, nil
+}
+
+// GetSessionStrVal looks up an irsdk_getSessionStrVal path (see SessionStrVal)
+// in the session info of the file
+func (tr *TelemetryReader) GetSessionStrVal(path string) (string, error) {
+	b, err := tr.ReadRawSessionData()
+	if err != nil {
+		return "", err
+	}
+
+	if b == nil {
+		return "", ErrEmptySessionData
+	}
+
+	value, ok := SessionStrVal(bytesToUtf8(b), path)
+	if !ok {
+		return "", ErrSessionPathNotFound
+	}
+
+	return value, nil
+}
@@ -0,0 +1,65 @@
+package irsdk
+
+import "testing"
+
+func TestSessionStrVal(t *testing.T) {
+	yaml := []byte(testSessionYAML + "\x00\x00\x00")
+
+	tests := []struct {
+		path  string
+		value string
+	}{
+		{"WeekendInfo:TrackName:", "spa up"},
+		{"WeekendInfo:WeekendOptions:NumStarters:", "3"},
+		{"WeekendInfo:TelemetryOptions:TelemetryDiskFile:", `""`},
+		{"DriverInfo:Drivers:CarIdx:{2}UserName:", "Jane Doe"},
+		{"DriverInfo:Drivers:CarIdx:{0}LicColor:", "0xundefined"},
+		{"SessionInfo:Sessions:SessionNum:{1}SessionLaps:", "12"},
+		{"SessionInfo:Sessions:SessionNum:{1}ResultsPositions:Position:{1}CarIdx:", "1"},
+		{"CameraInfo:Groups:GroupNum:{10}GroupName:", "TV1"},
+		{"CarSetup:Chassis:LeftFront:CornerWeight:", "4511 N"},
+	}
+
+	for _, test := range tests {
+		value, ok := SessionStrVal(yaml, test.path)
+		if !ok || value != test.value {
+			t.Errorf("%s: got %q, %v, want %q", test.path, value, ok, test.value)
+		}
+	}
+}
+
+func TestSessionStrValNotFound(t *testing.T) {
+	yaml := []byte(testSessionYAML)
+
+	for _, path := range []string{
+		"WeekendInfo:TrackNameX:",
+		"WeekendInfo:TrackLength:Unit:",
+		"DriverInfo:Drivers:CarIdx:{7}UserName:",
+		// Keys of other blocks don't match
+		"SessionInfo:TrackName:",
+		"CarSetup:Chassis:Front:CornerWeight:",
+		// Nor the keys of the next list entry
+		"DriverInfo:Drivers:CarIdx:{0}CarClassShortName:",
+	} {
+		if value, ok := SessionStrVal(yaml, path); ok {
+			t.Errorf("%s: got %q", path, value)
+		}
+	}
+}
+
+func TestConnectionGetSessionStrVal(t *testing.T) {
+	_, conn := newFakeConnection(t)
+
+	value, err := conn.GetSessionStrVal("DriverInfo:Drivers:CarIdx:{0}UserName:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "Fake Driver" {
+		t.Errorf("got %q", value)
+	}
+
+	_, err = conn.GetSessionStrVal("WeekendInfo:NoSuchKey:")
+	if err != ErrSessionPathNotFound {
+		t.Errorf("got %v, want ErrSessionPathNotFound", err)
+	}
+}
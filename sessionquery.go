@@ -0,0 +1,156 @@
+package irsdk
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const UNLIMITED = "unlimited"
+
+// LapLimit is the SessionLaps of a session: a number of laps or unlimited
+type LapLimit struct {
+	Laps      int
+	Unlimited bool
+}
+
+func (l *LapLimit) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	err := unmarshal(&s)
+	if err != nil {
+		return err
+	}
+
+	s = strings.TrimSpace(s)
+	if s == UNLIMITED {
+		*l = LapLimit{Unlimited: true}
+		return nil
+	}
+
+	laps, err := strconv.Atoi(s)
+	if err != nil {
+		return &valueError{Value: s, Err: err}
+	}
+
+	*l = LapLimit{Laps: laps}
+	return nil
+}
+
+func (l LapLimit) String() string {
+	if l.Unlimited {
+		return UNLIMITED
+	}
+
+	return strconv.Itoa(l.Laps)
+}
+
+// TimeLimit is the SessionTime of a session ("1800.0000 sec"): a duration or
+// unlimited
+type TimeLimit struct {
+	Duration  time.Duration
+	Unlimited bool
+}
+
+func (t *TimeLimit) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	err := unmarshal(&s)
+	if err != nil {
+		return err
+	}
+
+	s = strings.TrimSpace(s)
+	if s == UNLIMITED {
+		*t = TimeLimit{Unlimited: true}
+		return nil
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSuffix(s, " sec"), 64)
+	if err != nil {
+		return &valueError{Value: s, Err: err}
+	}
+
+	*t = TimeLimit{Duration: time.Duration(seconds * float64(time.Second))}
+	return nil
+}
+
+func (t TimeLimit) String() string {
+	if t.Unlimited {
+		return UNLIMITED
+	}
+
+	return t.Duration.String()
+}
+
+// DriverByCarIdx returns the driver of the car with index carIdx (the index
+// into the CarIdx* telemetry arrays) or nil
+func (s *SessionData) DriverByCarIdx(carIdx int) *Driver {
+	for i := range s.DriverInfo.Drivers {
+		if s.DriverInfo.Drivers[i].CarIdx == carIdx {
+			return &s.DriverInfo.Drivers[i]
+		}
+	}
+
+	return nil
+}
+
+// PlayerDriver returns the driver the sim runs for (DriverInfo.DriverCarIdx)
+func (s *SessionData) PlayerDriver() *Driver {
+	return s.DriverByCarIdx(s.DriverInfo.DriverCarIdx)
+}
+
+// CurrentSession returns the session with SessionNum sessionNum (the
+// SessionNum telemetry variable) or nil
+func (s *SessionData) CurrentSession(sessionNum int) *Session {
+	for i := range s.SessionInfo.Sessions {
+		if s.SessionInfo.Sessions[i].SessionNum == sessionNum {
+			return &s.SessionInfo.Sessions[i]
+		}
+	}
+
+	return nil
+}
+
+// DriversByClass groups the drivers by CarClassID. Spectators and the pace car
+// are left out.
+func (s *SessionData) DriversByClass() map[int][]*Driver {
+	classes := map[int][]*Driver{}
+	for i := range s.DriverInfo.Drivers {
+		d := &s.DriverInfo.Drivers[i]
+		if d.IsSpectator || d.CarIsPaceCar {
+			continue
+		}
+
+		classes[d.CarClassID] = append(classes[d.CarClassID], d)
+	}
+
+	return classes
+}
+
+// CameraByName returns the camera group called name (case insensitive) or nil
+func (s *SessionData) CameraByName(name string) *CameraGroup {
+	for i := range s.CameraInfo.Groups {
+		if strings.EqualFold(s.CameraInfo.Groups[i].GroupName, name) {
+			return &s.CameraInfo.Groups[i]
+		}
+	}
+
+	return nil
+}
+
+// SectorForPct returns the sector containing lap distance pct (0-1, the
+// LapDistPct telemetry variable) or nil when the track has no sectors
+func (s *SessionData) SectorForPct(pct float32) *Sector {
+	var sector *Sector
+	for i := range s.SplitTimeInfo.Sectors {
+		candidate := &s.SplitTimeInfo.Sectors[i]
+		if candidate.SectorStartPct > pct {
+			continue
+		}
+
+		if sector == nil || candidate.SectorStartPct > sector.SectorStartPct {
+			sector = candidate
+		}
+	}
+
+	return sector
+}
@@ -0,0 +1,94 @@
+package irsdk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionLimits(t *testing.T) {
+	data := testSessionData(t)
+
+	qualify := data.CurrentSession(0)
+	if qualify == nil || qualify.SessionName != "QUALIFY" {
+		t.Fatalf("CurrentSession(0): got %+v", qualify)
+	}
+	if !qualify.SessionLaps.Unlimited || qualify.SessionLaps.String() != UNLIMITED {
+		t.Errorf("SessionLaps: got %+v", qualify.SessionLaps)
+	}
+	if qualify.SessionTime.Unlimited || qualify.SessionTime.Duration != 20*time.Minute {
+		t.Errorf("SessionTime: got %+v", qualify.SessionTime)
+	}
+
+	race := data.CurrentSession(1)
+	if race.SessionLaps.Unlimited || race.SessionLaps.Laps != 12 || race.SessionLaps.String() != "12" {
+		t.Errorf("SessionLaps: got %+v", race.SessionLaps)
+	}
+	if !race.SessionTime.Unlimited {
+		t.Errorf("SessionTime: got %+v", race.SessionTime)
+	}
+
+	if data.CurrentSession(2) != nil {
+		t.Error("CurrentSession(2) isn't nil")
+	}
+}
+
+func TestDriverLookups(t *testing.T) {
+	data := testSessionData(t)
+
+	if d := data.DriverByCarIdx(2); d == nil || d.UserName != "Jane Doe" {
+		t.Errorf("DriverByCarIdx(2): got %+v", d)
+	}
+	if d := data.DriverByCarIdx(63); d != nil {
+		t.Errorf("DriverByCarIdx(63): got %+v", d)
+	}
+	if d := data.PlayerDriver(); d == nil || d.UserID != 100 {
+		t.Errorf("PlayerDriver: got %+v", d)
+	}
+
+	// Without the pace car and the spectator
+	classes := data.DriversByClass()
+	if len(classes) != 2 || len(classes[4029]) != 1 || len(classes[4084]) != 1 {
+		t.Errorf("DriversByClass: got %v", classes)
+	}
+	if classes[4029][0].CarIdx != 1 {
+		t.Errorf("DriversByClass: got car %d for class 4029", classes[4029][0].CarIdx)
+	}
+}
+
+func TestCameraByName(t *testing.T) {
+	data := testSessionData(t)
+
+	if g := data.CameraByName("tv1"); g == nil || g.GroupNum != 10 {
+		t.Errorf("CameraByName(tv1): got %+v", g)
+	}
+	if g := data.CameraByName("Blimp"); g != nil {
+		t.Errorf("CameraByName(Blimp): got %+v", g)
+	}
+}
+
+func TestSectorForPct(t *testing.T) {
+	data := testSessionData(t)
+
+	tests := []struct {
+		pct    float32
+		sector int
+	}{
+		{0, 0},
+		{0.2, 0},
+		{0.334, 1},
+		{0.5, 1},
+		{0.99, 2},
+	}
+
+	for _, test := range tests {
+		s := data.SectorForPct(test.pct)
+		if s == nil || s.SectorNum != test.sector {
+			t.Errorf("SectorForPct(%v): got %+v, want sector %d", test.pct, s, test.sector)
+		}
+	}
+
+	empty := &SessionData{}
+	if s := empty.SectorForPct(0.5); s != nil {
+		t.Errorf("SectorForPct without sectors: got %+v", s)
+	}
+}
@@ -0,0 +1,175 @@
+package irsdk
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// freeTextKeys are written unquoted by the sim although they contain whatever
+// the user typed (colons, #, leading quotes, ...)
+var freeTextKeys = map[string]bool{
+	"UserName":              true,
+	"AbbrevName":            true,
+	"Initials":              true,
+	"TeamName":              true,
+	"CarNumber":             true,
+	"ClubName":              true,
+	"DivisionName":          true,
+	"FrequencyName":         true,
+	"GroupName":             true,
+	"CameraName":            true,
+	"SessionName":           true,
+	"DriverSetupName":       true,
+	"CarScreenName":         true,
+	"CarScreenNameShort":    true,
+	"CarClassShortName":     true,
+	"TrackDisplayName":      true,
+	"TrackDisplayShortName": true,
+	"TrackConfigName":       true,
+	"TrackCity":             true,
+	"TrackState":            true,
+	"TrackCountry":          true,
+	"ReasonOutStr":          true,
+	"LicString":             true,
+}
+
+var sessionLineRegexp = regexp.MustCompile(`^(\s*(?:- )?)([A-Za-z0-9_]+): (.*)$`)
+var yamlLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// SessionDataError is returned when the session info can't be parsed
+type SessionDataError struct {
+	// Line and Column (both 1-based) point at the value that couldn't be
+	// parsed. Column is 0 when unknown.
+	Line   int
+	Column int
+	// Text is the offending line
+	Text string
+	// Err is the error of the YAML parser
+	Err error
+}
+
+func (e *SessionDataError) Error() string {
+	return fmt.Sprintf("Invalid session info at line %d, column %d (%q): %v", e.Line, e.Column, e.Text, e.Err)
+}
+
+func (e *SessionDataError) Unwrap() error {
+	return e.Err
+}
+
+// sanitizeSessionYAML works around the quirks of the YAML the sim writes: free
+// text values are quoted and the trailing NUL padding is dropped. Lines are
+// never added or removed, so line numbers of errors match the original.
+func sanitizeSessionYAML(b []byte) []byte {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+
+	lines := bytes.Split(b, []byte("\n"))
+	for i, line := range lines {
+		m := sessionLineRegexp.FindSubmatch(bytes.TrimRight(line, "\r"))
+		if m == nil || !freeTextKeys[string(m[2])] {
+			continue
+		}
+
+		value := string(m[3])
+		if value == "" || isQuoted(value) {
+			continue
+		}
+
+		quoted := "'" + strings.Replace(value, "'", "''", -1) + "'"
+		lines[i] = []byte(string(m[1]) + string(m[2]) + ": " + quoted)
+	}
+
+	return bytes.Join(lines, []byte("\n"))
+}
+
+func isQuoted(value string) bool {
+	if len(value) < 2 {
+		return false
+	}
+
+	first := value[0]
+	last := value[len(value)-1]
+	return (first == '\'' || first == '"') && first == last
+}
+
+// valueError is returned by the unmarshalers of the session info types. The
+// YAML parser doesn't tell them where the value is, so unmarshalSessionYAML
+// looks it up.
+type valueError struct {
+	Value string
+	Err   error
+}
+
+func (e *valueError) Error() string {
+	return fmt.Sprintf("%v: %q", e.Err, e.Value)
+}
+
+// unmarshalSessionYAML unmarshals sanitized session info into v and turns
+// errors into a *SessionDataError
+func unmarshalSessionYAML(original, sanitized []byte, v interface{}) error {
+	err := yaml.Unmarshal(sanitized, v)
+	if err == nil {
+		return nil
+	}
+
+	sdErr := &SessionDataError{Err: err}
+	lines := bytes.Split(original, []byte("\n"))
+	if vErr, ok := err.(*valueError); ok {
+		sdErr.Err = vErr.Err
+		sdErr.Line = findValueLine(lines, vErr.Value)
+	} else {
+		msg := err.Error()
+		if typeErr, ok := err.(*yaml.TypeError); ok && len(typeErr.Errors) > 0 {
+			msg = typeErr.Errors[0]
+		}
+
+		m := yamlLineRegexp.FindStringSubmatch(msg)
+		if m == nil {
+			return sdErr
+		}
+		sdErr.Line, _ = strconv.Atoi(m[1])
+	}
+
+	if sdErr.Line < 1 || sdErr.Line > len(lines) {
+		sdErr.Line = 0
+		return sdErr
+	}
+
+	text := string(bytes.TrimRight(lines[sdErr.Line-1], "\r"))
+	sdErr.Text = text
+	if lm := sessionLineRegexp.FindStringSubmatchIndex(text); lm != nil {
+		sdErr.Column = lm[6] + 1
+	} else {
+		sdErr.Column = len(text) - len(strings.TrimLeft(text, " -")) + 1
+	}
+
+	return sdErr
+}
+
+// findValueLine returns the (1-based) line of the first key with value, or 0.
+// Free text values are skipped, they're never parsed any further.
+func findValueLine(lines [][]byte, value string) int {
+	value = strings.TrimSpace(value)
+	for i, line := range lines {
+		m := sessionLineRegexp.FindSubmatch(bytes.TrimRight(line, "\r"))
+		if m == nil || freeTextKeys[string(m[2])] {
+			continue
+		}
+
+		v := strings.TrimSpace(string(m[3]))
+		if isQuoted(v) {
+			v = v[1 : len(v)-1]
+		}
+		if v == value {
+			return i + 1
+		}
+	}
+
+	return 0
+}
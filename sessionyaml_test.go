@@ -0,0 +1,50 @@
+package irsdk
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSanitizeSessionYAML(t *testing.T) {
+	yaml := "---\nDriverInfo:\n Drivers:\n - CarIdx: 0\n   UserName: 'Quote: #1\n   TeamName: A's team\n...\n\x00\x00\x00"
+
+	data, err := NewSessionDataFromBytes([]byte(yaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	driver := data.DriverInfo.Drivers[0]
+	if driver.UserName != "'Quote: #1" || driver.TeamName != "A's team" {
+		t.Errorf("got %q and %q", driver.UserName, driver.TeamName)
+	}
+}
+
+func TestSessionDataErrorLine(t *testing.T) {
+	tests := []struct {
+		yaml   string
+		line   int
+		column int
+	}{
+		// An error of the YAML parser
+		{"---\nWeekendInfo:\n TrackID: abc\n...\n", 3, 11},
+		// An error of an unmarshaler
+		{"---\nSessionInfo:\n Sessions:\n - SessionNum: 0\n   SessionLaps: 10\n - SessionNum: 1\n   SessionLaps: lots\n...\n", 7, 17},
+		{"---\nSessionInfo:\n Sessions:\n - SessionNum: 0\n   SessionTime: forever sec\n...\n", 5, 17},
+	}
+
+	for _, test := range tests {
+		_, err := NewSessionDataFromBytes([]byte(test.yaml))
+		sdErr, ok := err.(*SessionDataError)
+		if !ok {
+			t.Fatalf("got %v, want a *SessionDataError", err)
+		}
+		if sdErr.Line != test.line || sdErr.Column != test.column {
+			t.Errorf("%v: got line %d, column %d, want line %d, column %d", sdErr, sdErr.Line, sdErr.Column, test.line, test.column)
+		}
+	}
+
+	_, err := NewSessionDataFromBytes([]byte(tests[1].yaml))
+	if _, ok := err.(*SessionDataError).Err.(*strconv.NumError); !ok {
+		t.Errorf("Err: got %T, want *strconv.NumError", err.(*SessionDataError).Err)
+	}
+}
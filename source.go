@@ -0,0 +1,128 @@
+package irsdk
+
+import (
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// Source is everything a Connection needs to read telemetry. Implementations
+// exist for the live shared memory, .ibt files and network streams so the
+// same code can run against a live sim or a file.
+type Source interface {
+	// Open prepares the source for reading
+	Open() error
+	// Close releases everything acquired by Open
+	Close() error
+	// IsConnected reports if the source is (still) delivering data
+	IsConnected() bool
+
+	// Header returns the main header describing the data layout
+	Header() (*utils.Header, error)
+	// VarHeaders returns the description of every variable in a var buffer
+	VarHeaders() ([]*utils.VarHeader, error)
+	// SessionInfo returns the raw session info (YAML) string
+	SessionInfo() ([]byte, error)
+
+	// NextVarBuf returns the next var buffer without blocking. When nothing
+	// changed it returns utils.ErrNothingChanged.
+	NextVarBuf() ([]byte, error)
+	// WaitForTick waits at most timeOut for a new var buffer
+	WaitForTick(timeOut time.Duration) ([]byte, error)
+}
+
+// tickCounter is implemented by sources that know the exact tick count of the
+// var buffer they returned last
+type tickCounter interface {
+	LastTickCount() int32
+}
+
+// liveSource reads the shared memory of a running sim
+type liveSource struct {
+	sdk *utils.Irsdk
+}
+
+// NewLiveSource creates a Source for the shared memory of a running iRacing
+// instance
+func NewLiveSource() (Source, error) {
+	sdk, err := utils.NewIrsdk()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLiveSourceFromSdk(sdk), nil
+}
+
+// NewMmapSource creates a Source that maps the shared memory directly from path
+// and polls it for new data, without syscalls through the ir-syscalls-rpc
+// helper. When path is empty the shared memory of the sim itself is mapped.
+// When poll is nil utils.DefaultPollStrategy is used.
+func NewMmapSource(path string, poll utils.PollStrategy) Source {
+	return NewLiveSourceFromSdk(utils.NewMmapIrsdk(path, poll))
+}
+
+// NewLiveSourceFromSdk wraps an already created Irsdk as a Source
+func NewLiveSourceFromSdk(sdk *utils.Irsdk) Source {
+	return &liveSource{sdk: sdk}
+}
+
+func (s *liveSource) Open() error {
+	return s.sdk.Startup()
+}
+
+func (s *liveSource) Close() error {
+	s.sdk.Shutdown()
+	return nil
+}
+
+func (s *liveSource) IsConnected() bool {
+	return s.sdk.IsConnected()
+}
+
+func (s *liveSource) Header() (*utils.Header, error) {
+	return s.sdk.GetHeader()
+}
+
+func (s *liveSource) VarHeaders() ([]*utils.VarHeader, error) {
+	header, err := s.sdk.GetHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	if header == nil {
+		return nil, nil
+	}
+
+	numVars := s.sdk.GetNumVars()
+	varHeaders := make([]*utils.VarHeader, 0, numVars)
+	for i := 0; i < numVars; i++ {
+		varHeader, err := s.sdk.GetVarHeaderEntry(i)
+		if err != nil {
+			return nil, err
+		}
+
+		if varHeader == nil {
+			continue
+		}
+
+		varHeaders = append(varHeaders, varHeader)
+	}
+
+	return varHeaders, nil
+}
+
+func (s *liveSource) SessionInfo() ([]byte, error) {
+	return s.sdk.GetSessionInfoStr(), nil
+}
+
+func (s *liveSource) NextVarBuf() ([]byte, error) {
+	return s.sdk.GetNewData()
+}
+
+func (s *liveSource) LastTickCount() int32 {
+	return s.sdk.GetLastTickCount()
+}
+
+func (s *liveSource) WaitForTick(timeOut time.Duration) ([]byte, error) {
+	return s.sdk.WaitForDataReady(timeOut)
+}
@@ -0,0 +1,273 @@
+package irsdk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// BackpressurePolicy decides what happens to new frames when a subscriber
+// doesn't keep up
+type BackpressurePolicy int
+
+const (
+	// DropOldest discards the oldest buffered frame to make room for the new
+	// one
+	DropOldest BackpressurePolicy = iota
+	// Block waits until the subscriber has room. This also stalls every other
+	// subscriber of the connection.
+	Block
+	// LatestOnly keeps only the newest frame; Buffer is ignored
+	LatestOnly
+)
+
+const (
+	DEFAULT_SUBSCRIBE_BUFFER = 16
+)
+
+var (
+	ErrUnknownPolicy = errors.New("Unknown backpressure policy")
+)
+
+// SubscribeOptions configures a subscription
+type SubscribeOptions struct {
+	// Rate is the number of frames per second the subscriber wants. Ticks are
+	// decimated to approximate it; 0 (or a rate above the tick rate of the
+	// sim) delivers every tick.
+	Rate int
+	// Fields limits the variables readable from the delivered frames. When
+	// empty every variable is available.
+	Fields []string
+	// Buffer is the capacity of the channel (default
+	// DEFAULT_SUBSCRIBE_BUFFER)
+	Buffer int
+	// Policy decides what happens when the buffer is full
+	Policy BackpressurePolicy
+}
+
+type subscriber struct {
+	ctx  context.Context
+	opts SubscribeOptions
+	ch   chan *Frame
+
+	// schema is the (filtered) schema for base
+	base   *Schema
+	schema *Schema
+
+	// lastTick is the tick of the last delivered frame, -1 when nothing was
+	// delivered yet
+	lastTick int64
+}
+
+// Subscribe returns a channel that receives frames until ctx is done or the
+// source ends (io.EOF), after which the channel is closed. All subscribers of a
+// connection share a single reader, so every subscriber sees the same ticks at
+// its own rate.
+func (c *Connection) Subscribe(ctx context.Context, opts SubscribeOptions) (<-chan *Frame, error) {
+	switch opts.Policy {
+	case DropOldest, Block:
+		if opts.Buffer <= 0 {
+			opts.Buffer = DEFAULT_SUBSCRIBE_BUFFER
+		}
+	case LatestOnly:
+		opts.Buffer = 1
+	default:
+		return nil, ErrUnknownPolicy
+	}
+
+	sub := &subscriber{
+		ctx:      ctx,
+		opts:     opts,
+		ch:       make(chan *Frame, opts.Buffer),
+		lastTick: -1,
+	}
+
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	c.subs = append(c.subs, sub)
+	if !c.pumping {
+		c.pumping = true
+		go c.pump()
+	}
+
+	return sub.ch, nil
+}
+
+// pump reads frames from the source and hands them to the subscribers until
+// there are no subscribers left
+func (c *Connection) pump() {
+	seq := int64(0)
+
+	for {
+		subs := c.activeSubscribers()
+		if subs == nil {
+			return
+		}
+
+		frame, tickRate, update, err := c.nextFrame()
+		if err == io.EOF {
+			c.closeSubscribers()
+			return
+		}
+		if err == utils.ErrNothingChanged || err == utils.ErrDataChanged {
+			// The source already waited for the tick
+			continue
+		}
+		if err != nil {
+			// Don't spin when the source fails right away (sim not running)
+			time.Sleep(c.timeout)
+			continue
+		}
+		if frame == nil {
+			continue
+		}
+
+		// New session info is parsed in the background, not here
+		if c.cachedSessionData(update) == nil {
+			c.refreshSessionData(update)
+		}
+
+		seq = seq + 1
+		tick := seq
+		if frame.TickCount > 0 {
+			tick = int64(frame.TickCount)
+		}
+
+		for _, sub := range subs {
+			if !sub.due(tick, tickRate) {
+				continue
+			}
+
+			sub.deliver(frame)
+		}
+	}
+}
+
+// nextFrame waits for the next tick and returns it with the tick rate and
+// SessionInfoUpdate of the source
+func (c *Connection) nextFrame() (*Frame, int, int32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := c.src.WaitForTick(c.timeout)
+	c.noteError(err)
+	if err != nil || data == nil {
+		return nil, 0, 0, err
+	}
+
+	schema, err := c.getSchema()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	tickRate := 0
+	update := int32(0)
+	header, err := c.src.Header()
+	if err == nil && header != nil {
+		tickRate = int(header.TickRate)
+		update = header.SessionInfoUpdate
+	}
+
+	frame := schema.NewFrame(data)
+	if tc, ok := c.src.(tickCounter); ok {
+		frame.TickCount = tc.LastTickCount()
+	}
+
+	return frame, tickRate, update, nil
+}
+
+// activeSubscribers drops (and closes) the subscribers whose context is done
+// and returns the others. When none are left the pump is stopped and nil is
+// returned.
+func (c *Connection) activeSubscribers() []*subscriber {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	active := c.subs[:0]
+	for _, sub := range c.subs {
+		if sub.ctx.Err() != nil {
+			close(sub.ch)
+			continue
+		}
+		active = append(active, sub)
+	}
+	c.subs = active
+
+	if len(c.subs) == 0 {
+		c.subs = nil
+		c.pumping = false
+		return nil
+	}
+
+	return append([]*subscriber{}, c.subs...)
+}
+
+func (c *Connection) closeSubscribers() {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	for _, sub := range c.subs {
+		close(sub.ch)
+	}
+	c.subs = nil
+	c.pumping = false
+}
+
+// due reports if the subscriber wants the frame of tick according to its rate
+func (s *subscriber) due(tick int64, tickRate int) bool {
+	every := int64(1)
+	if s.opts.Rate > 0 && tickRate > s.opts.Rate {
+		every = int64(math.Floor(float64(tickRate)/float64(s.opts.Rate) + 0.5))
+	}
+
+	if s.lastTick >= 0 && tick > s.lastTick && tick-s.lastTick < every {
+		return false
+	}
+
+	s.lastTick = tick
+	return true
+}
+
+// deliver sends the frame to the subscriber according to its policy
+func (s *subscriber) deliver(frame *Frame) {
+	if s.base != frame.schema {
+		s.base = frame.schema
+		s.schema = frame.schema
+		if len(s.opts.Fields) > 0 {
+			s.schema = frame.schema.Filter(s.opts.Fields)
+		}
+	}
+
+	f := &Frame{
+		schema:    s.schema,
+		data:      frame.data,
+		TickCount: frame.TickCount,
+	}
+
+	if s.opts.Policy == Block {
+		select {
+		case s.ch <- f:
+		case <-s.ctx.Done():
+		}
+		return
+	}
+
+	for {
+		select {
+		case s.ch <- f:
+			return
+		default:
+		}
+
+		// Full: make room by dropping the oldest frame
+		select {
+		case <-s.ch:
+		default:
+		}
+	}
+}
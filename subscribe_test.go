@@ -0,0 +1,263 @@
+package irsdk
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// chanSource is a Source whose ticks are sent by the test. A tick of 0 is no
+// new data.
+type chanSource struct {
+	ticks chan int32
+	// last is only used by the pump
+	last int32
+}
+
+func newChanSource() *chanSource {
+	return &chanSource{ticks: make(chan int32)}
+}
+
+func (s *chanSource) Open() error       { return nil }
+func (s *chanSource) Close() error      { return nil }
+func (s *chanSource) IsConnected() bool { return true }
+
+func (s *chanSource) Header() (*utils.Header, error) {
+	return &utils.Header{TickRate: 60, NumVars: 2, BufLen: 8}, nil
+}
+
+func (s *chanSource) VarHeaders() ([]*utils.VarHeader, error) {
+	return []*utils.VarHeader{
+		testVarHeader("Speed", utils.FloatType, 0),
+		testVarHeader("RPM", utils.FloatType, 4),
+	}, nil
+}
+
+func (s *chanSource) SessionInfo() ([]byte, error) { return []byte("---\n"), nil }
+func (s *chanSource) NextVarBuf() ([]byte, error)  { return s.WaitForTick(0) }
+func (s *chanSource) LastTickCount() int32         { return s.last }
+
+func (s *chanSource) WaitForTick(timeOut time.Duration) ([]byte, error) {
+	select {
+	case tick := <-s.ticks:
+		if tick == 0 {
+			return nil, utils.ErrNothingChanged
+		}
+
+		s.last = tick
+		data := make([]byte, 8)
+		binary.LittleEndian.PutUint32(data, math.Float32bits(float32(tick)))
+		return data, nil
+	case <-time.After(timeOut):
+		return nil, utils.ErrNothingChanged
+	}
+}
+
+// tick hands tick to the pump and waits until the pump asks for the next one,
+// so the frame is delivered to every subscriber
+func (s *chanSource) tick(tick int32) {
+	s.ticks <- tick
+	s.ticks <- 0
+}
+
+// newChanConnection connects to a chanSource
+func newChanConnection(t *testing.T) (*chanSource, *Connection) {
+	src := newChanSource()
+	conn := NewConnectionFromSource(src)
+	err := conn.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Disconnect() })
+
+	return src, conn
+}
+
+// buffered returns the ticks of the frames buffered in ch
+func buffered(ch <-chan *Frame) []int32 {
+	ticks := []int32{}
+	for len(ch) > 0 {
+		ticks = append(ticks, (<-ch).TickCount)
+	}
+
+	return ticks
+}
+
+func equalTicks(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestSubscriberDue(t *testing.T) {
+	tests := []struct {
+		rate  int
+		ticks int
+		want  int
+	}{
+		{0, 60, 60},
+		{60, 60, 60},
+		{120, 60, 60},
+		{30, 60, 30},
+		{10, 60, 10},
+		{1, 60, 1},
+	}
+
+	for _, test := range tests {
+		s := &subscriber{opts: SubscribeOptions{Rate: test.rate}, lastTick: -1}
+		got := 0
+		for tick := int64(1); tick <= int64(test.ticks); tick++ {
+			if s.due(tick, 60) {
+				got = got + 1
+			}
+		}
+		if got != test.want {
+			t.Errorf("rate %d: %d of %d ticks due, want %d", test.rate, got, test.ticks, test.want)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	src, conn := newChanConnection(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	all, err := conn.Subscribe(ctx, SubscribeOptions{Buffer: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	half, err := conn.Subscribe(ctx, SubscribeOptions{Rate: 30, Buffer: 32, Fields: []string{"Speed"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = conn.Subscribe(ctx, SubscribeOptions{Policy: BackpressurePolicy(42)})
+	if err != ErrUnknownPolicy {
+		t.Errorf("got %v, want %v", err, ErrUnknownPolicy)
+	}
+
+	const ticks = 20
+	for i := int32(1); i <= ticks; i++ {
+		src.tick(i)
+	}
+
+	got := buffered(all)
+	if len(got) != ticks || got[0] != 1 || got[ticks-1] != ticks {
+		t.Errorf("got ticks %v, want 1 to %d", got, ticks)
+	}
+
+	got = []int32{}
+	for len(half) > 0 {
+		frame := <-half
+		if !frame.Has("Speed") || frame.Has("RPM") {
+			t.Error("frame isn't filtered")
+		}
+		if speed, _ := frame.Float("Speed"); speed != float32(frame.TickCount) {
+			t.Errorf("tick %d: got Speed %v", frame.TickCount, speed)
+		}
+		got = append(got, frame.TickCount)
+	}
+	if want := []int32{1, 3, 5, 7, 9, 11, 13, 15, 17, 19}; !equalTicks(got, want) {
+		t.Errorf("got ticks %v at rate 30, want %v", got, want)
+	}
+
+	// The pump closes the channels once it sees the context is done
+	cancel()
+	for range all {
+	}
+	for range half {
+	}
+}
+
+func TestSubscribeDropOldest(t *testing.T) {
+	src, conn := newChanConnection(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := conn.Subscribe(ctx, SubscribeOptions{Policy: DropOldest, Buffer: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := int32(1); i <= 5; i++ {
+		src.tick(i)
+	}
+
+	if got := buffered(ch); !equalTicks(got, []int32{4, 5}) {
+		t.Errorf("got ticks %v, want [4 5]", got)
+	}
+}
+
+func TestSubscribeLatestOnly(t *testing.T) {
+	src, conn := newChanConnection(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := conn.Subscribe(ctx, SubscribeOptions{Policy: LatestOnly, Buffer: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := int32(1); i <= 5; i++ {
+		src.tick(i)
+	}
+	if got := buffered(ch); !equalTicks(got, []int32{5}) {
+		t.Errorf("got ticks %v, want [5]", got)
+	}
+
+	src.tick(6)
+	if got := buffered(ch); !equalTicks(got, []int32{6}) {
+		t.Errorf("got ticks %v, want [6]", got)
+	}
+}
+
+func TestSubscribeBlock(t *testing.T) {
+	src, conn := newChanConnection(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := conn.Subscribe(ctx, SubscribeOptions{Policy: Block, Buffer: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src.tick(1)
+	src.tick(2)
+
+	// The buffer is full: the pump blocks on delivering tick 3 and doesn't
+	// read the next tick until there's room
+	src.ticks <- 3
+	next := make(chan struct{})
+	go func() {
+		src.ticks <- 0
+		close(next)
+	}()
+
+	select {
+	case <-next:
+		t.Fatal("pump read on with a full buffer")
+	default:
+	}
+
+	got := []int32{(<-ch).TickCount}
+	<-next
+	got = append(got, buffered(ch)...)
+	if !equalTicks(got, []int32{1, 2, 3}) {
+		t.Errorf("got ticks %v, want [1 2 3]", got)
+	}
+}
@@ -0,0 +1,343 @@
+package irsdk
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+// ConnectionState is a state of the Supervisor state machine
+type ConnectionState int
+
+const (
+	// SimNotRunning: the source couldn't be opened or the sim reports it's
+	// not connected. The supervisor retries with backoff.
+	SimNotRunning ConnectionState = iota
+	// Connecting: the source is being opened
+	Connecting
+	// Connected: the sim is publishing new ticks
+	Connected
+	// Stale: the sim reports it's connected but no new tick arrived for
+	// StaleAfter
+	Stale
+	// Disconnected: the sim went away (status bit cleared, tick counter reset,
+	// utils.ErrDisconnected or no new tick for Timeout). The supervisor
+	// reconnects.
+	Disconnected
+)
+
+const (
+	DEFAULT_MIN_BACKOFF    = time.Second
+	DEFAULT_MAX_BACKOFF    = 30 * time.Second
+	DEFAULT_STALE_AFTER    = 2 * time.Second
+	DEFAULT_CHECK_INTERVAL = 250 * time.Millisecond
+)
+
+var connectionStateNames = map[ConnectionState]string{
+	SimNotRunning: "SimNotRunning",
+	Connecting:    "Connecting",
+	Connected:     "Connected",
+	Stale:         "Stale",
+	Disconnected:  "Disconnected",
+}
+
+func (s ConnectionState) String() string {
+	if name, ok := connectionStateNames[s]; ok {
+		return name
+	}
+
+	return "Unknown"
+}
+
+// StateChange is delivered on every transition
+type StateChange struct {
+	From ConnectionState
+	To   ConnectionState
+	// Err is the error that caused the transition, if any
+	Err error
+	At  time.Time
+}
+
+type SupervisorOptions struct {
+	// MinBackoff is the first wait between reconnects (default 1s). It's
+	// doubled after every failed attempt up to MaxBackoff (default 30s).
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// StaleAfter is how long no new tick may arrive before the connection is
+	// considered stale (default 2s)
+	StaleAfter time.Duration
+	// Timeout is how long no new tick may arrive before the connection is
+	// considered gone (default utils.TIMEOUT)
+	Timeout time.Duration
+	// CheckInterval is how often the header is checked (default 250ms)
+	CheckInterval time.Duration
+	// OnStateChange is called from the supervisor goroutine on every
+	// transition
+	OnStateChange func(StateChange)
+}
+
+// Supervisor keeps a Connection connected. It watches the header of the source
+// (without consuming frames) and reconnects with backoff when the sim goes
+// away, so apps don't need their own sleep/IsConnected/Connect loops.
+type Supervisor struct {
+	conn *Connection
+	opts SupervisorOptions
+
+	mu       sync.Mutex
+	state    ConnectionState
+	watchers []*stateWatcher
+
+	// tick bookkeeping of the connected state
+	lastTick       int32
+	lastTickChange time.Time
+}
+
+type stateWatcher struct {
+	ctx context.Context
+	ch  chan StateChange
+}
+
+// NewSupervisor creates a supervisor for conn. Call Run to start it.
+func NewSupervisor(conn *Connection, opts SupervisorOptions) *Supervisor {
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = DEFAULT_MIN_BACKOFF
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = DEFAULT_MAX_BACKOFF
+		if opts.MaxBackoff < opts.MinBackoff {
+			opts.MaxBackoff = opts.MinBackoff
+		}
+	}
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = DEFAULT_STALE_AFTER
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = utils.TIMEOUT
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = DEFAULT_CHECK_INTERVAL
+	}
+
+	return &Supervisor{
+		conn:  conn,
+		opts:  opts,
+		state: SimNotRunning,
+	}
+}
+
+// Connection returns the supervised connection
+func (s *Supervisor) Connection() *Connection {
+	return s.conn
+}
+
+// State returns the current state
+func (s *Supervisor) State() ConnectionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state
+}
+
+// Events returns a channel receiving every state transition until ctx is done.
+// When the receiver doesn't keep up the oldest transitions are dropped.
+func (s *Supervisor) Events(ctx context.Context) <-chan StateChange {
+	w := &stateWatcher{
+		ctx: ctx,
+		ch:  make(chan StateChange, 8),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watchers = append(s.watchers, w)
+	return w.ch
+}
+
+// Run drives the state machine until ctx is done. The connection is
+// disconnected and all event channels are closed when it returns.
+func (s *Supervisor) Run(ctx context.Context) error {
+	defer s.stop()
+
+	backoff := s.opts.MinBackoff
+
+	for ctx.Err() == nil {
+		switch s.State() {
+		case SimNotRunning, Disconnected:
+			s.transition(Connecting, nil)
+			err := s.conn.Connect()
+			if err == nil && s.simConnected() {
+				s.startTicks()
+				s.transition(Connected, nil)
+				backoff = s.opts.MinBackoff
+				continue
+			}
+
+			if err == nil {
+				s.conn.Disconnect()
+			}
+			s.transition(SimNotRunning, err)
+
+			if !sleepContext(ctx, backoff) {
+				return ctx.Err()
+			}
+			backoff = backoff * 2
+			if backoff > s.opts.MaxBackoff {
+				backoff = s.opts.MaxBackoff
+			}
+
+		case Connected, Stale:
+			if !sleepContext(ctx, s.opts.CheckInterval) {
+				return ctx.Err()
+			}
+			s.check()
+		}
+	}
+
+	return ctx.Err()
+}
+
+// check moves between Connected, Stale and Disconnected based on the header
+func (s *Supervisor) check() {
+	if s.conn.takeDisconnected() {
+		s.lost(utils.ErrDisconnected)
+		return
+	}
+
+	header, err := s.conn.GetHeader()
+	if err != nil || header == nil {
+		s.lost(err)
+		return
+	}
+
+	if header.Status&utils.StatusConnected == 0 {
+		s.lost(nil)
+		return
+	}
+
+	tick := header.VarBuf[header.GetLatestVarBufN()].TickCount
+	now := time.Now()
+
+	if tick < s.lastTick {
+		// Tick counter went back: the sim restarted
+		s.lost(utils.ErrDisconnected)
+		return
+	}
+
+	if tick != s.lastTick {
+		s.lastTick = tick
+		s.lastTickChange = now
+		if s.State() == Stale {
+			s.transition(Connected, nil)
+		}
+		return
+	}
+
+	idle := now.Sub(s.lastTickChange)
+	if idle >= s.opts.Timeout {
+		s.lost(nil)
+		return
+	}
+
+	if idle >= s.opts.StaleAfter && s.State() == Connected {
+		s.transition(Stale, nil)
+	}
+}
+
+// lost disconnects, after which Run reconnects
+func (s *Supervisor) lost(err error) {
+	s.conn.Disconnect()
+	s.transition(Disconnected, err)
+}
+
+func (s *Supervisor) simConnected() bool {
+	header, err := s.conn.GetHeader()
+	if err != nil || header == nil {
+		return false
+	}
+
+	return header.Status&utils.StatusConnected != 0
+}
+
+func (s *Supervisor) startTicks() {
+	s.lastTick = 0
+	s.lastTickChange = time.Now()
+
+	header, err := s.conn.GetHeader()
+	if err == nil && header != nil {
+		s.lastTick = header.VarBuf[header.GetLatestVarBufN()].TickCount
+	}
+}
+
+func (s *Supervisor) transition(to ConnectionState, err error) {
+	s.mu.Lock()
+	change := StateChange{
+		From: s.state,
+		To:   to,
+		Err:  err,
+		At:   time.Now(),
+	}
+	if change.From == change.To && err == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.state = to
+
+	active := s.watchers[:0]
+	for _, w := range s.watchers {
+		if w.ctx.Err() != nil {
+			close(w.ch)
+			continue
+		}
+		w.send(change)
+		active = append(active, w)
+	}
+	s.watchers = active
+	s.mu.Unlock()
+
+	if s.opts.OnStateChange != nil {
+		s.opts.OnStateChange(change)
+	}
+}
+
+func (s *Supervisor) stop() {
+	s.conn.Disconnect()
+	s.transition(Disconnected, nil)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range s.watchers {
+		close(w.ch)
+	}
+	s.watchers = nil
+}
+
+func (w *stateWatcher) send(change StateChange) {
+	for {
+		select {
+		case w.ch <- change:
+			return
+		default:
+		}
+
+		select {
+		case <-w.ch:
+		default:
+		}
+	}
+}
+
+// sleepContext sleeps for d and returns false when ctx was done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
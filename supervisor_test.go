@@ -0,0 +1,160 @@
+package irsdk
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForState reads events until the supervisor moved to state
+func waitForState(t *testing.T, events <-chan StateChange, state ConnectionState) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case change, ok := <-events:
+			if !ok {
+				t.Fatalf("events closed before %v", state)
+			}
+			if change.To == state {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no transition to %v", state)
+		}
+	}
+}
+
+func testSupervisorOptions() SupervisorOptions {
+	return SupervisorOptions{
+		MinBackoff:    10 * time.Millisecond,
+		MaxBackoff:    20 * time.Millisecond,
+		StaleAfter:    50 * time.Millisecond,
+		Timeout:       200 * time.Millisecond,
+		CheckInterval: 5 * time.Millisecond,
+	}
+}
+
+func TestSupervisor(t *testing.T) {
+	p, path := newFakeSim(t)
+	conn := NewConnectionFromSource(NewMmapSource(path, nil))
+	s := NewSupervisor(conn, testSupervisorOptions())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Events(ctx)
+
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	stop := make(chan struct{})
+	go p.Run(stop)
+	waitForState(t, events, Connected)
+
+	// The sim stops ticking
+	close(stop)
+	waitForState(t, events, Stale)
+	waitForState(t, events, Disconnected)
+
+	// The sim ticks again but reports it's not connected
+	p.SetConnected(false)
+	stop = make(chan struct{})
+	go p.Run(stop)
+	defer func() { close(stop) }()
+	waitForState(t, events, SimNotRunning)
+
+	p.SetConnected(true)
+	waitForState(t, events, Connected)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Run returned %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after cancel")
+	}
+
+	if s.State() != Disconnected {
+		t.Errorf("state after Run is %v, want Disconnected", s.State())
+	}
+}
+
+func TestSupervisorSimNotRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.mmap")
+	conn := NewConnectionFromSource(NewMmapSource(path, nil))
+
+	changes := make(chan StateChange, 16)
+	opts := testSupervisorOptions()
+	opts.OnStateChange = func(change StateChange) {
+		select {
+		case changes <- change:
+		default:
+		}
+	}
+	s := NewSupervisor(conn, opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	attempts := 0
+	for len(changes) > 0 {
+		change := <-changes
+		if change.To == Connected {
+			t.Fatal("connected without a sim")
+		}
+		if change.To == SimNotRunning {
+			if change.Err == nil {
+				t.Error("SimNotRunning without the error of Connect")
+			}
+			attempts = attempts + 1
+		}
+	}
+
+	// 100ms with a backoff of 10ms, doubling up to 20ms
+	if attempts < 2 || attempts > 10 {
+		t.Errorf("%d connect attempts, want a backoff between them", attempts)
+	}
+}
+
+func TestConnectionStateString(t *testing.T) {
+	if s := Stale.String(); s != "Stale" {
+		t.Errorf("Stale.String() is %q", s)
+	}
+	if s := ConnectionState(42).String(); s != "Unknown" {
+		t.Errorf("ConnectionState(42).String() is %q", s)
+	}
+}
+
+func TestSupervisorWithReader(t *testing.T) {
+	p, path := newFakeSim(t)
+	conn := NewConnectionFromSource(NewMmapSource(path, nil))
+	s := NewSupervisor(conn, testSupervisorOptions())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Events(ctx)
+	go s.Run(ctx)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go p.Run(stop)
+	waitForState(t, events, Connected)
+
+	// Reading frames (including the first read that syncs to the sim) doesn't
+	// count as a disconnect
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		conn.GetFrame()
+	}
+
+	select {
+	case change := <-events:
+		t.Fatalf("transition from %v to %v (%v) while the sim was running", change.From, change.To, change.Err)
+	default:
+	}
+}
@@ -1,71 +1,29 @@
 package irsdk
 
-import "C"
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"log"
 	"reflect"
-	"unsafe"
 
 	utils "github.com/leonb/irsdk-go/utils"
 )
 
-var telemetryData = NewTelemetryData()
-
-type irCharVar struct {
-	name  string
-	desc  string
-	value byte
-	unit  string
-}
-
-type irBoolVar struct {
-	name  string
-	desc  string
-	value bool
-	unit  string
-}
-
-type irIntVar struct {
-	name  string
-	desc  string
-	value int
-	unit  string
-}
-
-type irBitfieldVar struct {
-	name   string
-	desc   string
-	fields map[string]bool
-	unit   string
-}
-
-type irFloatVar struct {
-	name  string
-	desc  string
-	value float32
-	unit  string
-}
-
-type irDoubleVar struct {
-	name  string
-	desc  string
-	value float64
-	unit  string
-}
+var (
+	ErrUnknownVar      = errors.New("Unknown telemetry variable")
+	ErrWrongVarType    = errors.New("Telemetry variable has a different type")
+	ErrIndexOutOfRange = errors.New("Index out of range for telemetry variable")
+	ErrInvalidVarType  = errors.New("Telemetry variable has an invalid type")
+)
 
 type TelemetryData struct {
-	fieldCache map[string]*reflect.Value
-
 	// bools
 	DriverMarker                   bool
 	IsOnTrack                      bool
 	IsReplayPlaying                bool
 	IsDiskLoggingEnabled           bool
 	IsDiskLoggingActive            bool
-	CarIdxOnPitRoad                bool
+	CarIdxOnPitRoad                [utils.MAX_CARS]bool
 	OnPitRoad                      bool
 	LapDeltaToBestLap_OK           bool
 	LapDeltaToOptimalLap_OK        bool
@@ -78,7 +36,7 @@ type TelemetryData struct {
 
 	// ints
 	SessionNum                int
-	SessionState              int
+	SessionState              utils.SessionState
 	SessionUniqueID           int
 	SessionLapsRemain         int
 	RadioTransmitCarIdx       int
@@ -86,9 +44,9 @@ type TelemetryData struct {
 	RadioTransmitFrequencyIdx int
 	ReplayFrameNum            int
 	ReplayFrameNumEnd         int
-	CarIdxLap                 int
-	CarIdxTrackSurface        int
-	CarIdxGear                int
+	CarIdxLap                 [utils.MAX_CARS]int
+	CarIdxTrackSurface        [utils.MAX_CARS]utils.TrkLoc
+	CarIdxGear                [utils.MAX_CARS]int
 	Gear                      int
 	Lap                       int
 	RaceLaps                  int
@@ -101,32 +59,45 @@ type TelemetryData struct {
 	DisplayUnits              int
 	PlayerCarPosition         int
 	PlayerCarClassPosition    int
-	CarIdxPosition            int
-	CarIdxClassPosition       int
+	CarIdxPosition            [utils.MAX_CARS]int
+	CarIdxClassPosition       [utils.MAX_CARS]int
 	LapLasNLapSeq             int
 	LapBestNLapLap            int
 	EnterExitReset            int
 	DCLapStatus               int
 	DCDriversSoFar            int
 
+	// enums
+	PlayerTrackSurface         utils.TrkLoc
+	PlayerTrackSurfaceMaterial utils.TrkSurf
+	CarLeftRight               utils.CarLeftRight
+	PaceMode                   utils.PaceMode
+	TrackWetness               utils.TrackWetness
+
 	// Only used in disk based telemetry
 	WeatherType int
 	Skies       int
 
+	// int arrays
+	CarIdxLapCompleted         [utils.MAX_CARS]int
+	CarIdxBestLapNum           [utils.MAX_CARS]int
+	CarIdxTrackSurfaceMaterial [utils.MAX_CARS]utils.TrkSurf
+
 	// bitfields
-	SessionFlags   map[string]bool
-	CamCameraState map[string]bool
-	EngineWarnings map[string]bool
+	SessionFlags       utils.Flags
+	CamCameraState     utils.CameraState
+	EngineWarnings     utils.EngineWarnings
+	CarIdxSessionFlags [utils.MAX_CARS]utils.Flags
 
 	// Only used in disk based telemetry data
-	PitSvFlags map[string]bool
+	PitSvFlags utils.PitSvFlag
 
 	// floats
 	FrameRate                       float32
 	CpuUsageBG                      float32
-	CarIdxLapDistPct                float32
-	CarIdxSteer                     float32
-	CarIdxRPM                       float32
+	CarIdxLapDistPct                [utils.MAX_CARS]float32
+	CarIdxSteer                     [utils.MAX_CARS]float32
+	CarIdxRPM                       [utils.MAX_CARS]float32
 	SteeringWheelAngle              float32
 	Throttle                        float32
 	Brake                           float32
@@ -224,13 +195,17 @@ type TelemetryData struct {
 	RFshockVel                      float32
 	LFshockDefl                     float32
 	LFshockVel                      float32
-	CarIdxF2Time                    float32
-	CarIdxEstTime                   float32
+	CarIdxF2Time                    [utils.MAX_CARS]float32
+	CarIdxEstTime                   [utils.MAX_CARS]float32
 	LapLastNLapTime                 float32
 	brakeLinePresse                 float32
 	DcBrakeBias                     float32
 	LapBestNLapTime                 float32
 
+	// float arrays
+	CarIdxLastLapTime [utils.MAX_CARS]float32
+	CarIdxBestLapTime [utils.MAX_CARS]float32
+
 	// Only used in disk based telemetry
 	Alt               float32
 	TrackTemp         float32
@@ -286,81 +261,96 @@ type TelemetryData struct {
 	Lon float64
 }
 
-func (d *TelemetryData) addVarHeaderData(varHeader *utils.VarHeader, data []byte) error {
-	switch varHeader.Type {
-	case utils.CharType:
-		irVar := extractCharFromVarHeader(varHeader, data)
-		err := d.AddIrCharVar(irVar)
-		if err != nil {
-			log.Println(err)
-		}
-	case utils.BoolType:
-		irVar := extractBoolFromVarHeader(varHeader, data)
-		err := d.AddIrBoolVar(irVar)
-		if err != nil {
-			log.Println(err)
-		}
-	case utils.IntType:
-		irVar := extractIntFromVarHeader(varHeader, data)
-		err := d.AddIrIntVar(irVar)
-		if err != nil {
-			log.Println(err)
-		}
-	case utils.BitfieldType:
-		irVar := extractBitfieldFromVarHeader(varHeader, data)
-		err := d.AddIrBitfieldVar(irVar)
-		if err != nil {
-			log.Println(err)
-		}
-	case utils.FloatType:
-		irVar := extractFloatFromVarHeader(varHeader, data)
-		err := d.AddIrFloatVar(irVar)
-		if err != nil {
-			log.Println(err)
-		}
-	case utils.DoubleType:
-		irVar := extractDoubleFromVarHeader(varHeader, data)
-		err := d.AddIrDoubleVar(irVar)
-		if err != nil {
-			log.Println(err)
-		}
-	default:
-		log.Println("Unknown irsdk varType:", varHeader.Type)
+// fieldKind returns the kind of a field or, for arrays and slices, the kind of
+// its elements. Enums (int32) count as int.
+func fieldKind(f reflect.Value) reflect.Kind {
+	kind := f.Kind()
+	switch kind {
+	case reflect.Array, reflect.Slice:
+		kind = f.Type().Elem().Kind()
 	}
 
-	return nil
+	if kind == reflect.Int32 {
+		return reflect.Int
+	}
+
+	return kind
 }
 
-func (d *TelemetryData) fieldByName(varName string, kind reflect.Kind) (*reflect.Value, error) {
-	if f, ok := d.fieldCache[varName]; ok {
-		return f, nil
-	}
+// The ir*Var types hold a single decoded variable for the AddIr* methods
+type irCharVar struct {
+	name  string
+	desc  string
+	value byte
+	unit  string
+}
+
+type irBoolVar struct {
+	name  string
+	desc  string
+	value bool
+	unit  string
+}
+
+type irIntVar struct {
+	name  string
+	desc  string
+	value int
+	unit  string
+}
 
-	// Uppercase string because all are stored as public variables
-	varNameUp := ucFirst(varName)
+type irBitfieldVar struct {
+	name  string
+	desc  string
+	value uint32
+	unit  string
+}
 
-	e := reflect.ValueOf(d).Elem() // Get reference to struct
-	f := e.FieldByName(varNameUp)  // Find struct field
-	if f.Kind() == kind {
-		if f.CanSet() {
-			d.fieldCache[varName] = &f
-			return &f, nil
-		}
+type irFloatVar struct {
+	name  string
+	desc  string
+	value float32
+	unit  string
+}
+
+type irDoubleVar struct {
+	name  string
+	desc  string
+	value float64
+	unit  string
+}
+
+// scalarField returns the field of the scalar var name if its kind (see
+// fieldKind) is kind
+func (d *TelemetryData) scalarField(name string, kind reflect.Kind) (reflect.Value, error) {
+	v, err := d.indexedField(name, 0, kind)
+	if err != nil {
+		return reflect.Value{}, err
 	}
 
-	return nil, errors.New(fmt.Sprintf("Unknown %v/%v: %v", kind, f.Kind(), varNameUp))
+	if d.Len(name) != 1 {
+		return reflect.Value{}, ErrWrongVarType
+	}
+
+	return v, nil
 }
 
+// AddIrCharVar doesn't do anything: TelemetryData has no char fields.
+//
+// Deprecated: decode var buffers with a Decoder
 func (d *TelemetryData) AddIrCharVar(irVar *irCharVar) error {
 	return nil
 }
 
+// AddIrBoolVar sets the field of a bool var.
+//
+// Deprecated: decode var buffers with a Decoder
 func (d *TelemetryData) AddIrBoolVar(irVar *irBoolVar) error {
 	if irVar == nil {
 		return nil
 	}
 
-	f, err := d.fieldByName(irVar.name, reflect.Bool)
+	f, err := d.scalarField(irVar.name, reflect.Bool)
 	if err != nil {
 		return err
 	}
@@ -369,12 +359,15 @@ func (d *TelemetryData) AddIrBoolVar(irVar *irBoolVar) error {
 	return nil
 }
 
+// AddIrIntVar sets the field of an int var, including enums.
+//
+// Deprecated: decode var buffers with a Decoder
 func (d *TelemetryData) AddIrIntVar(irVar *irIntVar) error {
 	if irVar == nil {
 		return nil
 	}
 
-	f, err := d.fieldByName(irVar.name, reflect.Int)
+	f, err := d.scalarField(irVar.name, reflect.Int)
 	if err != nil {
 		return err
 	}
@@ -383,30 +376,41 @@ func (d *TelemetryData) AddIrIntVar(irVar *irIntVar) error {
 	return nil
 }
 
+// AddIrBitfieldVar sets the field of a bitfield var.
+//
+// Deprecated: decode var buffers with a Decoder
 func (d *TelemetryData) AddIrBitfieldVar(irVar *irBitfieldVar) error {
 	if irVar == nil {
 		return nil
 	}
 
-	f, err := d.fieldByName(irVar.name, reflect.Map)
+	f, err := d.scalarField(irVar.name, reflect.Uint32)
+	if err == ErrWrongVarType {
+		// Bitfields of signed types (CameraState, EngineWarnings, ...)
+		f, err = d.scalarField(irVar.name, reflect.Int)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(int32(irVar.value)))
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	for key, val := range irVar.fields {
-		rKey := reflect.ValueOf(key)
-		rVal := reflect.ValueOf(val)
-		f.SetMapIndex(rKey, rVal)
-	}
+	f.SetUint(uint64(irVar.value))
 	return nil
 }
 
+// AddIrFloatVar sets the field of a float var.
+//
+// Deprecated: decode var buffers with a Decoder
 func (d *TelemetryData) AddIrFloatVar(irVar *irFloatVar) error {
 	if irVar == nil {
 		return nil
 	}
 
-	f, err := d.fieldByName(irVar.name, reflect.Float32)
+	f, err := d.scalarField(irVar.name, reflect.Float32)
 	if err != nil {
 		return err
 	}
@@ -415,12 +419,15 @@ func (d *TelemetryData) AddIrFloatVar(irVar *irFloatVar) error {
 	return nil
 }
 
+// AddIrDoubleVar sets the field of a double var.
+//
+// Deprecated: decode var buffers with a Decoder
 func (d *TelemetryData) AddIrDoubleVar(irVar *irDoubleVar) error {
 	if irVar == nil {
 		return nil
 	}
 
-	f, err := d.fieldByName(irVar.name, reflect.Float64)
+	f, err := d.scalarField(irVar.name, reflect.Float64)
 	if err != nil {
 		return err
 	}
@@ -429,303 +436,132 @@ func (d *TelemetryData) AddIrDoubleVar(irVar *irDoubleVar) error {
 	return nil
 }
 
-var irsdkFlags = map[utils.Flags]string{
-	// global flags
-	utils.CheckeredFlag:     "Checkered",
-	utils.WhiteFlag:         "White",
-	utils.GreenFlag:         "Green",
-	utils.YellowFlag:        "Yellow",
-	utils.RedFlag:           "Red",
-	utils.BlueFlag:          "Blue",
-	utils.DebrisFlag:        "Debris",
-	utils.CrossedFlag:       "Crossed",
-	utils.YellowWavingFlag:  "YellowWaving",
-	utils.OneLapToGreenFlag: "OneLapToGreen",
-	utils.GreenHeldFlag:     "GreenHeld",
-	utils.TenToGoFlag:       "TenToGo",
-	utils.FiveToGoFlag:      "FiveToGo",
-	utils.RandomWavingFlag:  "RandomWaving",
-	utils.CautionFlag:       "Caution",
-	utils.CautionWavingFlag: "CautionWaving",
-
-	// drivers black flags
-	utils.BlackFlag:      "Black",
-	utils.DisqualifyFlag: "Disqualify",
-	utils.ServicibleFlag: "Servicible", // car is allowed service (not a flag)
-	utils.FurledFlag:     "Furled",
-	utils.RepairFlag:     "Repair",
-
-	// start lights
-	utils.StartHidden: "StartHidden",
-	utils.StartReady:  "StartReady",
-	utils.StartSet:    "StartSet",
-	utils.StartGo:     "StartGo",
-}
-
-var irsdkEngineWarnings = map[utils.EngineWarnings]string{
-	utils.WaterTempWarning:    "WaterTempWarning",
-	utils.FuelPressureWarning: "FuelPressureWarning",
-	utils.OilPressureWarning:  "OilPressureWarning",
-	utils.EngineStalled:       "EngineStalled",
-	utils.PitSpeedLimiter:     "PitSpeedLimiter",
-	utils.RevLimiterActive:    "RevLimiterActive",
-}
-
-var irsdkCameraStates = map[utils.CameraState]string{
-	utils.IsSessionScreen:       "IsSessionScreen",
-	utils.IsScenicActive:        "IsScencActive",
-	utils.CamToolActive:         "RamToolActive",
-	utils.UIHidden:              "UiHidden",
-	utils.UseAutoShotSelection:  "UseAutoShotSelection",
-	utils.UseTemporaryEdits:     "UseTemporaryEdits",
-	utils.UseKeyAcceleration:    "UseKeyAcceleration",
-	utils.UseKey10xAcceleration: "UseKey10xAcceleration",
-	utils.UseMouseAimMode:       "UseMouseAimMode",
-}
-
-var irsdkSessionStates = map[utils.SessionState]string{
-	utils.StateInvalid:    "Invalid",
-	utils.StateGetInCar:   "GetInCar",
-	utils.StateWarmup:     "Warmup",
-	utils.StateParadeLaps: "ParadeLaps",
-	utils.StateRacing:     "Racing",
-	utils.StateCheckered:  "Checkered",
-	utils.StateCoolDown:   "CoolDown",
-}
-
-var irsdkPitSvFlags = map[utils.PitSvFlag]string{
-	utils.LFTireChange: "LFTireChange",
-	utils.RFTireChange: "RFTireChange",
-	utils.LRTireChange: "LRTireChange",
-	utils.RRTireChange: "RRTireChange",
-
-	utils.FuelFill:          "FuelFill",
-	utils.WindshieldTearoff: "WindshieldTearoff",
-	utils.FastRepair:        "FastRepair",
-}
-
-// @TODO: should this accept an io.Reader?
+// BytesToTelemetryStruct decodes a var buffer of this connection's source into
+// a new TelemetryData
 func (c *Connection) BytesToTelemetryStruct(data []byte) (*TelemetryData, error) {
-	// Create an new struct in the same memory location so reflect values can be
-	// cached
-	td := NewTelemetryData()
-	td.fieldCache = telemetryData.fieldCache
-	*telemetryData = *td
-	numVars := c.sdk.GetNumVars()
-
-	for i := 0; i <= numVars; i++ {
-		varHeader, err := c.sdk.GetVarHeaderEntry(i)
-		if err != nil {
-			continue
-		}
-
-		if varHeader == nil {
-			continue
-		}
+	c.mu.Lock()
+	decoder, err := c.getDecoder(nil)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
 
-		telemetryData.addVarHeaderData(varHeader, data)
+	td := NewTelemetryData()
+	err = decoder.Decode(data, td)
+	if err != nil {
+		return nil, err
 	}
 
-	return telemetryData, nil
+	return td, nil
 }
 
-// @TODO: should this accept an io.Reader?
-// @TODO: this shouldn't be on the connection because it can also be used by
-// disk based telemetry (.ibt)
+// BytesToTelemetryStructFiltered is BytesToTelemetryStruct, but only decodes
+// the variables in fields. Errors are logged and leave the returned
+// TelemetryData empty.
 func (c *Connection) BytesToTelemetryStructFiltered(data []byte, fields []string) *TelemetryData {
-	// Create an new struct in the same memory location so reflect values can be
-	// cached
 	td := NewTelemetryData()
-	td.fieldCache = telemetryData.fieldCache
-	*telemetryData = *td
-	numVars := c.sdk.GetNumVars()
-
-	for i := 0; i <= numVars; i++ {
-		varHeader, err := c.sdk.GetVarHeaderEntry(i)
-		if err != nil {
-			continue
-		}
-
-		if varHeader == nil {
-			continue
-		}
 
-		if fields == nil || len(fields) == 0 {
-			// fields is empty: add everything
-			telemetryData.addVarHeaderData(varHeader, data)
-			continue
-		}
-
-		varName := utils.CToGoString(varHeader.Name[:])
-		found := false
-
-		for _, v := range fields {
-			if v == varName {
-				// Found varName in fields, skip looping through fields
-				found = true
-				break
-			}
-		}
-
-		if found == false {
-			// var not in fieds: skip varHeader
-			continue
-		}
-
-		telemetryData.addVarHeaderData(varHeader, data)
+	c.mu.Lock()
+	decoder, err := c.getDecoder(fields)
+	c.mu.Unlock()
+	if err != nil {
+		log.Println(err)
+		return td
 	}
 
-	return telemetryData
-}
-
-func extractCharFromVarHeader(header *utils.VarHeader, data []byte) *irCharVar {
-	varName := utils.CToGoString(header.Name[:])
-	varDesc := utils.CToGoString(header.Desc[:])
-	varUnit := utils.CToGoString(header.Unit[:])
-
-	dataPtr := uintptr(unsafe.Pointer(&data[0]))
-	offset := uintptr(header.Offset)
-	varPtr := dataPtr + offset
-
-	hvar := *(*C.char)(unsafe.Pointer(varPtr))
-
-	return &irCharVar{
-		name:  varName,
-		desc:  varDesc,
-		value: byte(hvar),
-		unit:  varUnit,
+	err = decoder.Decode(data, td)
+	if err != nil {
+		log.Println(err)
 	}
+	return td
 }
 
-func extractBoolFromVarHeader(header *utils.VarHeader, data []byte) *irBoolVar {
-	varName := utils.CToGoString(header.Name[:])
-	varDesc := utils.CToGoString(header.Desc[:])
-	varUnit := utils.CToGoString(header.Unit[:])
-
-	dataPtr := uintptr(unsafe.Pointer(&data[0]))
-	offset := uintptr(header.Offset)
-	varPtr := dataPtr + offset
-
-	hvar := *(*bool)(unsafe.Pointer(varPtr))
-
-	return &irBoolVar{
-		name:  varName,
-		desc:  varDesc,
-		value: hvar,
-		unit:  varUnit,
+// FloatAt returns entry i of the float array var name (CarIdxLapDistPct,
+// CarIdxF2Time, ...). For scalar vars only index 0 is valid.
+func (d *TelemetryData) FloatAt(name string, i int) (float32, error) {
+	v, err := d.indexedField(name, i, reflect.Float32)
+	if err != nil {
+		return 0, err
 	}
-}
-
-func extractIntFromVarHeader(header *utils.VarHeader, data []byte) *irIntVar {
-	varName := utils.CToGoString(header.Name[:])
-	varDesc := utils.CToGoString(header.Desc[:])
-	varUnit := utils.CToGoString(header.Unit[:])
 
-	dataPtr := uintptr(unsafe.Pointer(&data[0]))
-	offset := uintptr(header.Offset)
-	varPtr := dataPtr + offset
-
-	hvar := *(*C.int)(unsafe.Pointer(varPtr))
+	return float32(v.Float()), nil
+}
 
-	return &irIntVar{
-		name:  varName,
-		desc:  varDesc,
-		value: int(hvar),
-		unit:  varUnit,
+// IntAt returns entry i of the int array var name (CarIdxPosition,
+// CarIdxLap, ...)
+func (d *TelemetryData) IntAt(name string, i int) (int, error) {
+	v, err := d.indexedField(name, i, reflect.Int)
+	if err != nil {
+		return 0, err
 	}
-}
 
-func extractBitfieldFromVarHeader(header *utils.VarHeader, data []byte) *irBitfieldVar {
-	varName := utils.CToGoString(header.Name[:])
-	varDesc := utils.CToGoString(header.Desc[:])
-	varUnit := utils.CToGoString(header.Unit[:])
+	return int(v.Int()), nil
+}
 
-	dataPtr := uintptr(unsafe.Pointer(&data[0]))
-	offset := uintptr(header.Offset)
-	varPtr := dataPtr + offset
+// BoolAt returns entry i of the bool array var name (CarIdxOnPitRoad, ...)
+func (d *TelemetryData) BoolAt(name string, i int) (bool, error) {
+	v, err := d.indexedField(name, i, reflect.Bool)
+	if err != nil {
+		return false, err
+	}
 
-	hvar := *(*uint32)(unsafe.Pointer(varPtr))
+	return v.Bool(), nil
+}
 
-	retVar := &irBitfieldVar{
-		name:   varName,
-		desc:   varDesc,
-		fields: make(map[string]bool),
-		unit:   varUnit,
+// Len returns the number of entries of var name: the array length for array
+// vars and 1 for scalars
+func (d *TelemetryData) Len(name string) int {
+	f := reflect.ValueOf(d).Elem().FieldByName(ucFirst(name))
+	if !f.IsValid() {
+		return 0
 	}
 
-	switch varName {
-	case "SessionFlags":
-		for bitmask, name := range irsdkFlags {
-			retVar.fields[name] = bool(uint32(hvar)&uint32(bitmask) != 0)
-		}
-	case "CamCameraState":
-		for bitmask, name := range irsdkCameraStates {
-			retVar.fields[name] = bool(uint32(hvar)&uint32(bitmask) != 0)
-		}
-	case "EngineWarnings":
-		for bitmask, name := range irsdkEngineWarnings {
-			retVar.fields[name] = bool(uint32(hvar)&uint32(bitmask) != 0)
-		}
-	case "PitSvFlags":
-		for bitmask, name := range irsdkPitSvFlags {
-			retVar.fields[name] = bool(uint32(hvar)&uint32(bitmask) != 0)
-		}
-	default:
-		log.Println("Unknown bitField var:", varName)
+	switch f.Kind() {
+	case reflect.Array, reflect.Slice:
+		return f.Len()
 	}
 
-	return retVar
+	return 1
 }
 
-func extractFloatFromVarHeader(header *utils.VarHeader, data []byte) *irFloatVar {
-	varName := utils.CToGoString(header.Name[:])
-	varDesc := utils.CToGoString(header.Desc[:])
-	varUnit := utils.CToGoString(header.Unit[:])
-
-	dataPtr := uintptr(unsafe.Pointer(&data[0]))
-	offset := uintptr(header.Offset)
-	varPtr := dataPtr + offset
-
-	hvar := *(*C.float)(unsafe.Pointer(varPtr))
-
-	return &irFloatVar{
-		name:  varName,
-		desc:  varDesc,
-		value: float32(hvar),
-		unit:  varUnit,
+func (d *TelemetryData) indexedField(name string, i int, kind reflect.Kind) (reflect.Value, error) {
+	if name == "" {
+		return reflect.Value{}, ErrUnknownVar
 	}
-}
 
-func extractDoubleFromVarHeader(header *utils.VarHeader, data []byte) *irDoubleVar {
-	varName := utils.CToGoString(header.Name[:])
-	varDesc := utils.CToGoString(header.Desc[:])
-	varUnit := utils.CToGoString(header.Unit[:])
+	f := reflect.ValueOf(d).Elem().FieldByName(ucFirst(name))
+	if !f.IsValid() {
+		return reflect.Value{}, ErrUnknownVar
+	}
 
-	dataPtr := uintptr(unsafe.Pointer(&data[0]))
-	offset := uintptr(header.Offset)
-	varPtr := dataPtr + offset
+	if fieldKind(f) != kind {
+		return reflect.Value{}, ErrWrongVarType
+	}
 
-	hvar := *(*C.double)(unsafe.Pointer(varPtr))
+	switch f.Kind() {
+	case reflect.Array, reflect.Slice:
+		if i < 0 || i >= f.Len() {
+			return reflect.Value{}, ErrIndexOutOfRange
+		}
+		return f.Index(i), nil
+	}
 
-	return &irDoubleVar{
-		name:  varName,
-		desc:  varDesc,
-		value: float64(hvar),
-		unit:  varUnit,
+	if i != 0 {
+		return reflect.Value{}, ErrIndexOutOfRange
 	}
+
+	return f, nil
 }
 
 func NewTelemetryData() *TelemetryData {
-	return &TelemetryData{
-		fieldCache:     make(map[string]*reflect.Value),
-		SessionFlags:   make(map[string]bool),
-		CamCameraState: make(map[string]bool),
-		EngineWarnings: make(map[string]bool),
-		PitSvFlags:     make(map[string]bool),
-	}
+	return &TelemetryData{}
 }
 
 func ucFirst(s string) string {
+	if s == "" {
+		return ""
+	}
+
 	b := []byte(s)
 	b[0] = bytes.ToUpper(b[0:1])[0]
 	return string(b)
@@ -0,0 +1,124 @@
+package irsdk
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/leonb/irsdk-go/utils"
+)
+
+func TestUcFirst(t *testing.T) {
+	tests := map[string]string{
+		"":           "",
+		"speed":      "Speed",
+		"Speed":      "Speed",
+		"dcABS":      "DcABS",
+		"s":          "S",
+		"1stGear":    "1stGear",
+		"CarIdxLap":  "CarIdxLap",
+		"carIdxLap_": "CarIdxLap_",
+	}
+
+	for s, want := range tests {
+		if got := ucFirst(s); got != want {
+			t.Errorf("ucFirst(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestTelemetryDataAt(t *testing.T) {
+	td := NewTelemetryData()
+	td.CarIdxLap[3] = 7
+	td.CarIdxOnPitRoad[2] = true
+	td.Speed = 42
+
+	if n := td.Len(""); n != 0 {
+		t.Errorf("Len(\"\"): got %d", n)
+	}
+	if n := td.Len("Speed"); n != 1 {
+		t.Errorf("Len(Speed): got %d", n)
+	}
+	if n := td.Len("CarIdxLap"); n != len(td.CarIdxLap) {
+		t.Errorf("Len(CarIdxLap): got %d", n)
+	}
+
+	if lap, err := td.IntAt("CarIdxLap", 3); err != nil || lap != 7 {
+		t.Errorf("IntAt(CarIdxLap, 3): got %v, %v", lap, err)
+	}
+	if pit, err := td.BoolAt("CarIdxOnPitRoad", 2); err != nil || !pit {
+		t.Errorf("BoolAt(CarIdxOnPitRoad, 2): got %v, %v", pit, err)
+	}
+	if speed, err := td.FloatAt("Speed", 0); err != nil || speed != 42 {
+		t.Errorf("FloatAt(Speed, 0): got %v, %v", speed, err)
+	}
+
+	if _, err := td.IntAt("CarIdxLap", len(td.CarIdxLap)); err != ErrIndexOutOfRange {
+		t.Errorf("IntAt out of range: got %v, want %v", err, ErrIndexOutOfRange)
+	}
+	if _, err := td.IntAt("Speed", 0); err != ErrWrongVarType {
+		t.Errorf("IntAt(Speed): got %v, want %v", err, ErrWrongVarType)
+	}
+	if _, err := td.IntAt("NoSuchVar", 0); err != ErrUnknownVar {
+		t.Errorf("IntAt(NoSuchVar): got %v, want %v", err, ErrUnknownVar)
+	}
+}
+
+func TestTelemetryDataAddIrVars(t *testing.T) {
+	td := NewTelemetryData()
+
+	errs := []error{
+		td.AddIrCharVar(&irCharVar{name: "Speed", value: 1}),
+		td.AddIrBoolVar(&irBoolVar{name: "OnPitRoad", value: true}),
+		td.AddIrIntVar(&irIntVar{name: "Lap", value: 3}),
+		td.AddIrIntVar(&irIntVar{name: "SessionState", value: int(utils.StateRacing)}),
+		td.AddIrBitfieldVar(&irBitfieldVar{name: "SessionFlags", value: uint32(utils.GreenFlag)}),
+		td.AddIrBitfieldVar(&irBitfieldVar{name: "EngineWarnings", value: uint32(utils.PitSpeedLimiter)}),
+		td.AddIrFloatVar(&irFloatVar{name: "Speed", value: 42}),
+		td.AddIrDoubleVar(&irDoubleVar{name: "SessionTime", value: 12.5}),
+		td.AddIrDoubleVar(nil),
+	}
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		}
+	}
+
+	if !td.OnPitRoad || td.Lap != 3 || td.SessionState != utils.StateRacing {
+		t.Errorf("got OnPitRoad %v, Lap %v and SessionState %v", td.OnPitRoad, td.Lap, td.SessionState)
+	}
+	if td.SessionFlags != utils.GreenFlag || td.EngineWarnings != utils.PitSpeedLimiter {
+		t.Errorf("got SessionFlags %v and EngineWarnings %v", td.SessionFlags, td.EngineWarnings)
+	}
+	if td.Speed != 42 || td.SessionTime != 12.5 {
+		t.Errorf("got Speed %v and SessionTime %v", td.Speed, td.SessionTime)
+	}
+
+	if err := td.AddIrIntVar(&irIntVar{name: "NoSuchVar"}); err != ErrUnknownVar {
+		t.Errorf("NoSuchVar: got %v, want %v", err, ErrUnknownVar)
+	}
+	if err := td.AddIrIntVar(&irIntVar{name: "CarIdxLap"}); err != ErrWrongVarType {
+		t.Errorf("CarIdxLap: got %v, want %v", err, ErrWrongVarType)
+	}
+	if err := td.AddIrFloatVar(&irFloatVar{name: "Lap"}); err != ErrWrongVarType {
+		t.Errorf("Lap: got %v, want %v", err, ErrWrongVarType)
+	}
+}
+
+func TestBytesToTelemetryStructFilteredLogsErrors(t *testing.T) {
+	_, conn := newFakeConnection(t)
+
+	buf := &bytes.Buffer{}
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+
+	td := conn.BytesToTelemetryStructFiltered([]byte{1, 2, 3}, []string{"Speed"})
+	if *td != (TelemetryData{}) {
+		t.Errorf("got %+v from a short buffer", td)
+	}
+	if !strings.Contains(buf.String(), ErrBufferTooSmall.Error()) {
+		t.Errorf("logged %q, want %v", buf.String(), ErrBufferTooSmall)
+	}
+}
@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type bitName struct {
+	mask uint32
+	name string
+}
+
+var flagNames = []bitName{
+	{uint32(CheckeredFlag), "Checkered"},
+	{uint32(WhiteFlag), "White"},
+	{uint32(GreenFlag), "Green"},
+	{uint32(YellowFlag), "Yellow"},
+	{uint32(RedFlag), "Red"},
+	{uint32(BlueFlag), "Blue"},
+	{uint32(DebrisFlag), "Debris"},
+	{uint32(CrossedFlag), "Crossed"},
+	{uint32(YellowWavingFlag), "YellowWaving"},
+	{uint32(OneLapToGreenFlag), "OneLapToGreen"},
+	{uint32(GreenHeldFlag), "GreenHeld"},
+	{uint32(TenToGoFlag), "TenToGo"},
+	{uint32(FiveToGoFlag), "FiveToGo"},
+	{uint32(RandomWavingFlag), "RandomWaving"},
+	{uint32(CautionFlag), "Caution"},
+	{uint32(CautionWavingFlag), "CautionWaving"},
+	{uint32(BlackFlag), "Black"},
+	{uint32(DisqualifyFlag), "Disqualify"},
+	{uint32(ServicibleFlag), "Servicible"},
+	{uint32(FurledFlag), "Furled"},
+	{uint32(RepairFlag), "Repair"},
+	{uint32(StartHidden), "StartHidden"},
+	{uint32(StartReady), "StartReady"},
+	{uint32(StartSet), "StartSet"},
+	{uint32(StartGo), "StartGo"},
+}
+
+var engineWarningNames = []bitName{
+	{uint32(WaterTempWarning), "WaterTempWarning"},
+	{uint32(FuelPressureWarning), "FuelPressureWarning"},
+	{uint32(OilPressureWarning), "OilPressureWarning"},
+	{uint32(EngineStalled), "EngineStalled"},
+	{uint32(PitSpeedLimiter), "PitSpeedLimiter"},
+	{uint32(RevLimiterActive), "RevLimiterActive"},
+}
+
+var cameraStateNames = []bitName{
+	{uint32(IsSessionScreen), "IsSessionScreen"},
+	{uint32(IsScenicActive), "IsScenicActive"},
+	{uint32(CamToolActive), "CamToolActive"},
+	{uint32(UIHidden), "UIHidden"},
+	{uint32(UseAutoShotSelection), "UseAutoShotSelection"},
+	{uint32(UseTemporaryEdits), "UseTemporaryEdits"},
+	{uint32(UseKeyAcceleration), "UseKeyAcceleration"},
+	{uint32(UseKey10xAcceleration), "UseKey10xAcceleration"},
+	{uint32(UseMouseAimMode), "UseMouseAimMode"},
+}
+
+var pitSvFlagNames = []bitName{
+	{uint32(LFTireChange), "LFTireChange"},
+	{uint32(RFTireChange), "RFTireChange"},
+	{uint32(LRTireChange), "LRTireChange"},
+	{uint32(RRTireChange), "RRTireChange"},
+	{uint32(FuelFill), "FuelFill"},
+	{uint32(WindshieldTearoff), "WindshieldTearoff"},
+	{uint32(FastRepair), "FastRepair"},
+}
+
+// bitNames returns the names of the bits set in v. Bits without a name are
+// kept as a single hex value (0x00000800).
+func bitNames(v uint32, names []bitName) []string {
+	set := []string{}
+	for _, bit := range names {
+		if v&bit.mask != 0 {
+			set = append(set, bit.name)
+			v = v &^ bit.mask
+		}
+	}
+
+	if v != 0 {
+		set = append(set, fmt.Sprintf("0x%08x", v))
+	}
+
+	return set
+}
+
+func parseBitNames(set []string, names []bitName) (uint32, error) {
+	v := uint32(0)
+	for _, s := range set {
+		found := false
+		for _, bit := range names {
+			if bit.name == s {
+				v = v | bit.mask
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		var unknown uint32
+		_, err := fmt.Sscanf(s, "0x%x", &unknown)
+		if err != nil {
+			return 0, fmt.Errorf("Unknown bit %q", s)
+		}
+		v = v | unknown
+	}
+
+	return v, nil
+}
+
+func bitString(v uint32, names []bitName) string {
+	if v == 0 {
+		return "0"
+	}
+
+	return strings.Join(bitNames(v, names), "|")
+}
+
+func unmarshalBits(data []byte, names []bitName) (uint32, error) {
+	set := []string{}
+	err := json.Unmarshal(data, &set)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseBitNames(set, names)
+}
+
+// Has reports if all bits of flag are set
+func (f Flags) Has(flag Flags) bool {
+	return f&flag == flag
+}
+
+// Names returns the names of the set bits
+func (f Flags) Names() []string {
+	return bitNames(uint32(f), flagNames)
+}
+
+func (f Flags) String() string {
+	return bitString(uint32(f), flagNames)
+}
+
+// MarshalJSON encodes the set bits as a list of names
+func (f Flags) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Names())
+}
+
+func (f *Flags) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalBits(data, flagNames)
+	*f = Flags(v)
+	return err
+}
+
+// Has reports if all bits of warning are set
+func (w EngineWarnings) Has(warning EngineWarnings) bool {
+	return w&warning == warning
+}
+
+// Names returns the names of the set bits
+func (w EngineWarnings) Names() []string {
+	return bitNames(uint32(w), engineWarningNames)
+}
+
+func (w EngineWarnings) String() string {
+	return bitString(uint32(w), engineWarningNames)
+}
+
+// MarshalJSON encodes the set bits as a list of names
+func (w EngineWarnings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.Names())
+}
+
+func (w *EngineWarnings) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalBits(data, engineWarningNames)
+	*w = EngineWarnings(v)
+	return err
+}
+
+// Has reports if all bits of state are set
+func (s CameraState) Has(state CameraState) bool {
+	return s&state == state
+}
+
+// Names returns the names of the set bits
+func (s CameraState) Names() []string {
+	return bitNames(uint32(s), cameraStateNames)
+}
+
+func (s CameraState) String() string {
+	return bitString(uint32(s), cameraStateNames)
+}
+
+// MarshalJSON encodes the set bits as a list of names
+func (s CameraState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Names())
+}
+
+func (s *CameraState) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalBits(data, cameraStateNames)
+	*s = CameraState(v)
+	return err
+}
+
+// Has reports if all bits of flag are set
+func (f PitSvFlag) Has(flag PitSvFlag) bool {
+	return f&flag == flag
+}
+
+// Names returns the names of the set bits
+func (f PitSvFlag) Names() []string {
+	return bitNames(uint32(f), pitSvFlagNames)
+}
+
+func (f PitSvFlag) String() string {
+	return bitString(uint32(f), pitSvFlagNames)
+}
+
+// MarshalJSON encodes the set bits as a list of names
+func (f PitSvFlag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Names())
+}
+
+func (f *PitSvFlag) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalBits(data, pitSvFlagNames)
+	*f = PitSvFlag(v)
+	return err
+}
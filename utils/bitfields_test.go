@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFlags(t *testing.T) {
+	f := GreenFlag | ServicibleFlag | Flags(0x00200000)
+
+	if !f.Has(GreenFlag) || !f.Has(GreenFlag|ServicibleFlag) || f.Has(GreenFlag|YellowFlag) {
+		t.Errorf("Has: wrong result for %v", f)
+	}
+
+	want := []string{"Green", "Servicible", "0x00200000"}
+	if names := f.Names(); !reflect.DeepEqual(names, want) {
+		t.Errorf("Names: got %v, want %v", names, want)
+	}
+	if s := f.String(); s != "Green|Servicible|0x00200000" {
+		t.Errorf("String: got %q", s)
+	}
+	if s := Flags(0).String(); s != "0" {
+		t.Errorf("String of no bits: got %q", s)
+	}
+}
+
+func TestBitfieldJSON(t *testing.T) {
+	f := CheckeredFlag | BlueFlag | Flags(0x00200000)
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `["Checkered","Blue","0x00200000"]` {
+		t.Errorf("MarshalJSON: got %s", b)
+	}
+
+	var decoded Flags
+	err = json.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != f {
+		t.Errorf("UnmarshalJSON: got %v, want %v", decoded, f)
+	}
+
+	var w EngineWarnings
+	err = json.Unmarshal([]byte(`["PitSpeedLimiter","RevLimiterActive"]`), &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != PitSpeedLimiter|RevLimiterActive {
+		t.Errorf("EngineWarnings: got %v", w)
+	}
+
+	var s CameraState
+	err = json.Unmarshal([]byte(`["Unknown"]`), &s)
+	if err == nil {
+		t.Error("unknown bit name didn't fail")
+	}
+}
+
+func TestBitfieldNames(t *testing.T) {
+	if s := (FuelFill | FastRepair).String(); s != "FuelFill|FastRepair" {
+		t.Errorf("PitSvFlag: got %q", s)
+	}
+	if s := (IsScenicActive | UIHidden).String(); s != "IsScenicActive|UIHidden" {
+		t.Errorf("CameraState: got %q", s)
+	}
+	if s := EngineStalled.String(); s != "EngineStalled" {
+		t.Errorf("EngineWarnings: got %q", s)
+	}
+}
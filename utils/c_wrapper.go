@@ -1,10 +1,28 @@
 package utils
 
-import "unsafe"
+import (
+	"time"
+	"unsafe"
+)
 
 const (
 )
 
+// wrapper is implemented by every backend that gives Irsdk access to the
+// shared memory: CWrapper (syscalls / rpc) and MmapWrapper (plain mmap)
+type wrapper interface {
+	startup() error
+	shutdown() error
+
+	getHeader() (*Header, error)
+	getVarHeaderEntry(index int) (*VarHeader, error)
+	memory() []byte
+
+	WaitForDataChange(timeout time.Duration) error
+	RegisterWindowMessageW(lpString string) (uint, error)
+	SendNotifyMessageW(msgID uint, wParam uint32, lParam uint32) error
+}
+
 func (cw *CWrapper) getHeader() (*Header, error) {
 	return (*Header)(cw.sharedMemPtr), nil
 }
@@ -22,3 +40,7 @@ func (cw *CWrapper) getVarHeaderEntry(index int) (*VarHeader, error) {
 
 	return (*VarHeader)(unsafe.Pointer(varHeaderPtr)), nil
 }
+
+func (cw *CWrapper) memory() []byte {
+	return cw.sharedMem
+}
@@ -107,6 +107,11 @@ func (cw *CWrapper) shutdown() error {
 }
 
 func (cw *CWrapper) getMmapFile() (*os.File, error) {
+	return openSharedMemFile()
+}
+
+// openSharedMemFile opens the fd of the wine shared memory iRacing writes to
+func openSharedMemFile() (*os.File, error) {
 	var err error
 
 	wineshm.WineCmd = WineCmd
@@ -210,7 +215,11 @@ func (cw *CWrapper) RegisterWindowMessageA(lpString string) (uint, error) {
 }
 
 func (cw *CWrapper) RegisterWindowMessageW(lpString string) (uint, error) {
-	return 0, nil
+	var msgID uint
+	args := &RegisterWindowMessageArgs{lpString}
+
+	err := cw.client.Call("Commands.RegisterWindowMessageW", args, &msgID)
+	return msgID, err
 }
 
 func (cw *CWrapper) SendNotifyMessage(msgID uint, wParam uint32, lParam uint32) error {
@@ -218,7 +227,10 @@ func (cw *CWrapper) SendNotifyMessage(msgID uint, wParam uint32, lParam uint32)
 }
 
 func (cw *CWrapper) SendNotifyMessageW(msgID uint, wParam uint32, lParam uint32) error {
-	return nil
+	retVal := new(bool)
+	args := &SendNotifyMessageArgs{msgID, wParam, lParam}
+
+	return cw.client.Call("Commands.SendNotifyMessageW", args, retVal)
 }
 
 func NewCWrapper() (*CWrapper, error) {
@@ -3,6 +3,7 @@
 package utils
 
 import (
+	"os"
 	"time"
 	"unsafe"
 
@@ -83,6 +84,12 @@ func (cw *CWrapper) WaitForDataChange(timeout time.Duration) error {
 	return cw.WaitForSingleObject(cw.hDataValidEvent, int(timeout/time.Millisecond))
 }
 
+// openSharedMemFile is only supported on Linux: on Windows the shared memory
+// isn't backed by a file, use CWrapper instead
+func openSharedMemFile() (*os.File, error) {
+	return nil, ErrMmapUnsupported
+}
+
 // Syscalls
 
 func (cw *CWrapper) OpenFileMapping(lpName string) (uintptr, error) {
@@ -44,6 +44,8 @@ package utils
    irsdk_broadcastMsg() function.
 */
 
+import "time"
+
 // Constant Definitions
 
 type StatusField int32
@@ -153,6 +155,78 @@ const (
 	StateCoolDown   SessionState = iota
 )
 
+// Track surface material (CarIdxTrackSurfaceMaterial)
+type TrkSurf int32
+
+const (
+	SurfaceNotInWorld TrkSurf = -1
+	UndefinedMaterial TrkSurf = 0
+
+	Asphalt1Material    TrkSurf = 1
+	Asphalt2Material    TrkSurf = 2
+	Asphalt3Material    TrkSurf = 3
+	Asphalt4Material    TrkSurf = 4
+	Concrete1Material   TrkSurf = 5
+	Concrete2Material   TrkSurf = 6
+	RacingDirt1Material TrkSurf = 7
+	RacingDirt2Material TrkSurf = 8
+	Paint1Material      TrkSurf = 9
+	Paint2Material      TrkSurf = 10
+	Rumble1Material     TrkSurf = 11
+	Rumble2Material     TrkSurf = 12
+	Rumble3Material     TrkSurf = 13
+	Rumble4Material     TrkSurf = 14
+	Grass1Material      TrkSurf = 15
+	Grass2Material      TrkSurf = 16
+	Grass3Material      TrkSurf = 17
+	Grass4Material      TrkSurf = 18
+	Dirt1Material       TrkSurf = 19
+	Dirt2Material       TrkSurf = 20
+	Dirt3Material       TrkSurf = 21
+	Dirt4Material       TrkSurf = 22
+	SandMaterial        TrkSurf = 23
+	Gravel1Material     TrkSurf = 24
+	Gravel2Material     TrkSurf = 25
+	GrasscreteMaterial  TrkSurf = 26
+	AstroturfMaterial   TrkSurf = 27
+)
+
+// Spotter (CarLeftRight)
+type CarLeftRight int32
+
+const (
+	LROff          CarLeftRight = 0
+	LRClear        CarLeftRight = 1 // no cars around us
+	LRCarLeft      CarLeftRight = 2 // there is a car to our left
+	LRCarRight     CarLeftRight = 3 // there is a car to our right
+	LRCarLeftRight CarLeftRight = 4 // there are cars on each side
+	LR2CarsLeft    CarLeftRight = 5 // there are two cars to our left
+	LR2CarsRight   CarLeftRight = 6 // there are two cars to our right
+)
+
+type PaceMode int32
+
+const (
+	PaceModeSingleFileStart   PaceMode = 0
+	PaceModeDoubleFileStart   PaceMode = 1
+	PaceModeSingleFileRestart PaceMode = 2
+	PaceModeDoubleFileRestart PaceMode = 3
+	PaceModeNotPacing         PaceMode = 4
+)
+
+type TrackWetness int32
+
+const (
+	TrackWetnessUnknown        TrackWetness = 0
+	TrackWetnessDry            TrackWetness = 1
+	TrackWetnessMostlyDry      TrackWetness = 2
+	TrackWetnessVeryLightlyWet TrackWetness = 3
+	TrackWetnessLightlyWet     TrackWetness = 4
+	TrackWetnessModeratelyWet  TrackWetness = 5
+	TrackWetnessVeryWet        TrackWetness = 6
+	TrackWetnessExtremelyWet   TrackWetness = 7
+)
+
 type CameraState int32
 
 const (
@@ -224,13 +298,18 @@ type Header struct {
 	VarBuf [MAX_BUFS]VarBuf
 }
 
-// sub header used when writing telemetry to disk
+// sub header used when writing telemetry to disk (directly after Header)
 type DiskSubHeader struct {
-	sessionStartDate   int32
-	sessionStartTime   float64
-	sessionEndTime     float64
-	sessionLapCount    int32
-	sessionRecordCount int32
+	SessionStartDate   int64   // time_t, seconds since the unix epoch
+	SessionStartTime   float64 // SessionTime of the first record
+	SessionEndTime     float64 // SessionTime of the last record
+	SessionLapCount    int32
+	SessionRecordCount int32
+}
+
+// StartDate returns SessionStartDate as a time.Time
+func (sh *DiskSubHeader) StartDate() time.Time {
+	return time.Unix(sh.SessionStartDate, 0)
 }
 
 func (header *Header) GetLatestVarBufN() int {
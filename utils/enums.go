@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+)
+
+var trkLocNames = map[int32]string{
+	int32(NotInWorld):     "NotInWorld",
+	int32(OffTrack):       "OffTrack",
+	int32(InPitStall):     "InPitStall",
+	int32(AproachingPits): "ApproachingPits",
+	int32(OnTrac):         "OnTrack",
+}
+
+var trkSurfNames = map[int32]string{
+	int32(SurfaceNotInWorld):   "NotInWorld",
+	int32(UndefinedMaterial):   "Undefined",
+	int32(Asphalt1Material):    "Asphalt1",
+	int32(Asphalt2Material):    "Asphalt2",
+	int32(Asphalt3Material):    "Asphalt3",
+	int32(Asphalt4Material):    "Asphalt4",
+	int32(Concrete1Material):   "Concrete1",
+	int32(Concrete2Material):   "Concrete2",
+	int32(RacingDirt1Material): "RacingDirt1",
+	int32(RacingDirt2Material): "RacingDirt2",
+	int32(Paint1Material):      "Paint1",
+	int32(Paint2Material):      "Paint2",
+	int32(Rumble1Material):     "Rumble1",
+	int32(Rumble2Material):     "Rumble2",
+	int32(Rumble3Material):     "Rumble3",
+	int32(Rumble4Material):     "Rumble4",
+	int32(Grass1Material):      "Grass1",
+	int32(Grass2Material):      "Grass2",
+	int32(Grass3Material):      "Grass3",
+	int32(Grass4Material):      "Grass4",
+	int32(Dirt1Material):       "Dirt1",
+	int32(Dirt2Material):       "Dirt2",
+	int32(Dirt3Material):       "Dirt3",
+	int32(Dirt4Material):       "Dirt4",
+	int32(SandMaterial):        "Sand",
+	int32(Gravel1Material):     "Gravel1",
+	int32(Gravel2Material):     "Gravel2",
+	int32(GrasscreteMaterial):  "Grasscrete",
+	int32(AstroturfMaterial):   "Astroturf",
+}
+
+var sessionStateNames = map[int32]string{
+	int32(StateInvalid):    "Invalid",
+	int32(StateGetInCar):   "GetInCar",
+	int32(StateWarmup):     "Warmup",
+	int32(StateParadeLaps): "ParadeLaps",
+	int32(StateRacing):     "Racing",
+	int32(StateCheckered):  "Checkered",
+	int32(StateCoolDown):   "CoolDown",
+}
+
+var carLeftRightNames = map[int32]string{
+	int32(LROff):          "Off",
+	int32(LRClear):        "Clear",
+	int32(LRCarLeft):      "CarLeft",
+	int32(LRCarRight):     "CarRight",
+	int32(LRCarLeftRight): "CarLeftRight",
+	int32(LR2CarsLeft):    "2CarsLeft",
+	int32(LR2CarsRight):   "2CarsRight",
+}
+
+var paceModeNames = map[int32]string{
+	int32(PaceModeSingleFileStart):   "SingleFileStart",
+	int32(PaceModeDoubleFileStart):   "DoubleFileStart",
+	int32(PaceModeSingleFileRestart): "SingleFileRestart",
+	int32(PaceModeDoubleFileRestart): "DoubleFileRestart",
+	int32(PaceModeNotPacing):         "NotPacing",
+}
+
+var trackWetnessNames = map[int32]string{
+	int32(TrackWetnessUnknown):        "Unknown",
+	int32(TrackWetnessDry):            "Dry",
+	int32(TrackWetnessMostlyDry):      "MostlyDry",
+	int32(TrackWetnessVeryLightlyWet): "VeryLightlyWet",
+	int32(TrackWetnessLightlyWet):     "LightlyWet",
+	int32(TrackWetnessModeratelyWet):  "ModeratelyWet",
+	int32(TrackWetnessVeryWet):        "VeryWet",
+	int32(TrackWetnessExtremelyWet):   "ExtremelyWet",
+}
+
+// enumString returns the name of v or typ(v) for values without a name
+func enumString(v int32, names map[int32]string, typ string) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("%s(%d)", typ, v)
+}
+
+// enumText is the text form of v: its name or, for values without a name, the
+// number so it can be read back
+func enumText(v int32, names map[int32]string) []byte {
+	if name, ok := names[v]; ok {
+		return []byte(name)
+	}
+
+	return []byte(strconv.Itoa(int(v)))
+}
+
+// parseEnum parses a name or a number
+func parseEnum(text []byte, names map[int32]string, typ string) (int32, error) {
+	s := string(text)
+	for v, name := range names {
+		if name == s {
+			return v, nil
+		}
+	}
+
+	v, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Unknown %s %q", typ, s)
+	}
+
+	return int32(v), nil
+}
+
+func (l TrkLoc) String() string {
+	return enumString(int32(l), trkLocNames, "TrkLoc")
+}
+
+// Valid reports if l is a known track location
+func (l TrkLoc) Valid() bool {
+	_, ok := trkLocNames[int32(l)]
+	return ok
+}
+
+func (l TrkLoc) MarshalText() ([]byte, error) {
+	return enumText(int32(l), trkLocNames), nil
+}
+
+func (l *TrkLoc) UnmarshalText(text []byte) error {
+	v, err := parseEnum(text, trkLocNames, "TrkLoc")
+	*l = TrkLoc(v)
+	return err
+}
+
+func (s TrkSurf) String() string {
+	return enumString(int32(s), trkSurfNames, "TrkSurf")
+}
+
+// Valid reports if s is a known surface material
+func (s TrkSurf) Valid() bool {
+	_, ok := trkSurfNames[int32(s)]
+	return ok
+}
+
+func (s TrkSurf) MarshalText() ([]byte, error) {
+	return enumText(int32(s), trkSurfNames), nil
+}
+
+func (s *TrkSurf) UnmarshalText(text []byte) error {
+	v, err := parseEnum(text, trkSurfNames, "TrkSurf")
+	*s = TrkSurf(v)
+	return err
+}
+
+func (s SessionState) String() string {
+	return enumString(int32(s), sessionStateNames, "SessionState")
+}
+
+// Valid reports if s is a known session state
+func (s SessionState) Valid() bool {
+	_, ok := sessionStateNames[int32(s)]
+	return ok
+}
+
+func (s SessionState) MarshalText() ([]byte, error) {
+	return enumText(int32(s), sessionStateNames), nil
+}
+
+func (s *SessionState) UnmarshalText(text []byte) error {
+	v, err := parseEnum(text, sessionStateNames, "SessionState")
+	*s = SessionState(v)
+	return err
+}
+
+func (lr CarLeftRight) String() string {
+	return enumString(int32(lr), carLeftRightNames, "CarLeftRight")
+}
+
+// Valid reports if lr is a known spotter value
+func (lr CarLeftRight) Valid() bool {
+	_, ok := carLeftRightNames[int32(lr)]
+	return ok
+}
+
+func (lr CarLeftRight) MarshalText() ([]byte, error) {
+	return enumText(int32(lr), carLeftRightNames), nil
+}
+
+func (lr *CarLeftRight) UnmarshalText(text []byte) error {
+	v, err := parseEnum(text, carLeftRightNames, "CarLeftRight")
+	*lr = CarLeftRight(v)
+	return err
+}
+
+func (m PaceMode) String() string {
+	return enumString(int32(m), paceModeNames, "PaceMode")
+}
+
+// Valid reports if m is a known pace mode
+func (m PaceMode) Valid() bool {
+	_, ok := paceModeNames[int32(m)]
+	return ok
+}
+
+func (m PaceMode) MarshalText() ([]byte, error) {
+	return enumText(int32(m), paceModeNames), nil
+}
+
+func (m *PaceMode) UnmarshalText(text []byte) error {
+	v, err := parseEnum(text, paceModeNames, "PaceMode")
+	*m = PaceMode(v)
+	return err
+}
+
+func (w TrackWetness) String() string {
+	return enumString(int32(w), trackWetnessNames, "TrackWetness")
+}
+
+// Valid reports if w is a known wetness
+func (w TrackWetness) Valid() bool {
+	_, ok := trackWetnessNames[int32(w)]
+	return ok
+}
+
+func (w TrackWetness) MarshalText() ([]byte, error) {
+	return enumText(int32(w), trackWetnessNames), nil
+}
+
+func (w *TrackWetness) UnmarshalText(text []byte) error {
+	v, err := parseEnum(text, trackWetnessNames, "TrackWetness")
+	*w = TrackWetness(v)
+	return err
+}
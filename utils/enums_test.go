@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnumString(t *testing.T) {
+	tests := []struct {
+		v    interface{ String() string }
+		want string
+	}{
+		{NotInWorld, "NotInWorld"},
+		{AproachingPits, "ApproachingPits"},
+		{SurfaceNotInWorld, "NotInWorld"},
+		{AstroturfMaterial, "Astroturf"},
+		{StateRacing, "Racing"},
+		{LR2CarsLeft, "2CarsLeft"},
+		{PaceModeNotPacing, "NotPacing"},
+		{TrackWetnessExtremelyWet, "ExtremelyWet"},
+		{SessionState(42), "SessionState(42)"},
+		{TrkLoc(-5), "TrkLoc(-5)"},
+	}
+
+	for _, test := range tests {
+		if s := test.v.String(); s != test.want {
+			t.Errorf("got %q, want %q", s, test.want)
+		}
+	}
+}
+
+func TestEnumValid(t *testing.T) {
+	if !StateCoolDown.Valid() || SessionState(7).Valid() {
+		t.Error("SessionState.Valid")
+	}
+	if !OnTrac.Valid() || TrkLoc(4).Valid() {
+		t.Error("TrkLoc.Valid")
+	}
+	if !LROff.Valid() || CarLeftRight(-1).Valid() {
+		t.Error("CarLeftRight.Valid")
+	}
+}
+
+func TestEnumText(t *testing.T) {
+	type state struct {
+		Surface  TrkLoc
+		Material TrkSurf
+		Session  SessionState
+		Pace     PaceMode
+		Wetness  TrackWetness
+	}
+	in := state{OnTrac, Grass2Material, SessionState(42), PaceModeDoubleFileRestart, TrackWetnessDry}
+
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"Surface":"OnTrack","Material":"Grass2","Session":"42","Pace":"DoubleFileRestart","Wetness":"Dry"}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var out state
+	err = json.Unmarshal(b, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+
+	var lr CarLeftRight
+	if err := lr.UnmarshalText([]byte("CarBehind")); err == nil {
+		t.Error("unknown name didn't fail")
+	}
+}
@@ -18,6 +18,8 @@ const (
 	MAX_STRING = 32
 	// descriptions can be longer than max_string!
 	MAX_DESC = 64
+	// number of entries in the CarIdx* arrays
+	MAX_CARS = 64
 
 	TIMEOUT = time.Duration(time.Second * 30) // timeout after 30 seconds with no communication
 )
@@ -35,7 +37,8 @@ type Irsdk struct {
 	lastTickCount int32
 
 	// Syscalls & pointer arithmetic goes into cwrapper
-	c *CWrapper
+	c          wrapper
+	newWrapper func() (wrapper, error)
 }
 
 func NewIrsdk() (*Irsdk, error) {
@@ -44,7 +47,22 @@ func NewIrsdk() (*Irsdk, error) {
 		return nil, err
 	}
 
-	return &Irsdk{c: cw}, nil
+	return &Irsdk{c: cw, newWrapper: newCWrapper}, nil
+}
+
+// NewMmapIrsdk creates an Irsdk that maps the shared memory directly from path
+// and polls it for new data. When path is empty the shared memory of the sim
+// itself is mapped (on Linux: the wine shared memory).
+func NewMmapIrsdk(path string, poll PollStrategy) *Irsdk {
+	newWrapper := func() (wrapper, error) {
+		return NewMmapWrapper(path, poll), nil
+	}
+
+	return &Irsdk{newWrapper: newWrapper}
+}
+
+func newCWrapper() (wrapper, error) {
+	return NewCWrapper()
 }
 
 func (ir *Irsdk) Startup() error {
@@ -52,7 +70,11 @@ func (ir *Irsdk) Startup() error {
 
 	// Create c wrapper if it doesn't exist
 	if ir.c == nil {
-		ir.c, err = NewCWrapper()
+		if ir.newWrapper == nil {
+			ir.newWrapper = newCWrapper
+		}
+
+		ir.c, err = ir.newWrapper()
 		if err != nil {
 			return err
 		}
@@ -92,7 +114,7 @@ func (ir *Irsdk) GetNewData() ([]byte, error) {
 		}
 	}
 
-	header := ir.c.header
+	header := ir.header()
 
 	// if sim is not active, then no new data
 	if (header.Status & StatusConnected) == 0 {
@@ -161,7 +183,7 @@ func (ir *Irsdk) WaitForDataReady(timeOut time.Duration) ([]byte, error) {
 func (ir *Irsdk) IsConnected() bool {
 	if ir.isInitialized {
 		elapsed := time.Now().Sub(ir.lastValidTime)
-		header := ir.c.header
+		header := ir.header()
 		if (header.Status&StatusConnected) > 0 && (elapsed < TIMEOUT) {
 			return true
 		}
@@ -172,17 +194,17 @@ func (ir *Irsdk) IsConnected() bool {
 
 func (ir *Irsdk) GetSessionInfoStr() []byte {
 	if ir.isInitialized {
-		header := ir.c.header
+		header := ir.header()
 		startByte := header.SessionInfoOffset
 		length := header.SessionInfoLen
-		return ir.c.sharedMem[startByte:length]
+		return ir.c.memory()[startByte : startByte+length]
 	}
 	return nil
 }
 
 func (ir *Irsdk) GetVarHeaderEntry(index int) (*VarHeader, error) {
 	if ir.isInitialized {
-		header := ir.c.header
+		header := ir.header()
 		if index >= 0 && index < (int)(header.NumVars) {
 			return ir.c.getVarHeaderEntry(index)
 		}
@@ -193,7 +215,7 @@ func (ir *Irsdk) GetVarHeaderEntry(index int) (*VarHeader, error) {
 // Note: this is a linear search, so cache the results
 func (ir *Irsdk) VarNameToIndex(name string) (int, error) {
 	if name != "" {
-		header := ir.c.header
+		header := ir.header()
 		numVars := int(header.NumVars)
 		for index := 0; index <= numVars; index++ {
 			pVar, err := ir.GetVarHeaderEntry(index)
@@ -212,7 +234,7 @@ func (ir *Irsdk) VarNameToIndex(name string) (int, error) {
 
 func (ir *Irsdk) VarNameToOffset(name string) (int, error) {
 	if name != "" {
-		header := ir.c.header
+		header := ir.header()
 		numVars := int(header.NumVars)
 		for index := 0; index <= numVars; index++ {
 			pVar, err := ir.GetVarHeaderEntry(index)
@@ -279,7 +301,7 @@ func (ir *Irsdk) PadCarNum(num int, zero int) int {
 // Custom functions
 
 func (ir *Irsdk) GetNumVars() int {
-	return int(ir.c.header.NumVars)
+	return int(ir.header().NumVars)
 }
 
 func (ir *Irsdk) GetBroadcastMsgID() (uint, error) {
@@ -287,25 +309,41 @@ func (ir *Irsdk) GetBroadcastMsgID() (uint, error) {
 }
 
 func (ir *Irsdk) copyTelemetryData(varBufN int) ([]byte, error) {
-	header := ir.c.header
+	header := ir.header()
 	bufLen := int(header.BufLen)
 	startByte := int(header.VarBuf[varBufN].BufOffset)
 	endByte := startByte + bufLen
 
 	data := make([]byte, bufLen)
-	copy(data, ir.c.sharedMem[startByte:endByte])
+	copy(data, ir.c.memory()[startByte:endByte])
 
 	return data, nil
 }
 
 func (ir *Irsdk) GetHeader() (*Header, error) {
-	return ir.c.header, nil
+	return ir.header(), nil
+}
+
+// header returns the header at the start of the shared memory
+func (ir *Irsdk) header() *Header {
+	if ir.c == nil {
+		return nil
+	}
+
+	header, _ := ir.c.getHeader()
+	return header
 }
 
 func (ir *Irsdk) GetLastValidTime() time.Time {
 	return ir.lastValidTime
 }
 
+// GetLastTickCount returns the tick count of the data last returned by
+// GetNewData
+func (ir *Irsdk) GetLastTickCount() int32 {
+	return ir.lastTickCount
+}
+
 func MAKELONG(lo, hi uint16) uint32 {
 	return uint32(uint32(lo) | ((uint32(hi)) << 16))
 }
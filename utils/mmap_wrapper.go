@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	mmap "github.com/edsrzf/mmap-go"
+)
+
+var (
+	ErrMmapUnsupported = errors.New("Mapping the shared memory directly is not supported on this platform")
+	ErrMmapTooSmall    = errors.New("Mapped file is too small to contain an irsdk header")
+)
+
+// DefaultPollStrategy checks the tick count four times per tick
+var DefaultPollStrategy PollStrategy = TickRatePoll(4)
+
+// PollStrategy decides how long MmapWrapper sleeps between two checks of the
+// tick counts in the var buffers
+type PollStrategy interface {
+	// Interval returns the time to sleep before check number attempt
+	Interval(header *Header, attempt int) time.Duration
+}
+
+// FixedPoll checks the tick count at a fixed interval
+type FixedPoll time.Duration
+
+func (p FixedPoll) Interval(header *Header, attempt int) time.Duration {
+	return time.Duration(p)
+}
+
+// TickRatePoll checks the tick count n times per tick of the sim (based on
+// header.TickRate)
+type TickRatePoll int
+
+func (p TickRatePoll) Interval(header *Header, attempt int) time.Duration {
+	tickRate := 60
+	if header != nil && header.TickRate > 0 {
+		tickRate = int(header.TickRate)
+	}
+
+	checks := int(p)
+	if checks < 1 {
+		checks = 1
+	}
+
+	return time.Second / time.Duration(tickRate*checks)
+}
+
+// BackoffPoll starts checking at Min and doubles the interval after every
+// check until Max is reached. Useful when the sim is (mostly) idle.
+type BackoffPoll struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (p BackoffPoll) Interval(header *Header, attempt int) time.Duration {
+	interval := p.Min
+	for i := 0; i < attempt && interval < p.Max; i++ {
+		interval = interval * 2
+	}
+
+	if interval > p.Max {
+		return p.Max
+	}
+
+	return interval
+}
+
+// MmapWrapper reads the shared memory by mapping a file directly. New data is
+// detected by polling the tick counts so reading doesn't need any syscalls
+// through the ir-syscalls-rpc helper. Only broadcast messages still go through
+// a CWrapper, which is created the first time one is sent.
+type MmapWrapper struct {
+	path string
+	poll PollStrategy
+
+	file      *os.File
+	sharedMem mmap.MMap
+	header    *Header
+
+	broadcasterMu sync.Mutex
+	broadcaster   *CWrapper
+}
+
+// NewMmapWrapper creates a wrapper that maps path. When path is empty the
+// shared memory of the sim is used (on Linux: the wine shared memory). When
+// poll is nil DefaultPollStrategy is used.
+func NewMmapWrapper(path string, poll PollStrategy) *MmapWrapper {
+	if poll == nil {
+		poll = DefaultPollStrategy
+	}
+
+	return &MmapWrapper{path: path, poll: poll}
+}
+
+func (mw *MmapWrapper) startup() error {
+	var err error
+
+	if mw.file == nil {
+		mw.file, err = mw.openFile()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(mw.sharedMem) == 0 {
+		mw.sharedMem, err = mmap.Map(mw.file, mmap.RDONLY, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(mw.sharedMem) < int(unsafe.Sizeof(Header{})) {
+		return ErrMmapTooSmall
+	}
+
+	if mw.header == nil {
+		mw.header = (*Header)(unsafe.Pointer(&mw.sharedMem[0]))
+	}
+
+	return nil
+}
+
+func (mw *MmapWrapper) shutdown() error {
+	if mw.sharedMem != nil {
+		mw.sharedMem.Unmap()
+	}
+
+	if mw.file != nil {
+		mw.file.Close()
+	}
+
+	mw.broadcasterMu.Lock()
+	if mw.broadcaster != nil {
+		mw.broadcaster.shutdown()
+	}
+	mw.broadcaster = nil
+	mw.broadcasterMu.Unlock()
+
+	mw.file = nil
+	mw.sharedMem = nil
+	mw.header = nil
+
+	return nil
+}
+
+func (mw *MmapWrapper) openFile() (*os.File, error) {
+	if mw.path == "" {
+		return openSharedMemFile()
+	}
+
+	return os.Open(mw.path)
+}
+
+func (mw *MmapWrapper) getHeader() (*Header, error) {
+	return mw.header, nil
+}
+
+func (mw *MmapWrapper) getVarHeaderEntry(index int) (*VarHeader, error) {
+	varHeaderSize := int(unsafe.Sizeof(VarHeader{}))
+	startByte := int(mw.header.VarHeaderOffset) + index*varHeaderSize
+	endByte := startByte + varHeaderSize
+	if startByte < 0 || endByte > len(mw.sharedMem) {
+		return nil, nil
+	}
+
+	return (*VarHeader)(unsafe.Pointer(&mw.sharedMem[startByte])), nil
+}
+
+func (mw *MmapWrapper) memory() []byte {
+	return mw.sharedMem
+}
+
+// WaitForDataChange polls the tick count of the latest var buffer until it
+// changes or timeout passes
+func (mw *MmapWrapper) WaitForDataChange(timeout time.Duration) error {
+	header := mw.header
+	if header == nil {
+		return ErrInitialize
+	}
+
+	latest := header.GetLatestVarBufN()
+	prevTickCount := header.VarBuf[latest].TickCount
+	deadline := time.Now().Add(timeout)
+
+	for attempt := 0; ; attempt++ {
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			return ErrNothingChanged
+		}
+
+		interval := mw.poll.Interval(header, attempt)
+		if interval > remaining {
+			interval = remaining
+		}
+		time.Sleep(interval)
+
+		latest = header.GetLatestVarBufN()
+		if header.VarBuf[latest].TickCount != prevTickCount {
+			return nil
+		}
+	}
+}
+
+// Broadcast messages
+
+func (mw *MmapWrapper) getBroadcaster() (*CWrapper, error) {
+	mw.broadcasterMu.Lock()
+	defer mw.broadcasterMu.Unlock()
+
+	if mw.broadcaster != nil {
+		return mw.broadcaster, nil
+	}
+
+	cw, err := NewCWrapper()
+	if err != nil {
+		return nil, err
+	}
+
+	mw.broadcaster = cw
+	return cw, nil
+}
+
+func (mw *MmapWrapper) RegisterWindowMessageW(lpString string) (uint, error) {
+	cw, err := mw.getBroadcaster()
+	if err != nil {
+		return 0, err
+	}
+
+	return cw.RegisterWindowMessageW(lpString)
+}
+
+func (mw *MmapWrapper) SendNotifyMessageW(msgID uint, wParam uint32, lParam uint32) error {
+	cw, err := mw.getBroadcaster()
+	if err != nil {
+		return err
+	}
+
+	return cw.SendNotifyMessageW(msgID, wParam, lParam)
+}
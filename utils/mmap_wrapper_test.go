@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPollStrategies(t *testing.T) {
+	header := &Header{TickRate: 360}
+
+	tests := []struct {
+		poll    PollStrategy
+		header  *Header
+		attempt int
+		want    time.Duration
+	}{
+		{FixedPoll(5 * time.Millisecond), header, 3, 5 * time.Millisecond},
+		{TickRatePoll(4), nil, 0, time.Second / 240},
+		{TickRatePoll(4), header, 0, time.Second / 1440},
+		{TickRatePoll(0), &Header{}, 0, time.Second / 60},
+		{BackoffPoll{Min: time.Millisecond, Max: 10 * time.Millisecond}, header, 0, time.Millisecond},
+		{BackoffPoll{Min: time.Millisecond, Max: 10 * time.Millisecond}, header, 3, 8 * time.Millisecond},
+		{BackoffPoll{Min: time.Millisecond, Max: 10 * time.Millisecond}, header, 100, 10 * time.Millisecond},
+	}
+
+	for i, test := range tests {
+		if got := test.poll.Interval(test.header, test.attempt); got != test.want {
+			t.Errorf("%d: %T got %v, want %v", i, test.poll, got, test.want)
+		}
+	}
+}
+
+// writeMmapHeader writes a header with a single var buffer at tickCount
+func writeMmapHeader(f *os.File, tickCount int32) error {
+	header := Header{Ver: 2, Status: StatusConnected, TickRate: 60, NumBuf: 1, BufLen: 4}
+	header.VarBuf[0] = VarBuf{TickCount: tickCount, BufOffset: 256}
+
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, header)
+	_, err := f.WriteAt(buf.Bytes(), 0)
+	return err
+}
+
+func TestMmapWrapper(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "irsdk.mmap")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	err = f.Truncate(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writeMmapHeader(f, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mw := NewMmapWrapper(path, FixedPoll(time.Millisecond))
+	err = mw.startup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mw.shutdown()
+
+	header, _ := mw.getHeader()
+	if header.TickRate != 60 || header.VarBuf[0].TickCount != 1 {
+		t.Fatalf("got header %+v", header)
+	}
+
+	err = mw.WaitForDataChange(20 * time.Millisecond)
+	if err != ErrNothingChanged {
+		t.Errorf("without a new tick: got %v, want %v", err, ErrNothingChanged)
+	}
+
+	// A write to the file shows up in the mapping
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		writeMmapHeader(f, 2)
+	}()
+
+	err = mw.WaitForDataChange(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.VarBuf[0].TickCount != 2 {
+		t.Errorf("got tick count %d, want 2", header.VarBuf[0].TickCount)
+	}
+}
+
+func TestMmapWrapperTooSmall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "irsdk.mmap")
+	err := ioutil.WriteFile(path, make([]byte, 64), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mw := NewMmapWrapper(path, nil)
+	defer mw.shutdown()
+
+	if err := mw.startup(); err != ErrMmapTooSmall {
+		t.Errorf("got %v, want %v", err, ErrMmapTooSmall)
+	}
+	if err := mw.WaitForDataChange(time.Millisecond); err != ErrInitialize {
+		t.Errorf("WaitForDataChange: got %v, want %v", err, ErrInitialize)
+	}
+}
@@ -35,3 +35,13 @@ type GetMemoryArgs struct {
 	StartByte    int
 	EndByte      int
 }
+
+type RegisterWindowMessageArgs struct {
+	LpString string
+}
+
+type SendNotifyMessageArgs struct {
+	MsgID  uint
+	WParam uint32
+	LParam uint32
+}
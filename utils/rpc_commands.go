@@ -37,6 +37,18 @@ func (c *RpcCommands) WaitForSingleObject(args *WaitForSingleObjectArgs, retVal
 	return syscalls.WaitForSingleObject(args.HDataValidEvent, args.TimeOut)
 }
 
+func (c *RpcCommands) RegisterWindowMessageW(args *RegisterWindowMessageArgs, msgID *uint) error {
+	v, err := syscalls.RegisterWindowMessageW(args.LpString)
+	*msgID = v
+	return err
+}
+
+func (c *RpcCommands) SendNotifyMessageW(args *SendNotifyMessageArgs, retVal *bool) error {
+	err := syscalls.SendNotifyMessageW(args.MsgID, args.WParam, args.LParam)
+	*retVal = err == nil
+	return err
+}
+
 func (c *RpcCommands) PtrToHeader(args *PtrToHeaderArgs, header *Header) error {
 	*header = *(*Header)(unsafe.Pointer(args.SharedMemPtr))
 	return nil